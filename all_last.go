@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/fatih/color"
+	"math"
+	"strings"
+)
+
+var (
+	placementNames = []string{"一位", "二位", "三位", "四位"}
+	playerNames    = []string{"自家", "下家", "对家", "上家"}
+)
+
+// 终局时才做顺位分析
+func newAllLastAnalysis(playerInfo *model.PlayerInfo) *util.AllLastAnalysis {
+	if playerInfo.Standing == nil || !playerInfo.Standing.IsAllLast() {
+		return nil
+	}
+	return util.AnalysisAllLast(playerInfo.Standing)
+}
+
+func tsumoPointToStr(childPoint, parentPoint int) string {
+	if parentPoint == 0 {
+		return fmt.Sprintf("%d∀", childPoint)
+	}
+	return fmt.Sprintf("%d-%d", childPoint, parentPoint)
+}
+
+// 升至某一顺位所需的打点，如 "升至一位：荣和下家3900 对家5200 上家2000 自摸700-1300"
+func placementTargetToStr(a *util.AllLastAnalysis, t *util.PlacementTarget) string {
+	items := []string{}
+	for who, point := range t.MinRonPoints {
+		if who == 0 || !a.Standing.IsPresent(who) {
+			continue
+		}
+		if point == -1 {
+			items = append(items, playerNames[who]+"无法")
+		} else {
+			items = append(items, fmt.Sprintf("%s%d", playerNames[who], point))
+		}
+	}
+	s := "升至" + placementNames[t.Placement] + "：荣和" + strings.Join(items, " ")
+	if t.MinTsumoChildPoint == -1 {
+		s += " 自摸无法"
+	} else {
+		s += " 自摸" + tsumoPointToStr(t.MinTsumoChildPoint, t.MinTsumoParentPoint)
+	}
+	return s
+}
+
+// 放铳给各家时能否保持顺位，如 "放铳：下家7700以内 对家掉顺位 上家无妨"
+func dealInToStr(a *util.AllLastAnalysis) string {
+	items := []string{}
+	for who, point := range a.MaxDealInPoints {
+		if who == 0 || !a.Standing.IsPresent(who) {
+			continue
+		}
+		switch point {
+		case -1:
+			items = append(items, playerNames[who]+"无妨")
+		case 0:
+			items = append(items, playerNames[who]+"掉顺位")
+		default:
+			items = append(items, fmt.Sprintf("%s%d以内", playerNames[who], point))
+		}
+	}
+	return "放铳：" + strings.Join(items, " ")
+}
+
+// 听牌时，根据默听和立直的打点判断能否升至更高顺位
+// result13 为 nil 或未听牌时返回空字符串
+func winAdviceToStr(a *util.AllLastAnalysis, result13 *util.Hand13AnalysisResult) string {
+	if result13 == nil || result13.Shanten != 0 {
+		return ""
+	}
+	if a.Placement == 0 {
+		return "当前一位，和了即可"
+	}
+
+	t := a.NextTarget()
+	targetName := placementNames[t.Placement]
+	damaPoint := int(math.Round(result13.DamaPoint))
+	riichiPoint := int(math.Round(result13.RiichiPoint))
+
+	damaOK := []string{}
+	riichiOK := []string{}
+	opponentCount := 0
+	for who := range t.MinRonPoints {
+		if who == 0 || !a.Standing.IsPresent(who) {
+			continue
+		}
+		opponentCount++
+		if damaPoint > 0 && t.CanRon(who, damaPoint) {
+			damaOK = append(damaOK, playerNames[who])
+		}
+		if riichiPoint > 0 && t.CanRon(who, riichiPoint) {
+			riichiOK = append(riichiOK, playerNames[who])
+		}
+	}
+
+	switch {
+	case len(damaOK) == opponentCount:
+		return "默听荣和即可升至" + targetName
+	case len(damaOK) > 0:
+		return "默听荣和" + strings.Join(damaOK, "") + "即可升至" + targetName
+	case len(riichiOK) > 0:
+		return "需要立直才能升至" + targetName
+	default:
+		return "打点不足以升至" + targetName
+	}
+}
+
+// 终局时的顺位提示
+func allLastAdvices(a *util.AllLastAnalysis, result13 *util.Hand13AnalysisResult) []string {
+	advices := []string{"当前" + placementNames[a.Placement]}
+	for i := len(a.Targets) - 1; i >= 0; i-- {
+		advices = append(advices, placementTargetToStr(a, a.Targets[i]))
+	}
+	advices = append(advices, dealInToStr(a))
+	if advice := winAdviceToStr(a, result13); advice != "" {
+		advices = append(advices, advice)
+	}
+	return advices
+}
+
+func printAllLastAnalysis(playerInfo *model.PlayerInfo, result13 *util.Hand13AnalysisResult) {
+	a := newAllLastAnalysis(playerInfo)
+	if a == nil {
+		return
+	}
+	advices := allLastAdvices(a, result13)
+	color.HiYellow("【All Last】" + advices[0])
+	for _, advice := range advices[1:] {
+		fmt.Println(advice)
+	}
+}
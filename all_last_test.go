@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_allLastAdvices(t *testing.T) {
+	assert := assert.New(t)
+
+	// 南4局，自家二位，上家为庄家
+	playerInfo := &model.PlayerInfo{Standing: &model.Standing{
+		Scores:       []int{25000, 30000, 20000, 15000},
+		AbsentPlayer: -1,
+		RoundNumber:  7,
+		Dealer:       3,
+	}}
+	a := newAllLastAnalysis(playerInfo)
+	if !assert.NotNil(a) {
+		return
+	}
+
+	advices := allLastAdvices(a, nil)
+	assert.Equal("当前二位", advices[0])
+	assert.Equal("升至一位：荣和下家2600 对家5200 上家5200 自摸1000-2000", advices[1])
+	assert.Contains(advices[2], "放铳：")
+	assert.Len(advices, 3)
+
+	// 默听打点只够荣和下家
+	result13 := &util.Hand13AnalysisResult{Shanten: 0, DamaPoint: 3900, RiichiPoint: 6400}
+	assert.Equal("默听荣和下家即可升至一位", winAdviceToStr(a, result13))
+
+	result13 = &util.Hand13AnalysisResult{Shanten: 0, DamaPoint: 5200}
+	assert.Equal("默听荣和即可升至一位", winAdviceToStr(a, result13))
+
+	// 无役
+	result13 = &util.Hand13AnalysisResult{Shanten: 0, RiichiPoint: 2600}
+	assert.Equal("需要立直才能升至一位", winAdviceToStr(a, result13))
+
+	result13 = &util.Hand13AnalysisResult{Shanten: 0, DamaPoint: 1000, RiichiPoint: 2000}
+	assert.Equal("打点不足以升至一位", winAdviceToStr(a, result13))
+
+	// 未听牌
+	assert.Empty(winAdviceToStr(a, &util.Hand13AnalysisResult{Shanten: 1}))
+
+	// 非终局
+	playerInfo.Standing.RoundNumber = 6
+	assert.Nil(newAllLastAnalysis(playerInfo))
+}
+
+func Test_newAllLastAnalysis_sanma(t *testing.T) {
+	assert := assert.New(t)
+
+	// 三麻南3局为终局
+	playerInfo := &model.PlayerInfo{IsSanma: true, Standing: &model.Standing{
+		Scores:       []int{40000, 35000, 30000, 0},
+		AbsentPlayer: 3,
+		RoundNumber:  6,
+		Dealer:       2,
+	}}
+	a := newAllLastAnalysis(playerInfo)
+	if assert.NotNil(a) {
+		advices := allLastAdvices(a, nil)
+		assert.Equal("当前一位", advices[0])
+	}
+
+	playerInfo.Standing.RoundNumber = 5
+	assert.Nil(newAllLastAnalysis(playerInfo))
+}
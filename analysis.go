@@ -6,10 +6,16 @@ import (
 	"strings"
 	"github.com/fatih/color"
 	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+	"time"
 )
 
 func simpleBestDiscardTile(playerInfo *model.PlayerInfo) int {
 	shanten, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
+	return _simpleBestDiscardTile(playerInfo, shanten, results14, incShantenResults14)
+}
+
+func _simpleBestDiscardTile(playerInfo *model.PlayerInfo, shanten int, results14 util.Hand14AnalysisResultList, incShantenResults14 util.Hand14AnalysisResultList) int {
 	bestAttackDiscardTile := -1
 	if len(results14) > 0 {
 		bestAttackDiscardTile = results14[0].DiscardTile
@@ -45,7 +51,8 @@ func humanHands(playerInfo *model.PlayerInfo) string {
 	return humanHands
 }
 
-func analysisPlayerWithRisk(playerInfo *model.PlayerInfo, mixedRiskTable riskTable) error {
+// opponentRisks 不为空时，何切按照期望收支排序
+func analysisPlayerWithRisk(playerInfo *model.PlayerInfo, mixedRiskTable riskTable, opponentRisks util.OpponentRiskList) error {
 	// 手牌
 	humanTiles := humanHands(playerInfo)
 	fmt.Println(humanTiles)
@@ -62,6 +69,7 @@ func analysisPlayerWithRisk(playerInfo *model.PlayerInfo, mixedRiskTable riskTab
 			mixedRiskTable: mixedRiskTable,
 		}
 		r.printWaitsWithImproves13_oneRow()
+		printAllLastAnalysis(playerInfo, result)
 	case 2:
 		// 分析手牌
 		shanten, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
@@ -84,11 +92,30 @@ func analysisPlayerWithRisk(playerInfo *model.PlayerInfo, mixedRiskTable riskTab
 			}
 		}
 
+		// 终局时提示顺位
+		var bestResult13 *util.Hand13AnalysisResult
+		if len(results14) > 0 {
+			bestResult13 = results14[0].Result13
+		}
+		printAllLastAnalysis(playerInfo, bestResult13)
+
 		// TODO: 接近流局时提示河底是哪家
 
 		// 何切分析结果
-		printResults14WithRisk(results14, mixedRiskTable)
-		printResults14WithRisk(incShantenResults14, mixedRiskTable)
+		printResults14WithRisk(results14, mixedRiskTable, opponentRisks, playerInfo)
+		printResults14WithRisk(incShantenResults14, mixedRiskTable, opponentRisks, playerInfo)
+		printMergedResults14(results14, incShantenResults14, opponentRisks, playerInfo)
+
+		// 蒙特卡罗模拟
+		if rolloutTimeBudget > 0 {
+			printRolloutResults(rolloutDiscards(playerInfo, results14, incShantenResults14))
+		}
+
+		// 暗杠和加杠的判断，与排序后的最佳何切比较
+		printKanAdvices(util.CalcKanAdvices(playerInfo, -1, false, bestResult13AfterSort(results14, incShantenResults14), opponentRisks))
+
+		// 三麻拔北的判断
+		printNukiAdvice(util.CalcNukiAdvice(playerInfo, results14, incShantenResults14, opponentRisks))
 	default:
 		err := fmt.Errorf("参数错误: %d 张牌", countOfTiles)
 		if debugMode {
@@ -107,7 +134,8 @@ func analysisPlayerWithRisk(playerInfo *model.PlayerInfo, mixedRiskTable riskTab
 // isRedFive: 此舍牌是否为赤5
 // allowChi: 是否能吃
 // mixedRiskTable: 危险度表
-func analysisMeld(playerInfo *model.PlayerInfo, targetTile34 int, isRedFive bool, allowChi bool, mixedRiskTable riskTable) error {
+// opponentRisks: 他家放铳信息，不为空时按照期望收支排序
+func analysisMeld(playerInfo *model.PlayerInfo, targetTile34 int, isRedFive bool, allowChi bool, mixedRiskTable riskTable, opponentRisks util.OpponentRiskList) error {
 	if handsCount := util.CountOfTiles34(playerInfo.HandTiles34); handsCount%3 != 1 {
 		return fmt.Errorf("手牌错误：%d 张牌 %v", handsCount, playerInfo.HandTiles34)
 	}
@@ -148,17 +176,49 @@ func analysisMeld(playerInfo *model.PlayerInfo, targetTile34 int, isRedFive bool
 	// TODO: 接近流局时提示河底是哪家
 
 	// 鸣牌何切分析结果
-	printResults14WithRisk(results14, mixedRiskTable)
-	printResults14WithRisk(incShantenResults14, mixedRiskTable)
+	printResults14WithRisk(results14, mixedRiskTable, opponentRisks, playerInfo)
+	printResults14WithRisk(incShantenResults14, mixedRiskTable, opponentRisks, playerInfo)
+	printMergedResults14(results14, incShantenResults14, opponentRisks, playerInfo)
+
+	// 鸣牌与跳过的比较
+	printMeldAdvice(util.CalcMeldAdvice(playerInfo, result, results14, incShantenResults14, opponentRisks))
+
+	// 大明杠的判断
+	printKanAdvices(util.CalcKanAdvices(playerInfo, targetTile34, isRedFive, result, opponentRisks))
 	return nil
 }
 
-func analysisHumanTiles(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model.PlayerInfo, err error) {
-	defer func() {
-		if er := recover(); er != nil {
-			err = er.(error)
-		}
-	}()
+// 最佳何切的分析结果，无法何切时返回 nil
+func bestResult13AfterSort(results14 util.Hand14AnalysisResultList, incShantenResults14 util.Hand14AnalysisResultList) *util.Hand13AnalysisResult {
+	if len(results14) > 0 {
+		return results14[0].Result13
+	}
+	if len(incShantenResults14) > 0 {
+		return incShantenResults14[0].Result13
+	}
+	return nil
+}
+
+// 蒙特卡罗模拟的切牌数上限
+const rolloutMaxCandidates = 5
+
+// 对排序后靠前的切牌进行蒙特卡罗模拟，在 rolloutTimeBudget 内尽可能多地模拟
+func rolloutDiscards(playerInfo *model.PlayerInfo, results14 util.Hand14AnalysisResultList, incShantenResults14 util.Hand14AnalysisResultList) []*util.RolloutResult {
+	candidates := append(util.Hand14AnalysisResultList{}, results14...)
+	candidates = append(candidates, incShantenResults14...)
+	if len(candidates) > rolloutMaxCandidates {
+		candidates = candidates[:rolloutMaxCandidates]
+	}
+	return util.RolloutDiscards(playerInfo, candidates, util.RolloutConfig{
+		Simulations: math.MaxInt32,
+		TimeBudget:  rolloutTimeBudget,
+		Seed:        time.Now().UnixNano(),
+	})
+}
+
+// 解析手牌输入，有他家舍牌时 targetTile34 为该舍牌，否则为 -1
+func parseHumanTilesInfo(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model.PlayerInfo, targetTile34 int, isRedFive bool, err error) {
+	targetTile34 = -1
 
 	if err = humanTilesInfo.SelfParse(); err != nil {
 		return
@@ -171,7 +231,7 @@ func analysisHumanTiles(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model
 
 	tileCount := util.CountOfTiles34(tiles34)
 	if tileCount > 14 {
-		return nil, fmt.Errorf("输入错误：%d 张牌", tileCount)
+		return nil, -1, false, fmt.Errorf("输入错误：%d 张牌", tileCount)
 	}
 
 	if tileCount%3 == 0 {
@@ -183,7 +243,7 @@ func analysisHumanTiles(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model
 	for _, humanMeld := range humanTilesInfo.HumanMelds {
 		tiles, _numRedFives, er := util.StrToTiles(humanMeld)
 		if er != nil {
-			return nil, er
+			return nil, -1, false, er
 		}
 		isUpper := humanMeld[len(humanMeld)-1] <= 'Z'
 		var meldType int
@@ -197,7 +257,7 @@ func analysisHumanTiles(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model
 		case len(tiles) == 4 && !isUpper:
 			meldType = model.MeldTypeMinkan
 		default:
-			return nil, fmt.Errorf("输入错误: %s", humanMeld)
+			return nil, -1, false, fmt.Errorf("输入错误: %s", humanMeld)
 		}
 		containRedFive := false
 		for i, c := range _numRedFives {
@@ -216,6 +276,25 @@ func analysisHumanTiles(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model
 	playerInfo = model.NewSimplePlayerInfo(tiles34, melds)
 	playerInfo.NumRedFives = numRedFives
 
+	if humanTilesInfo.IsSanma {
+		// 三麻没有 2-8m
+		allTiles := util.Tiles34ToTiles(tiles34)
+		for _, meld := range melds {
+			allTiles = append(allTiles, meld.Tiles...)
+		}
+		for _, tile := range allTiles {
+			if util.IsSanmaAbsentTile(tile) {
+				return nil, -1, false, fmt.Errorf("输入错误: 三麻没有 %s", util.Mahjong[tile])
+			}
+		}
+		for tile := range playerInfo.LeftTiles34 {
+			if util.IsSanmaAbsentTile(tile) {
+				playerInfo.LeftTiles34[tile] = 0
+			}
+		}
+		playerInfo.IsSanma = true
+	}
+
 	if humanTilesInfo.HumanDoraTiles != "" {
 		playerInfo.DoraTiles, _, err = util.StrToTiles(humanTilesInfo.HumanDoraTiles)
 		if err != nil {
@@ -223,21 +302,126 @@ func analysisHumanTiles(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model
 		}
 	}
 
+	parseWind := func(humanWind string) (int, error) {
+		windTile, _, er := util.StrToTile34(humanWind)
+		if er != nil {
+			return -1, er
+		}
+		if windTile < 27 || windTile > 30 {
+			return -1, fmt.Errorf("输入错误: %s 不是风牌", humanWind)
+		}
+		return windTile, nil
+	}
+	if humanTilesInfo.HumanRoundWind != "" {
+		if playerInfo.RoundWindTile, err = parseWind(humanTilesInfo.HumanRoundWind); err != nil {
+			return nil, -1, false, err
+		}
+	}
+	if humanTilesInfo.HumanSelfWind != "" {
+		if playerInfo.SelfWindTile, err = parseWind(humanTilesInfo.HumanSelfWind); err != nil {
+			return nil, -1, false, err
+		}
+		playerInfo.IsParent = playerInfo.SelfWindTile == 27
+	}
+
+	if humanTilesInfo.HumanDiscardTiles != "" {
+		playerInfo.DiscardTiles, _, err = util.StrToTiles(humanTilesInfo.HumanDiscardTiles)
+		if err != nil {
+			return
+		}
+		// 舍牌是可见的
+		for _, tile := range playerInfo.DiscardTiles {
+			if playerInfo.LeftTiles34[tile] > 0 {
+				playerInfo.LeftTiles34[tile]--
+			}
+		}
+	}
+
+	if humanTilesInfo.Turn > 0 {
+		// 配牌后牌山有 70 张，每巡四家各摸一张
+		const leftDrawTilesCountAfterDeal = 70
+		playerInfo.LeftDrawTilesCount = leftDrawTilesCountAfterDeal - 4*humanTilesInfo.Turn
+		if playerInfo.IsSanma {
+			playerInfo.LeftDrawTilesCount = util.SanmaLeftDrawTilesCountAfterDeal - 3*humanTilesInfo.Turn
+		}
+		if playerInfo.LeftDrawTilesCount < 0 {
+			playerInfo.LeftDrawTilesCount = 0
+		}
+	}
+
 	if humanTilesInfo.HumanTargetTile != "" {
 		if tileCount%3 == 2 {
-			return nil, fmt.Errorf("输入错误: %s 是 %d 张牌", humanTilesInfo.HumanTiles, tileCount)
-		}
-		targetTile34, isRedFive, er := util.StrToTile34(humanTilesInfo.HumanTargetTile)
-		if er != nil {
-			return nil, er
+			return nil, -1, false, fmt.Errorf("输入错误: %s 是 %d 张牌", humanTilesInfo.HumanTiles, tileCount)
 		}
-		if er := analysisMeld(playerInfo, targetTile34, isRedFive, true, nil); er != nil {
-			return nil, er
+		targetTile34, isRedFive, err = util.StrToTile34(humanTilesInfo.HumanTargetTile)
+		if err != nil {
+			return nil, -1, false, err
 		}
 		return
 	}
 
 	playerInfo.IsTsumo = humanTilesInfo.IsTsumo
-	err = analysisPlayerWithRisk(playerInfo, nil)
 	return
 }
+
+func analysisHumanTiles(humanTilesInfo *model.HumanTilesInfo) (playerInfo *model.PlayerInfo, err error) {
+	defer func() {
+		if er := recover(); er != nil {
+			err = er.(error)
+		}
+	}()
+
+	playerInfo, targetTile34, isRedFive, err := parseHumanTilesInfo(humanTilesInfo)
+	if err != nil {
+		return
+	}
+
+	if playerInfo.IsSanma {
+		// JSON 模式下输出到 stderr
+		color.HiYellow(util.SanmaApproximationNote)
+	}
+
+	if targetTile34 != -1 {
+		// 三麻不能吃
+		allowChi := !playerInfo.IsSanma
+		if isJSONOutput {
+			err = printMeldAnalysisJSON(playerInfo, targetTile34, isRedFive, allowChi, nil, nil)
+		} else {
+			err = analysisMeld(playerInfo, targetTile34, isRedFive, allowChi, nil, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return
+	}
+
+	if isJSONOutput {
+		err = printAnalysisJSON(playerInfo, nil, nil)
+		return
+	}
+	err = analysisPlayerWithRisk(playerInfo, nil, nil)
+	return
+}
+
+// 同 analysisHumanTiles，返回结构化的分析结果
+func analysisHumanTilesJSON(humanTilesInfo *model.HumanTilesInfo) (result *analysisJSON, err error) {
+	defer func() {
+		if er := recover(); er != nil {
+			err = er.(error)
+		}
+	}()
+
+	playerInfo, targetTile34, isRedFive, err := parseHumanTilesInfo(humanTilesInfo)
+	if err != nil {
+		return
+	}
+
+	if targetTile34 != -1 {
+		result, err = newMeldAnalysisJSON(playerInfo, targetTile34, isRedFive, !playerInfo.IsSanma, nil, nil)
+		if err == nil && result == nil {
+			err = fmt.Errorf("输入错误：无法鸣这张牌")
+		}
+		return
+	}
+	return newAnalysisJSON(playerInfo, nil, nil)
+}
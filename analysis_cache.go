@@ -14,8 +14,6 @@ const (
 	analysisOpTypeKan        // 加杠 暗杠
 )
 
-// TODO: 提醒「此处应该副露，不应跳过」
-
 type analysisCache struct {
 	analysisOpType analysisOpType
 
@@ -34,6 +32,17 @@ type analysisCache struct {
 	aiDefenceDiscardTileRisk float64
 
 	tenpaiRate []float64 // TODO: 三家听牌率
+
+	// 摸牌时的何切分析结果（含向听倒退），用于计算进张损失
+	results14 util.Hand14AnalysisResultList
+
+	// 可以鸣牌时的鸣牌判断，用于检查应鸣未鸣和不应鸣牌
+	meldAdvice *util.MeldAdvice
+}
+
+// AI 判断应该鸣牌
+func (c *analysisCache) isCallAdvised() bool {
+	return c.meldAdvice != nil && c.meldAdvice.Verdict == util.MeldVerdictCall
 }
 
 type roundAnalysisCache struct {
@@ -42,6 +51,9 @@ type roundAnalysisCache struct {
 	cache   []*analysisCache
 
 	analysisCacheBeforeChiPon *analysisCache
+
+	// AI 判断应该鸣牌，但玩家跳过的情况
+	missedCalls []*analysisCache
 }
 
 func (rc *roundAnalysisCache) print() {
@@ -118,6 +130,10 @@ func (rc *roundAnalysisCache) print() {
 	}
 	fmt.Println()
 
+	if done {
+		fmt.Println("本局统计　" + rc.quality().String())
+	}
+
 	fmt.Println()
 }
 
@@ -130,7 +146,7 @@ func (rc *roundAnalysisCache) addSelfDiscardTile(tile int, risk float64, isRiich
 }
 
 // 摸牌时的切牌推荐
-func (rc *roundAnalysisCache) addAIDiscardTileWhenDrawTile(attackTile int, defenceTile int, attackTileRisk float64, defenceDiscardTileRisk float64) {
+func (rc *roundAnalysisCache) addAIDiscardTileWhenDrawTile(attackTile int, defenceTile int, attackTileRisk float64, defenceDiscardTileRisk float64, results14 util.Hand14AnalysisResultList) {
 	// 摸牌，巡目+1
 	rc.cache = append(rc.cache, &analysisCache{
 		analysisOpType:           analysisOpTypeTsumo,
@@ -139,7 +155,21 @@ func (rc *roundAnalysisCache) addAIDiscardTileWhenDrawTile(attackTile int, defen
 		aiDefenceDiscardTile:     defenceTile,
 		aiAttackDiscardTileRisk:  attackTileRisk,
 		aiDefenceDiscardTileRisk: defenceDiscardTileRisk,
+		results14:                results14,
 	})
+	rc.skipPossibleChiPonKan()
+}
+
+// 玩家跳过了鸣牌
+func (rc *roundAnalysisCache) skipPossibleChiPonKan() {
+	if c := rc.analysisCacheBeforeChiPon; c != nil && c.isCallAdvised() {
+		rc.missedCalls = append(rc.missedCalls, c)
+	}
+	rc.analysisCacheBeforeChiPon = nil
+}
+
+// 他家鸣牌或自家明杠，无需检查是否跳过了鸣牌
+func (rc *roundAnalysisCache) cancelPossibleChiPonKan() {
 	rc.analysisCacheBeforeChiPon = nil
 }
 
@@ -156,6 +186,7 @@ func (rc *roundAnalysisCache) addKan(meldType int) {
 func (rc *roundAnalysisCache) addChiPonKan(meldType int) {
 	if meldType == meldTypeMinkan {
 		// 暂时忽略明杠，巡目不+1，留给摸牌时+1
+		rc.cancelPossibleChiPonKan()
 		return
 	}
 	// 巡目+1
@@ -182,13 +213,16 @@ func (rc *roundAnalysisCache) addChiPonKan(meldType int) {
 }
 
 // 吃 碰 杠 跳过
-func (rc *roundAnalysisCache) addPossibleChiPonKan(attackTile int, attackTileRisk float64) {
+func (rc *roundAnalysisCache) addPossibleChiPonKan(attackTile int, attackTileRisk float64, meldAdvice *util.MeldAdvice) {
+	// 之前可以鸣的牌没有鸣
+	rc.skipPossibleChiPonKan()
 	rc.analysisCacheBeforeChiPon = &analysisCache{
 		analysisOpType:          analysisOpTypeChiPonKan,
 		selfDiscardTile:         -1,
 		aiAttackDiscardTile:     attackTile,
 		aiDefenceDiscardTile:    -1,
 		aiAttackDiscardTileRisk: attackTileRisk,
+		meldAdvice:              meldAdvice,
 	}
 }
 
@@ -243,10 +277,12 @@ func getCurrentAnalysisCache() *gameAnalysisCache {
 	return getAnalysisCache(_currentSeat)
 }
 
-func (c *gameAnalysisCache) runMajsoulRecordAnalysisTask(actions majsoulRoundActions) error {
+// 计算一局的 AI 舍牌推荐，返回的 roundCache 为 nil 表示无需重复计算或已提前退出
+// isCanceled 返回 true 时提前退出
+func (c *gameAnalysisCache) analysisMajsoulRoundActions(actions majsoulRoundActions, isCanceled func() bool) (*roundAnalysisCache, error) {
 	// 从第一个 action 中取出局和场
 	if len(actions) == 0 {
-		return fmt.Errorf("数据异常：此局数据为空")
+		return nil, fmt.Errorf("数据异常：此局数据为空")
 	}
 
 	newRoundAction := actions[0]
@@ -264,24 +300,24 @@ func (c *gameAnalysisCache) runMajsoulRecordAnalysisTask(actions majsoulRoundAct
 		if debugMode {
 			fmt.Println("无需重复计算")
 		}
-		return nil
+		return nil, nil
 	}
 
 	// 遍历自家舍牌，找到舍牌前的操作
 	// 若为摸牌操作，计算出此时的 AI 进攻舍牌和防守舍牌
 	// 若为鸣牌操作，计算出此时的 AI 进攻舍牌（无进攻舍牌则设为 -1），防守舍牌设为 -1
-	// TODO: 玩家跳过，但是 AI 觉得应鸣牌？
+	// 他家舍牌时若可以鸣牌，记录 AI 的鸣牌判断，玩家跳过但 AI 觉得应鸣牌时记为应鸣未鸣
 	majsoulRoundData := &majsoulRoundData{selfSeat: c.selfSeat} // 注意这里是用的一个新的 majsoulRoundData 去计算的，不会有数据冲突
 	majsoulRoundData.roundData = newGame(majsoulRoundData)
 	majsoulRoundData.roundData.gameMode = gameModeRecordCache
 	majsoulRoundData.skipOutput = true
 	for i, action := range actions[:len(actions)-1] {
-		if c.majsoulRecordUUID != getMajsoulCurrentRecordUUID() {
+		if isCanceled() {
 			if debugMode {
 				fmt.Println("用户退出该牌谱")
 			}
 			// 提前退出，减少不必要的计算
-			return nil
+			return nil, nil
 		}
 		if debugMode {
 			fmt.Println("助手正在计算推荐舍牌…… action", i)
@@ -291,7 +327,19 @@ func (c *gameAnalysisCache) runMajsoulRecordAnalysisTask(actions majsoulRoundAct
 	}
 	roundCache.isEnd = true
 
-	if c.majsoulRecordUUID != getMajsoulCurrentRecordUUID() {
+	return roundCache, nil
+}
+
+func (c *gameAnalysisCache) runMajsoulRecordAnalysisTask(actions majsoulRoundActions) error {
+	isCanceled := func() bool {
+		return c.majsoulRecordUUID != getMajsoulCurrentRecordUUID()
+	}
+	roundCache, err := c.analysisMajsoulRoundActions(actions, isCanceled)
+	if err != nil || roundCache == nil {
+		return err
+	}
+
+	if isCanceled() {
 		if debugMode {
 			fmt.Println("用户退出该牌谱")
 		}
@@ -300,6 +348,7 @@ func (c *gameAnalysisCache) runMajsoulRecordAnalysisTask(actions majsoulRoundAct
 
 	clearConsole()
 	roundCache.print()
+	fmt.Println("整场统计（已计算的局）　" + c.quality().String())
 
 	return nil
 }
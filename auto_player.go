@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"strings"
 	"time"
 	"github.com/fatih/color"
 	"github.com/EndlessCheng/mahjong-helper/util"
@@ -61,37 +62,36 @@ func NewAutoPlayer(config *AutoPlayerConfig) *AutoPlayer {
 }
 
 // 分析并做出决策
-func (ap *AutoPlayer) MakeDecision(playerInfo *model.PlayerInfo, mixedRiskTable riskTable, targetTile int, canMeld bool) Decision {
+// opponentRisks 为他家放铳信息，用于计算切牌的期望收支，可以为 nil
+// targetTile 为他家切的牌，isRedFive 表示这张牌是否为赤5，canMeld 表示能否鸣这张牌，allowChi 表示能否吃这张牌（见 roundData.allowChi）
+// 自家摸牌时 targetTile 为 -1
+func (ap *AutoPlayer) MakeDecision(playerInfo *model.PlayerInfo, mixedRiskTable riskTable, opponentRisks util.OpponentRiskList, targetTile int, isRedFive bool, canMeld bool, allowChi bool) Decision {
 	if !ap.config.Enabled {
 		return Decision{Action: "pass", Confidence: 0, Reason: "自动出牌已禁用"}
 	}
 
-	// 检查是否已和牌
-	if util.CountOfTiles34(playerInfo.HandTiles34)%3 == 1 {
-		shanten, _, _ := util.CalculateShantenWithImproves14(playerInfo)
-		if shanten == -1 {
-			return Decision{Action: "agari", Confidence: 1.0, Reason: "已和牌"}
-		}
-	}
-
 	// 分析手牌状态
 	handCount := util.CountOfTiles34(playerInfo.HandTiles34)
 	
 	switch handCount % 3 {
-	case 1: // 需要切牌
-		return ap.makeDiscardDecision(playerInfo, mixedRiskTable)
-	case 2: // 有选择权（鸣牌或切牌）
+	case 1: // 他家舍牌，可以鸣牌
 		if canMeld && targetTile != -1 {
-			return ap.makeMeldDecision(playerInfo, targetTile, mixedRiskTable)
+			return ap.makeMeldDecision(playerInfo, targetTile, isRedFive, allowChi, opponentRisks)
 		}
-		return ap.makeDiscardDecision(playerInfo, mixedRiskTable)
+	case 2: // 需要切牌
+		// 检查是否已和牌
+		shanten, _, _ := util.CalculateShantenWithImproves14(playerInfo)
+		if shanten == -1 {
+			return Decision{Action: "agari", Confidence: 1.0, Reason: "已和牌"}
+		}
+		return ap.makeDiscardDecision(playerInfo, mixedRiskTable, opponentRisks)
 	}
 
 	return Decision{Action: "pass", Confidence: 0, Reason: "无有效操作"}
 }
 
 // 做出切牌决策
-func (ap *AutoPlayer) makeDiscardDecision(playerInfo *model.PlayerInfo, mixedRiskTable riskTable) Decision {
+func (ap *AutoPlayer) makeDiscardDecision(playerInfo *model.PlayerInfo, mixedRiskTable riskTable, opponentRisks util.OpponentRiskList) Decision {
 	_, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
 	
 	// 评估危险度
@@ -104,7 +104,7 @@ func (ap *AutoPlayer) makeDiscardDecision(playerInfo *model.PlayerInfo, mixedRis
 	case "defensive":
 		return ap.defensiveDiscardDecision(playerInfo, mixedRiskTable, dangerLevel)
 	default: // balanced
-		return ap.balancedDiscardDecision(playerInfo, results14, incShantenResults14, mixedRiskTable, dangerLevel)
+		return ap.balancedDiscardDecision(playerInfo, results14, incShantenResults14, mixedRiskTable, opponentRisks, dangerLevel)
 	}
 }
 
@@ -116,7 +116,7 @@ func (ap *AutoPlayer) aggressiveDiscardDecision(playerInfo *model.PlayerInfo, re
 			Action:     "discard",
 			Tile:       best.DiscardTile,
 			Confidence: 0.9,
-			Reason:     fmt.Sprintf("进攻切牌：%s (进张%d, 打点%d)", util.MahjongZH[best.DiscardTile], best.Result13.Waits.AllCount(), best.Result13.DamaPoint),
+			Reason:     fmt.Sprintf("进攻切牌：%s (进张%d, 打点%.0f)", util.MahjongZH[best.DiscardTile], best.Result13.Waits.AllCount(), best.Result13.DamaPoint),
 		}
 	} else if len(incShantenResults14) > 0 {
 		best := incShantenResults14[0]
@@ -124,7 +124,7 @@ func (ap *AutoPlayer) aggressiveDiscardDecision(playerInfo *model.PlayerInfo, re
 			Action:     "discard",
 			Tile:       best.DiscardTile,
 			Confidence: 0.7,
-			Reason:     fmt.Sprintf("向听倒退切牌：%s (改良后进张%d)", util.MahjongZH[best.DiscardTile], best.Result13.AvgImproveWaitsCount),
+			Reason:     fmt.Sprintf("向听倒退切牌：%s (改良后进张%.2f)", util.MahjongZH[best.DiscardTile], best.Result13.AvgImproveWaitsCount),
 		}
 	}
 	
@@ -147,11 +147,35 @@ func (ap *AutoPlayer) defensiveDiscardDecision(playerInfo *model.PlayerInfo, mix
 	}
 	
 	// 危险度不高时按常规切牌
-	return ap.balancedDiscardDecision(playerInfo, nil, nil, mixedRiskTable, dangerLevel)
+	return ap.balancedDiscardDecision(playerInfo, nil, nil, mixedRiskTable, nil, dangerLevel)
 }
 
 // 平衡策略的切牌决策
-func (ap *AutoPlayer) balancedDiscardDecision(playerInfo *model.PlayerInfo, results14, incShantenResults14 util.Hand14AnalysisResultList, mixedRiskTable riskTable, dangerLevel float64) Decision {
+func (ap *AutoPlayer) balancedDiscardDecision(playerInfo *model.PlayerInfo, results14, incShantenResults14 util.Hand14AnalysisResultList, mixedRiskTable riskTable, opponentRisks util.OpponentRiskList, dangerLevel float64) Decision {
+	// 有威胁时，在所有切牌中选择期望收支最高的
+	if len(opponentRisks) > 0 {
+		sortResults14(results14, opponentRisks, playerInfo.Standing)
+		sortResults14(incShantenResults14, opponentRisks, playerInfo.Standing)
+		candidates := mergeResults14(results14, incShantenResults14, opponentRisks, playerInfo.Standing)
+		if len(candidates) > 0 {
+			best := candidates[0]
+			confidence := 0.85
+			if len(candidates) > 1 && util.InDelta(best.EV, candidates[1].EV, 100) {
+				confidence *= 0.8 // 期望收支相近时降低置信度
+			}
+			reason := fmt.Sprintf("期望收支切牌：%s (期望收支%.0f, 铳率%.2f%%)", util.MahjongZH[best.DiscardTile], best.EV, opponentRisks.DealInRate(best.DiscardTile))
+			if useRankPoint(playerInfo.Standing) {
+				reason = fmt.Sprintf("期望pt切牌：%s (期望%.1fpt, 铳率%.2f%%)", util.MahjongZH[best.DiscardTile], best.RankPointEV, opponentRisks.DealInRate(best.DiscardTile))
+			}
+			return Decision{
+				Action:     "discard",
+				Tile:       best.DiscardTile,
+				Confidence: confidence,
+				Reason:     reason,
+			}
+		}
+	}
+
 	// 高危险度时优先防守
 	if dangerLevel > ap.config.DefenseThreshold {
 		safestTile := mixedRiskTable.getBestDefenceTile(playerInfo.HandTiles34)
@@ -176,7 +200,7 @@ func (ap *AutoPlayer) balancedDiscardDecision(playerInfo *model.PlayerInfo, resu
 			Action:     "discard",
 			Tile:       best.DiscardTile,
 			Confidence: confidence,
-			Reason:     fmt.Sprintf("平衡切牌：%s (进张%d, 打点%d)", util.MahjongZH[best.DiscardTile], best.Result13.Waits.AllCount(), best.Result13.DamaPoint),
+			Reason:     fmt.Sprintf("平衡切牌：%s (进张%d, 打点%.0f)", util.MahjongZH[best.DiscardTile], best.Result13.Waits.AllCount(), best.Result13.DamaPoint),
 		}
 	}
 	
@@ -184,25 +208,29 @@ func (ap *AutoPlayer) balancedDiscardDecision(playerInfo *model.PlayerInfo, resu
 }
 
 // 做出鸣牌决策
-func (ap *AutoPlayer) makeMeldDecision(playerInfo *model.PlayerInfo, targetTile int, mixedRiskTable riskTable) Decision {
+func (ap *AutoPlayer) makeMeldDecision(playerInfo *model.PlayerInfo, targetTile int, isRedFive bool, allowChi bool, opponentRisks util.OpponentRiskList) Decision {
 	if !ap.config.AutoMeld {
 		return Decision{Action: "pass", Confidence: 0, Reason: "自动鸣牌已禁用"}
 	}
 	
-	// 分析鸣牌效果
-	_, results14, _ := util.CalculateMeld(playerInfo, targetTile, false, true)
-	
-	if len(results14) > 0 {
-		best := results14[0]
-		return Decision{
-			Action:     "meld",
-			Tile:       targetTile,
-			Confidence: 0.75,
-			Reason:     fmt.Sprintf("鸣牌：%s (向听%d, 进张%d)", util.MahjongZH[targetTile], best.Result13.Shanten, best.Result13.Waits.AllCount()),
-		}
+	// 比较鸣牌与跳过
+	_, results14, incShantenResults14 := util.CalculateMeld(playerInfo, targetTile, isRedFive, allowChi)
+	skipResult13 := util.CalculateShantenWithImproves13(playerInfo)
+	advice := util.CalcMeldAdvice(playerInfo, skipResult13, results14, incShantenResults14, opponentRisks)
+	if advice == nil {
+		return Decision{Action: "pass", Confidence: 0, Reason: "无法鸣牌"}
 	}
 	
-	return Decision{Action: "pass", Confidence: 0, Reason: "鸣牌效果不佳"}
+	reason := fmt.Sprintf("%s：%s (%s)", util.MeldVerdictNames[advice.Verdict], util.MahjongZH[targetTile], strings.Join(advice.Reasons, "；"))
+	if advice.Verdict == util.MeldVerdictSkip {
+		return Decision{Action: "pass", Confidence: advice.Confidence, Reason: reason}
+	}
+	return Decision{
+		Action:     "meld",
+		Tile:       targetTile,
+		Confidence: advice.Confidence,
+		Reason:     reason,
+	}
 }
 
 // 评估危险度
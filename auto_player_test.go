@@ -0,0 +1,61 @@
+package main
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func newTestAutoPlayer() *AutoPlayer {
+	config := defaultAutoPlayerConfig
+	config.Enabled = true
+	config.AutoMeld = true
+	return NewAutoPlayer(&config)
+}
+
+func TestAutoPlayer_MakeDecision_discard(t *testing.T) {
+	assert := assert.New(t)
+
+	// 两向听，他家立直，只有 1z 是现物，但切 1z 会向听倒退
+	playerInfo := model.NewSimplePlayerInfo(util.MustStrToTiles34("234m 468p 3468s 11z 57z"), nil)
+	shanten, _, _ := util.CalculateShantenWithImproves14(playerInfo)
+	if !assert.Equal(2, shanten) {
+		return
+	}
+	risk34 := make(util.RiskTiles34, 34)
+	for i := range risk34 {
+		risk34[i] = 5
+	}
+	risk34[27] = 0
+	opponents := util.OpponentRiskList{{Who: 3, TenpaiRate: 100, RiskTiles34: risk34, RonPoint: 8000}}
+
+	d := newTestAutoPlayer().MakeDecision(playerInfo, nil, opponents, -1, false, false, false)
+	assert.Equal("discard", d.Action)
+	assert.NotEqual(27, d.Tile)
+}
+
+func TestAutoPlayer_MakeDecision_meld(t *testing.T) {
+	assert := assert.New(t)
+
+	// 他家切中，碰后役牌听牌
+	playerInfo := model.NewSimplePlayerInfo(util.MustStrToTiles34("234m 567p 678s 77z 19p"), nil)
+	ap := newTestAutoPlayer()
+	d := ap.MakeDecision(playerInfo, nil, nil, 33, false, true, false)
+	assert.Equal("meld", d.Action)
+	assert.Equal(33, d.Tile)
+
+	// 不能鸣牌时跳过
+	d = ap.MakeDecision(playerInfo, nil, nil, 33, false, false, false)
+	assert.Equal("pass", d.Action)
+
+	// 有中刻子，吃 4m 后听牌
+	playerInfo = model.NewSimplePlayerInfo(util.MustStrToTiles34("23m 567p 678s 777z 19p"), nil)
+	d = ap.MakeDecision(playerInfo, nil, nil, 3, false, true, true)
+	assert.Equal("meld", d.Action)
+	assert.Equal(3, d.Tile)
+
+	// 下家或对家切的牌不能吃
+	d = ap.MakeDecision(playerInfo, nil, nil, 3, false, true, false)
+	assert.Equal("pass", d.Action)
+}
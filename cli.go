@@ -3,6 +3,7 @@ package main
 import (
 	"fmt"
 	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
 	"github.com/fatih/color"
 	"math"
 	"sort"
@@ -140,9 +141,12 @@ type riskInfo struct {
 	// 若该玩家有杠操作，把杠的那张牌也算作安牌，这有助于判断筋壁危险度
 	safeTiles34 []bool
 
-	// 各种牌的铳率表
+	// 各种牌的铳率表，已根据荣和点数修正
 	riskTable riskTable
 
+	// 听牌时各种牌的铳率表，未根据荣和点数修正，用于计算期望收支
+	dealInRiskTable riskTable
+
 	// 剩余无筋 123789
 	// 总计 18 种。剩余无筋牌数量越少，该无筋牌越危险
 	leftNoSujiTiles []int
@@ -150,9 +154,11 @@ type riskInfo struct {
 	// 是否摸切立直
 	isTsumogiriRiichi bool
 
+	// 读牌结果
+	handReading *util.HandReading
+
 	// 荣和点数
-	// 仅调试用
-	_ronPoint float64
+	ronPoint float64
 }
 
 type riskInfoList []*riskInfo
@@ -174,6 +180,26 @@ func (l riskInfoList) mixedRiskTable() riskTable {
 	return mixedRiskTable
 }
 
+// 用于计算期望收支的他家放铳信息，与 mixedRiskTable 一样忽略听牌率较低的玩家
+func (l riskInfoList) opponentRisks() util.OpponentRiskList {
+	opponents := util.OpponentRiskList{}
+	if len(l) == 0 {
+		return opponents
+	}
+	for i, ri := range l[1:] {
+		if ri.tenpaiRate <= 15 || ri.dealInRiskTable == nil {
+			continue
+		}
+		opponents = append(opponents, &util.OpponentRisk{
+			Who:         i + 1,
+			TenpaiRate:  ri.tenpaiRate,
+			RiskTiles34: util.RiskTiles34(ri.dealInRiskTable),
+			RonPoint:    ri.ronPoint,
+		})
+	}
+	return opponents
+}
+
 func (l riskInfoList) printWithHands(hands []int, leftCounts []int) {
 	// 听牌率超过一定值就打印铳率
 	const (
@@ -195,7 +221,7 @@ func (l riskInfoList) printWithHands(hands []int, leftCounts []int) {
 			dangerousPlayerCount++
 			fmt.Print(names[i] + "安牌:")
 			//if debugMode {
-			//fmt.Printf("(%d*%2.2f%%听牌率)", int(l[i]._ronPoint), l[i].tenpaiRate)
+			//fmt.Printf("(%d*%2.2f%%听牌率)", int(l[i].ronPoint), l[i].tenpaiRate)
 			//}
 			containLine := l[i].riskTable.printWithHands(hands, tenpaiRate/100)
 
@@ -231,6 +257,15 @@ func (l riskInfoList) printWithHands(hands []int, leftCounts []int) {
 				fmt.Printf("[%d无筋]", len(l[i].leftNoSujiTiles))
 			}
 
+			// 打印读牌结果
+			if l[i].handReading != nil {
+				if info := l[i].handReading.String(); info != "" {
+					fmt.Print(" [读牌: ")
+					color.New(color.FgHiYellow).Printf("%s", info)
+					fmt.Print("]")
+				}
+			}
+
 			fmt.Println()
 		}
 	}
@@ -318,8 +353,8 @@ func alertBackwardToShanten2(results util.Hand14AnalysisResultList, incShantenRe
 
 // 需要提醒的役种
 var yakuTypesToAlert = []int{
-	//util.YakuKokushi,
-	//util.YakuKokushi13,
+	util.YakuKokushi,
+	util.YakuKokushi13,
 	util.YakuSuuAnkou,
 	util.YakuSuuAnkouTanki,
 	util.YakuDaisangen,
@@ -449,6 +484,17 @@ type analysisResult struct {
 
 	highlightAvgImproveWaitsCount bool
 	highlightMixedScore           bool
+
+	// 有威胁时显示期望收支
+	showEV bool
+	ev     float64
+
+	// 段位战终盘时显示期望 pt
+	showRankPoint bool
+	rankPointEV   float64
+
+	// 切牌后听牌时的立直判断
+	riichiAdvice *util.RiichiAdvice
 }
 
 /*
@@ -558,12 +604,38 @@ func (r *analysisResult) printWaitsWithImproves13_oneRow() {
 		}
 	}
 
+	// 多巡的牌效率
+	if result13.Turns != nil {
+		fmt.Print(" ")
+		fmt.Printf("[%d巡听牌%.0f%% 自摸%.0f%%]", result13.Turns.Turns, result13.Turns.TenpaiRate, result13.Turns.AgariRate)
+	}
+
 	// 局收支
 	if showScore && result13.MixedRoundPoint != 0.0 {
 		fmt.Print(" ")
 		color.New(color.FgHiGreen).Printf("[局收支%4d]", int(math.Round(result13.MixedRoundPoint)))
 	}
 
+	// 期望收支
+	if r.showEV {
+		fmt.Print(" ")
+		c := color.FgHiGreen
+		if r.ev < 0 {
+			c = color.FgHiRed
+		}
+		color.New(c).Printf("[期望%+5d]", int(math.Round(r.ev)))
+	}
+
+	// 期望 pt
+	if r.showRankPoint {
+		fmt.Print(" ")
+		c := color.FgHiGreen
+		if r.rankPointEV < 0 {
+			c = color.FgHiRed
+		}
+		color.New(c).Printf("[%+.1fpt]", r.rankPointEV)
+	}
+
 	// (默听)荣和点数
 	if result13.DamaPoint > 0 {
 		fmt.Print(" ")
@@ -580,6 +652,12 @@ func (r *analysisResult) printWaitsWithImproves13_oneRow() {
 		color.New(color.FgHiGreen).Printf("[立直%d]", int(math.Round(result13.RiichiPoint)))
 	}
 
+	// 立直判断
+	if r.riichiAdvice != nil {
+		fmt.Print(" ")
+		color.New(color.FgHiYellow).Printf("[宜%s]", r.riichiAdvice)
+	}
+
 	if len(result13.YakuTypes) > 0 {
 		// 役种（两向听以内开启显示）
 		if result13.Shanten <= 2 {
@@ -611,7 +689,16 @@ func (r *analysisResult) printWaitsWithImproves13_oneRow() {
 	} else if result13.IsNaki && shanten >= 0 && shanten <= 2 {
 		// 鸣牌时的无役提示（从听牌到两向听）
 		fmt.Print(" ")
-		color.New(color.FgHiRed).Printf("[无役]")
+		if paths := result13.YakuPaths; paths != nil && len(paths.Paths) > 0 {
+			// 未听牌时显示役的路线，无役的可能性较大时标红
+			c := color.FgHiYellow
+			if paths.NoYakuRate >= 0.5 {
+				c = color.FgHiRed
+			}
+			color.New(c).Printf("[%s]", paths)
+		} else {
+			color.New(color.FgHiRed).Printf("[无役]")
+		}
 	}
 
 	// 振听提示
@@ -651,11 +738,16 @@ func (r *analysisResult) printWaitsWithImproves13_oneRow() {
 	}
 }
 
-func printResults14WithRisk(results14 util.Hand14AnalysisResultList, mixedRiskTable riskTable) {
+func printResults14WithRisk(results14 util.Hand14AnalysisResultList, mixedRiskTable riskTable, opponentRisks util.OpponentRiskList, playerInfo *model.PlayerInfo) {
 	if len(results14) == 0 {
 		return
 	}
 
+	// 有威胁时按照期望收支排序，段位战终盘时按照期望 pt 排序
+	sortResults14(results14, opponentRisks, playerInfo.Standing)
+	showRankPoint := useRankPoint(playerInfo.Standing)
+	results14.CalcRiichiAdvices(playerInfo, opponentRisks)
+
 	maxMixedScore := -1.0
 	maxAvgImproveWaitsCount := -1.0
 	for _, result := range results14 {
@@ -704,9 +796,103 @@ func printResults14WithRisk(results14 util.Hand14AnalysisResultList, mixedRiskTa
 			mixedRiskTable,
 			result.Result13.AvgImproveWaitsCount == maxAvgImproveWaitsCount,
 			result.Result13.MixedWaitsScore == maxMixedScore,
+			len(opponentRisks) > 0,
+			result.EV,
+			showRankPoint,
+			result.RankPointEV,
+			result.RiichiAdvice,
 		}
 		r.printWaitsWithImproves13_oneRow()
 	}
+
+	// 最优切牌的立直判断依据
+	if a := results14[0].RiichiAdvice; a != nil {
+		fmt.Printf("切%s%s：%s\n", util.MahjongZH[results14[0].DiscardTile], a, strings.Join(a.Reasons, "；"))
+	}
+}
+
+// 有威胁时，综合向听不变和向听倒退的切牌，按照期望收支（终盘时按照期望 pt）排序
+// 如 "综合期望收支：切6z(-820) > 切3m(-1034) > 切1p(-1910)"
+func printMergedResults14(results14 util.Hand14AnalysisResultList, incShantenResults14 util.Hand14AnalysisResultList, opponentRisks util.OpponentRiskList, playerInfo *model.PlayerInfo) {
+	if len(opponentRisks) == 0 || len(results14) == 0 || len(incShantenResults14) == 0 {
+		return
+	}
+	const maxShown = 5
+	showRankPoint := useRankPoint(playerInfo.Standing)
+	merged := mergeResults14(results14, incShantenResults14, opponentRisks, playerInfo.Standing)
+	var infos []string
+	for i, r := range merged {
+		if i == maxShown {
+			break
+		}
+		if showRankPoint {
+			infos = append(infos, fmt.Sprintf("切%s(%.1fpt)", util.Mahjong[r.DiscardTile], r.RankPointEV))
+		} else {
+			infos = append(infos, fmt.Sprintf("切%s(%d)", util.Mahjong[r.DiscardTile], int(math.Round(r.EV))))
+		}
+	}
+	title := "综合期望收支："
+	if showRankPoint {
+		title = "综合期望pt："
+	}
+	color.HiYellow(title + strings.Join(infos, " > "))
+}
+
+// 下标为 model.MeldType
+var meldTypeNames = []string{"吃", "碰", "暗杠", "大明杠", "加杠"}
+
+// 杠的判断，如 "【杠判断】暗杠东 开杠（置信度80%）：局收支 杠2400 不杠1800；岭上开花率5.2%；期望宝牌 自家+0.40 他家每人+0.36"
+func printKanAdvices(advices []*util.KanAdvice) {
+	for _, a := range advices {
+		info := "【杠判断】" + meldTypeNames[a.Meld.MeldType] + util.MahjongZH[a.Meld.Tiles[0]] + " " + a.String() + "：" + strings.Join(a.Reasons, "；")
+		if a.Verdict == util.KanVerdictKan {
+			color.HiGreen(info)
+		} else {
+			color.HiYellow(info)
+		}
+	}
+}
+
+// 拔北判断，如 "【拔北判断】拔北（置信度60%）：局收支 拔北2171 留北1011"
+func printNukiAdvice(a *util.NukiAdvice) {
+	if a == nil {
+		return
+	}
+	info := "【拔北判断】" + a.String() + "：" + strings.Join(a.Reasons, "；")
+	if a.Verdict == util.NukiVerdictNuki {
+		color.HiGreen(info)
+	} else {
+		color.HiYellow(info)
+	}
+}
+
+// 蒙特卡罗模拟结果，如 "【模拟】切1万：听牌率85.2% 和率42.1% 平均打点5200（模拟2000次）"
+func printRolloutResults(results []*util.RolloutResult) {
+	for _, r := range results {
+		if r.Simulations == 0 {
+			continue
+		}
+		fmt.Printf("【模拟】切%s：听牌率%.1f%% 和率%.1f%% 平均打点%d（模拟%d次）\n", util.MahjongZH[r.DiscardTile], r.TenpaiRate, r.AgariRate, int(math.Round(r.AvgPoint)), r.Simulations)
+	}
+}
+
+// 鸣牌判断，如 "【鸣牌判断】鸣牌（置信度75%）：34万吃，切1万；鸣牌后向听数前进；局收支 鸣牌1200 不鸣800"
+func printMeldAdvice(a *util.MeldAdvice) {
+	if a == nil {
+		return
+	}
+	reasons := a.Reasons
+	if a.Verdict == util.MeldVerdictCall {
+		best := a.Best
+		how := fmt.Sprintf("%s%s%s，切%s", string([]rune(util.MahjongZH[best.OpenTiles[0]])[:1]), util.MahjongZH[best.OpenTiles[1]], meldTypeNames[a.MeldType()], util.MahjongZH[best.DiscardTile])
+		reasons = append([]string{how}, reasons...)
+	}
+	info := "【鸣牌判断】" + a.String() + "：" + strings.Join(reasons, "；")
+	if a.Verdict == util.MeldVerdictCall {
+		color.HiGreen(info)
+	} else {
+		color.HiYellow(info)
+	}
 }
 
 // 自动出牌相关命令处理函数
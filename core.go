@@ -35,6 +35,11 @@ type DataParser interface {
 	IsInit() bool
 	ParseInit() (roundNumber int, benNumber int, dealer int, doraIndicators []int, handTiles []int, numRedFives []int)
 
+	// 场况：各家点数、立直棒数等，在 ParseInit 之后调用
+	// 只需填写 Scores AbsentPlayer FirstDealer RiichiSticks IsTonpuu，其余由 core 填写
+	// 没有点数信息时返回 nil
+	ParseStanding() *model.Standing
+
 	// 自家摸牌
 	// tile: 0-33
 	// isRedFive: 是否为赤5
@@ -102,6 +107,8 @@ type playerInfo struct {
 	isReached  bool // 是否立直
 	canIppatsu bool // 是否有一发
 
+	isRinshanDraw bool // 开杠后尚未舍牌，即手上有岭上牌
+
 	reachTileAtGlobal int // 立直宣言牌在 globalDiscardTiles 中的下标，初始为 -1
 	reachTileAt       int // 立直宣言牌在 discardTiles 中的下标，初始为 -1
 
@@ -209,6 +216,16 @@ type roundData struct {
 
 	// 0=自家, 1=下家, 2=对家, 3=上家
 	players []*playerInfo
+
+	// 他家加杠的牌，可以抢杠，初始为 -1
+	kakanTile int
+
+	// 场况（各家点数等），没有点数信息时为 nil
+	standing *model.Standing
+
+	// 数据不同步的原因，为空表示数据正常
+	// 不同步时暂停输出，直到收到完整的场况，见 reconcileLeftCounts
+	desyncReason string
 }
 
 func newRoundData(parser DataParser, roundNumber int, benNumber int, dealer int) *roundData {
@@ -235,6 +252,7 @@ func newRoundData(parser DataParser, roundNumber int, benNumber int, dealer int)
 			newPlayerInfo("对家", playerWindTile[2]),
 			newPlayerInfo("上家", playerWindTile[3]),
 		},
+		kakanTile: -1,
 	}
 }
 
@@ -251,11 +269,8 @@ func (d *roundData) reset(roundNumber int, benNumber int, dealer int) {
 	newData.skipOutput = skipOutput
 	newData.gameMode = gameMode
 	newData.playerNumber = playerNumber
+	newData.leftCounts = newData.initLeftCounts()
 	if playerNumber == 3 {
-		// 三麻没有 2-8m
-		for i := 1; i <= 7; i++ {
-			newData.leftCounts[i] = 0
-		}
 		newData.players = modifySanninPlayerInfoList(newData.players, roundNumber)
 	}
 	*d = *newData
@@ -265,16 +280,9 @@ func (d *roundData) newGame() {
 	d.reset(0, 0, 0)
 }
 
+// 数量异常时由 reconcileLeftCounts 处理
 func (d *roundData) descLeftCounts(tile int) {
 	d.leftCounts[tile]--
-	if d.leftCounts[tile] < 0 {
-		info := fmt.Sprintf("数据异常: %s 数量为 %d", util.MahjongZH[tile], d.leftCounts[tile])
-		if debugMode {
-			panic(info)
-		} else {
-			fmt.Println(info)
-		}
-	}
 }
 
 // 杠！
@@ -294,11 +302,22 @@ func (d *roundData) doraList() (dl []int) {
 	return model.DoraList(d.doraIndicators, d.playerNumber == 3)
 }
 
+// 三麻时不存在的一家
+func (d *roundData) isAbsentPlayer(who int) bool {
+	if d.playerNumber != 3 {
+		return false
+	}
+	if d.standing != nil {
+		return !d.standing.IsPresent(who)
+	}
+	return d.players[who].selfWindTile == 30
+}
+
 func (d *roundData) printDiscards() {
 	// 三麻的北家是不需要打印的
 	for i := len(d.players) - 1; i >= 1; i-- {
-		if player := d.players[i]; d.playerNumber != 3 || player.selfWindTile != 30 {
-			player.printDiscards()
+		if !d.isAbsentPlayer(i) {
+			d.players[i].printDiscards()
 		}
 	}
 }
@@ -353,6 +372,9 @@ func (d *roundData) analysisTilesRisk() (riList riskInfoList) {
 			// TODO: 暂时不计算自家的
 			continue
 		}
+		if d.isAbsentPlayer(who) {
+			continue
+		}
 
 		// 该玩家的巡目 = 为其切过的牌的数目
 		turns := util.MinInt(len(player.discardTiles), util.MaxTurns)
@@ -367,11 +389,11 @@ func (d *roundData) analysisTilesRisk() (riList riskInfoList) {
 				riList[who].isTsumogiriRiichi = d.globalDiscardTiles[player.reachTileAtGlobal] < 0
 			}
 		} else {
-			rate := util.CalcTenpaiRate(player.melds, player.discardTiles, player.meldDiscardsAt)
 			if d.playerNumber == 3 {
-				rate = util.GetTenpaiRate3(rate)
+				riList[who].tenpaiRate = util.CalcTenpaiRate3(player.melds, player.discardTiles, player.meldDiscardsAt)
+			} else {
+				riList[who].tenpaiRate = util.CalcTenpaiRate(player.melds, player.discardTiles, player.meldDiscardsAt)
 			}
-			riList[who].tenpaiRate = rate
 		}
 
 		// 估计该玩家荣和点数
@@ -395,13 +417,36 @@ func (d *roundData) analysisTilesRisk() (riList riskInfoList) {
 		if who == d.dealer {
 			ronPoint *= 1.5
 		}
-		riList[who]._ronPoint = ronPoint
-
-		// 根据该玩家的巡目、现物、立直后通过的牌、NC、Dora、早外、荣和点数来计算每张牌的危险度
-		risk34 := util.CalculateRiskTiles34(turns, riList[who].safeTiles34, d.leftCounts, d.doraList(), d.roundWindTile, player.selfWindTile).
+		// 赤宝牌数不同时打点也不同
+		ronPoint *= util.CurrentRuleset().RonPointMulti()
+		riList[who].ronPoint = ronPoint
+
+		// 根据该玩家的巡目、现物、立直后通过的牌、NC、Dora、早外、读牌、荣和点数来计算每张牌的危险度
+		// 读牌：根据舍牌、副露和立直宣言牌推测听牌形和役
+		riList[who].handReading = util.ReadHand(util.HandReadingInput{
+			DiscardTiles:   player.discardTiles,
+			Melds:          player.melds,
+			MeldDiscardsAt: player.meldDiscardsAt,
+			ReachTileAt:    player.reachTileAt,
+			RoundWindTile:  d.roundWindTile,
+			SelfWindTile:   player.selfWindTile,
+		})
+
+		calcRiskTiles34 := util.CalculateRiskTiles34
+		if d.playerNumber == 3 {
+			calcRiskTiles34 = util.CalculateRiskTiles34Sanma
+		}
+		risk34 := calcRiskTiles34(turns, riList[who].safeTiles34, d.leftCounts, d.doraList(), d.roundWindTile, player.selfWindTile).
 			FixWithEarlyOutside(player.earlyOutsideTiles).
-			FixWithPoint(ronPoint)
-		riList[who].riskTable = riskTable(risk34)
+			FixWithHandReading(riList[who].handReading)
+		if d.playerNumber == 3 {
+			risk34 = risk34.FixWithSanma(turns, riList[who].safeTiles34, d.leftCounts, d.doraList())
+		}
+		if util.MaybeKokushi(normalDiscardTiles(player.discardTiles), player.isNaki) {
+			risk34 = risk34.FixWithKokushi(turns, riList[who].safeTiles34, d.leftCounts, d.playerNumber == 3)
+		}
+		riList[who].dealInRiskTable = append(riskTable{}, risk34...)
+		riList[who].riskTable = riskTable(risk34.FixWithPoint(ronPoint))
 
 		// 计算剩余筋牌
 		if len(player.melds) < 4 {
@@ -429,8 +474,8 @@ func (d *roundData) isPlayerDaburii(who int) bool {
 	return d.players[who].reachTileAt == 0
 }
 
-// 自家的 PlayerInfo
-func (d *roundData) newModelPlayerInfo() *model.PlayerInfo {
+// 牌山剩余可以摸的牌数
+func (d *roundData) leftDrawTilesCount() int {
 	const wannpaiTilesCount = 14
 	leftDrawTilesCount := util.CountOfTiles34(d.leftCounts) - (wannpaiTilesCount - len(d.doraIndicators))
 	for _, player := range d.players[1:] {
@@ -439,6 +484,53 @@ func (d *roundData) newModelPlayerInfo() *model.PlayerInfo {
 	if d.playerNumber == 3 {
 		leftDrawTilesCount += 13
 	}
+	return leftDrawTilesCount
+}
+
+// 是否为第一巡且此前无人鸣牌（含暗杠、拔北）
+func (d *roundData) isFirstGoAround() bool {
+	for _, p := range d.players {
+		if len(p.melds) > 0 || p.nukiDoraNum > 0 {
+			return false
+		}
+	}
+	return len(d.players[0].discardTiles) == 0
+}
+
+// 根据本局状况，设置自家和牌时的一发、海底/河底、岭上、抢杠、天和/地和/人和
+// 调用前需设置 IsTsumo 和 WinTile
+func (d *roundData) fillWinSituation(playerInfo *model.PlayerInfo) {
+	selfPlayer := d.players[0]
+	playerInfo.IsIppatsu = selfPlayer.isReached && selfPlayer.canIppatsu
+	playerInfo.IsLastTile = d.leftDrawTilesCount() == 0
+	playerInfo.IsRinshan = playerInfo.IsTsumo && selfPlayer.isRinshanDraw
+	playerInfo.IsChankan = !playerInfo.IsTsumo && d.kakanTile != -1 && playerInfo.WinTile == d.kakanTile
+	playerInfo.IsFirstDraw = d.isFirstGoAround()
+}
+
+// 设置本局开始时的场况
+func (d *roundData) initStanding() {
+	d.standing = d.parser.ParseStanding()
+	if d.standing == nil {
+		return
+	}
+	d.standing.RoundNumber = d.roundNumber
+	d.standing.BenNumber = d.benNumber
+	d.standing.Dealer = d.dealer
+}
+
+// 立直时支付 1000 点立直棒
+func (d *roundData) payRiichiStick(who int) {
+	if d.standing == nil {
+		return
+	}
+	d.standing.Scores[who] -= 1000
+	d.standing.RiichiSticks++
+}
+
+// 自家的 PlayerInfo
+func (d *roundData) newModelPlayerInfo() *model.PlayerInfo {
+	leftDrawTilesCount := d.leftDrawTilesCount()
 
 	melds := []model.Meld{}
 	for _, m := range d.players[0].melds {
@@ -466,9 +558,17 @@ func (d *roundData) newModelPlayerInfo() *model.PlayerInfo {
 		LeftDrawTilesCount: leftDrawTilesCount,
 
 		NukiDoraNum: selfPlayer.nukiDoraNum,
+		IsSanma:     d.playerNumber == 3,
+
+		Standing: d.standing,
 	}
 }
 
+// 能否吃 who 切的牌：只能吃上家的牌，三麻不能吃，河底牌不能吃
+func (d *roundData) allowChi(who int, playerInfo *model.PlayerInfo) bool {
+	return d.playerNumber != 3 && who == 3 && playerInfo.LeftDrawTilesCount > 0
+}
+
 func (d *roundData) analysis() error {
 	if !debugMode {
 		defer func() {
@@ -503,6 +603,15 @@ func (d *roundData) analysis() error {
 		return nil
 	}
 
+	// 数据不同步时，在收到完整的场况前只更新数据，不输出分析结果
+	// 会输出分析结果的事件在更新数据后、输出前调用 reconcileBeforeOutput，其余事件在处理完后校验
+	skipOutput := d.skipOutput
+	defer func() { d.skipOutput = skipOutput }()
+	if d.desyncReason != "" && !d.parser.IsInit() {
+		d.skipOutput = true
+	}
+	defer d.reconcileLeftCounts()
+
 	if debugMode {
 		fmt.Println("当前座位为", d.parser.GetSelfSeat())
 	}
@@ -515,13 +624,13 @@ func (d *roundData) analysis() error {
 	switch {
 	case d.parser.IsInit():
 		// round 开始/重连
-		if !debugMode && !d.skipOutput {
+		if !debugMode && !d.skipOutput && !isJSONOutput {
 			clearConsole()
 		}
 
 		roundNumber, benNumber, dealer, doraIndicators, hands, numRedFives := d.parser.ParseInit()
 		switch d.parser.GetDataSourceType() {
-		case dataSourceTypeTenhou:
+		case dataSourceTypeTenhou, dataSourceTypeSimulator, dataSourceTypeMjai:
 			d.reset(roundNumber, benNumber, dealer)
 			d.gameMode = gameModeMatch // TODO: 牌谱模式？
 		case dataSourceTypeMajsoul:
@@ -547,6 +656,8 @@ func (d *roundData) analysis() error {
 			currentRoundCache = analysisCache.wholeGameCache[d.roundNumber][d.benNumber]
 		}
 
+		d.initStanding()
+
 		d.doraIndicators = doraIndicators
 		for _, dora := range doraIndicators {
 			d.descLeftCounts(dora)
@@ -557,17 +668,31 @@ func (d *roundData) analysis() error {
 		}
 		d.numRedFives = numRedFives
 
+		// 重连时恢复各家的牌河和副露，并重新计算剩余牌量
+		if p, ok := d.parser.(reinitParser); ok && p.IsReinit() {
+			d.restoreReinitState(p.ParseReinit())
+		}
+		d.reconcileBeforeOutput()
+
 		playerInfo := d.newModelPlayerInfo()
 
 		// 牌谱分析模式下，记录舍牌推荐
 		if d.gameMode == gameModeRecordCache && len(hands) == 14 {
-			currentRoundCache.addAIDiscardTileWhenDrawTile(simpleBestDiscardTile(playerInfo), -1, 0, 0)
+			shanten, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
+			bestAttackDiscardTile := _simpleBestDiscardTile(playerInfo, shanten, results14, incShantenResults14)
+			currentRoundCache.addAIDiscardTileWhenDrawTile(bestAttackDiscardTile, -1, 0, 0, append(results14, incShantenResults14...))
 		}
 
 		if d.skipOutput {
 			return nil
 		}
 
+		d.publishAnalysis(streamEventTypeRoundStart, playerInfo, nil, nil)
+
+		if isJSONOutput {
+			return printAnalysisJSON(playerInfo, nil, nil)
+		}
+
 		// 牌谱模式下，打印舍牌推荐
 		if d.gameMode == gameModeRecord {
 			currentRoundCache.print()
@@ -580,9 +705,12 @@ func (d *roundData) analysis() error {
 		info := fmt.Sprintln(util.TilesToMahjongZHInterface(d.doraIndicators)...)
 		info = info[:len(info)-1]
 		color.HiYellow("宝牌指示牌是 " + info)
+		if d.playerNumber == 3 {
+			color.HiYellow(util.SanmaApproximationNote)
+		}
 		fmt.Println()
 		// TODO: 显示地和概率
-		return analysisPlayerWithRisk(playerInfo, nil)
+		return analysisPlayerWithRisk(playerInfo, nil, nil)
 	case d.parser.IsOpen():
 		// 某家鸣牌（含暗杠、加杠）
 		who, meld, kanDoraIndicator := d.parser.ParseOpen()
@@ -595,6 +723,12 @@ func (d *roundData) analysis() error {
 			player.canIppatsu = false
 		}
 
+		// 开杠后摸岭上牌
+		if meld.IsKan() {
+			d.players[who].isRinshanDraw = true
+		}
+		d.kakanTile = -1
+
 		// 杠宝牌指示牌
 		if kanDoraIndicator != -1 {
 			d.newDora(kanDoraIndicator)
@@ -612,6 +746,8 @@ func (d *roundData) analysis() error {
 			if who != 0 {
 				// （不是自家时）修改牌山剩余量
 				d.descLeftCounts(calledTile)
+				// 加杠的牌可以被抢杠
+				d.kakanTile = calledTile
 			} else {
 				// 自家加杠成功，修改手牌
 				d.counts[calledTile]--
@@ -652,6 +788,11 @@ func (d *roundData) analysis() error {
 			// 先增后减
 			if meldType != meldTypeAnkan {
 				d.leftCounts[calledTile]++
+
+				// 他家鸣牌了，自家无法再鸣这张牌
+				if d.gameMode == gameModeRecordCache {
+					currentRoundCache.cancelPossibleChiPonKan()
+				}
 			}
 			for _, tile := range meldTiles {
 				d.descLeftCounts(tile)
@@ -698,6 +839,7 @@ func (d *roundData) analysis() error {
 		// 如果是他家立直，进入攻守判断模式
 		who := d.parser.ParseReach()
 		d.players[who].isReached = true
+		d.payRiichiStick(who)
 		d.players[who].canIppatsu = true
 		//case "AGARI", "RYUUKYOKU":
 		//	// 某人和牌或流局，round 结束
@@ -718,11 +860,9 @@ func (d *roundData) analysis() error {
 		//case "HELO", "RANKING", "TAIKYOKU", "UN", "LN", "SAIKAI":
 		//	// 其他
 	case d.parser.IsSelfDraw():
-		if !debugMode && !d.skipOutput {
-			clearConsole()
-		}
 		// 自家（从牌山 d.leftCounts）摸牌（至手牌 d.counts）
 		tile, isRedFive, kanDoraIndicator := d.parser.ParseSelfDraw()
+		d.kakanTile = -1
 		d.descLeftCounts(tile)
 		d.counts[tile]++
 		if isRedFive {
@@ -732,6 +872,11 @@ func (d *roundData) analysis() error {
 			d.newDora(kanDoraIndicator)
 		}
 
+		d.reconcileBeforeOutput()
+		if !debugMode && !d.skipOutput && !isJSONOutput {
+			clearConsole()
+		}
+
 		playerInfo := d.newModelPlayerInfo()
 
 		// 安全度分析
@@ -740,39 +885,47 @@ func (d *roundData) analysis() error {
 
 		// 牌谱分析模式下，记录舍牌推荐
 		if d.gameMode == gameModeRecordCache {
-			bestAttackDiscardTile := simpleBestDiscardTile(playerInfo)
+			shanten, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
+			bestAttackDiscardTile := _simpleBestDiscardTile(playerInfo, shanten, results14, incShantenResults14)
 			bestDefenceDiscardTile := mixedRiskTable.getBestDefenceTile(playerInfo.HandTiles34)
 			bestAttackDiscardTileRisk, bestDefenceDiscardTileRisk := 0.0, 0.0
 			if bestDefenceDiscardTile >= 0 {
 				bestAttackDiscardTileRisk = mixedRiskTable[bestAttackDiscardTile]
 				bestDefenceDiscardTileRisk = mixedRiskTable[bestDefenceDiscardTile]
 			}
-			currentRoundCache.addAIDiscardTileWhenDrawTile(bestAttackDiscardTile, bestDefenceDiscardTile, bestAttackDiscardTileRisk, bestDefenceDiscardTileRisk)
+			currentRoundCache.addAIDiscardTileWhenDrawTile(bestAttackDiscardTile, bestDefenceDiscardTile, bestAttackDiscardTileRisk, bestDefenceDiscardTileRisk, append(results14, incShantenResults14...))
 		}
 
 		if d.skipOutput {
 			return nil
 		}
 
-		// 牌谱模式下，打印舍牌推荐
-		if d.gameMode == gameModeRecord {
-			currentRoundCache.print()
-		}
+		d.publishAnalysis(streamEventTypeAnalysis, playerInfo, mixedRiskTable, riskTables)
 
-		// 打印他家舍牌信息
-		d.printDiscards()
-		fmt.Println()
+		var err error
+		if isJSONOutput {
+			err = printAnalysisJSON(playerInfo, mixedRiskTable, riskTables)
+		} else {
+			// 牌谱模式下，打印舍牌推荐
+			if d.gameMode == gameModeRecord {
+				currentRoundCache.print()
+			}
+
+			// 打印他家舍牌信息
+			d.printDiscards()
+			fmt.Println()
 
-		// 打印手牌对各家的安全度
-		riskTables.printWithHands(d.counts, d.leftCounts)
+			// 打印手牌对各家的安全度
+			riskTables.printWithHands(d.counts, d.leftCounts)
 
-		// 打印何切推荐
-		// TODO: 根据是否听牌/一向听、打点、巡目、和率等进行攻守判断
-		err := analysisPlayerWithRisk(playerInfo, mixedRiskTable)
+			// 打印何切推荐
+			// TODO: 根据是否听牌/一向听、打点、巡目、和率等进行攻守判断
+			err = analysisPlayerWithRisk(playerInfo, mixedRiskTable, riskTables.opponentRisks())
+		}
 		
 		// 自动出牌处理
 		if err == nil {
-			decision := globalAutoPlayer.MakeDecision(playerInfo, mixedRiskTable, -1, false)
+			decision := globalAutoPlayer.MakeDecision(playerInfo, mixedRiskTable, riskTables.opponentRisks(), -1, false, false, false)
 			if decision.Action != "pass" {
 				if autoErr := globalAutoPlayer.ExecuteDecision(decision); autoErr != nil {
 					fmt.Printf("自动出牌执行失败: %v\n", autoErr)
@@ -792,7 +945,10 @@ func (d *roundData) analysis() error {
 		if isReach {
 			player.isReached = true
 			player.canIppatsu = true
+			d.payRiichiStick(who)
 		}
+		player.isRinshanDraw = false
+		d.kakanTile = -1
 
 		if who == 0 {
 			// 特殊处理自家舍牌的情况
@@ -835,6 +991,8 @@ func (d *roundData) analysis() error {
 		player.discardTiles = append(player.discardTiles, _disTile)
 		player.latestDiscardAtGlobal = len(d.globalDiscardTiles) - 1
 
+		d.reconcileBeforeOutput()
+
 		// 标记外侧牌
 		if !player.isReached && len(player.discardTiles) <= 5 {
 			player.earlyOutsideTiles = append(player.earlyOutsideTiles, util.OutsideTiles(discardTile)...)
@@ -869,7 +1027,7 @@ func (d *roundData) analysis() error {
 
 		// 牌谱分析模式下，记录可能的鸣牌
 		if d.gameMode == gameModeRecordCache {
-			allowChi := who == 3
+			allowChi := d.allowChi(who, playerInfo)
 			_, results14, incShantenResults14 := util.CalculateMeld(playerInfo, discardTile, isRedFive, allowChi)
 			bestAttackDiscardTile := -1
 			if len(results14) > 0 {
@@ -883,7 +1041,9 @@ func (d *roundData) analysis() error {
 				if bestDefenceDiscardTile >= 0 {
 					bestAttackDiscardTileRisk = mixedRiskTable[bestAttackDiscardTile]
 				}
-				currentRoundCache.addPossibleChiPonKan(bestAttackDiscardTile, bestAttackDiscardTileRisk)
+				skipResult13 := util.CalculateShantenWithImproves13(playerInfo)
+				meldAdvice := util.CalcMeldAdvice(playerInfo, skipResult13, results14, incShantenResults14, riskTables.opponentRisks())
+				currentRoundCache.addPossibleChiPonKan(bestAttackDiscardTile, bestAttackDiscardTileRisk, meldAdvice)
 			}
 		}
 
@@ -896,19 +1056,21 @@ func (d *roundData) analysis() error {
 		//	return nil
 		//}
 
-		if !debugMode {
-			clearConsole()
-		}
+		if !isJSONOutput {
+			if !debugMode {
+				clearConsole()
+			}
 
-		// 牌谱模式下，打印舍牌推荐
-		if d.gameMode == gameModeRecord {
-			currentRoundCache.print()
-		}
+			// 牌谱模式下，打印舍牌推荐
+			if d.gameMode == gameModeRecord {
+				currentRoundCache.print()
+			}
 
-		// 打印他家舍牌信息
-		d.printDiscards()
-		fmt.Println()
-		riskTables.printWithHands(d.counts, d.leftCounts)
+			// 打印他家舍牌信息
+			d.printDiscards()
+			fmt.Println()
+			riskTables.printWithHands(d.counts, d.leftCounts)
+		}
 
 		if d.gameMode == gameModeMatch && !canBeMeld {
 			return nil
@@ -916,12 +1078,19 @@ func (d *roundData) analysis() error {
 
 		// 为了方便解析牌谱，这里尽可能地解析副露
 		// TODO: 提醒: 消除海底/避免河底
-		allowChi := d.playerNumber != 3 && who == 3 && playerInfo.LeftDrawTilesCount > 0
-		err := analysisMeld(playerInfo, discardTile, isRedFive, allowChi, mixedRiskTable)
+		allowChi := d.allowChi(who, playerInfo)
+		d.publishMeldAnalysis(who, playerInfo, discardTile, isRedFive, allowChi, mixedRiskTable, riskTables)
+
+		var err error
+		if isJSONOutput {
+			err = printMeldAnalysisJSON(playerInfo, discardTile, isRedFive, allowChi, mixedRiskTable, riskTables)
+		} else {
+			err = analysisMeld(playerInfo, discardTile, isRedFive, allowChi, mixedRiskTable, riskTables.opponentRisks())
+		}
 		
 		// 自动鸣牌处理
 		if err == nil {
-			decision := globalAutoPlayer.MakeDecision(playerInfo, mixedRiskTable, discardTile, canBeMeld)
+			decision := globalAutoPlayer.MakeDecision(playerInfo, mixedRiskTable, riskTables.opponentRisks(), discardTile, isRedFive, canBeMeld, allowChi)
 			if decision.Action != "pass" {
 				if autoErr := globalAutoPlayer.ExecuteDecision(decision); autoErr != nil {
 					fmt.Printf("自动鸣牌执行失败: %v\n", autoErr)
@@ -932,12 +1101,20 @@ func (d *roundData) analysis() error {
 		return err
 	case d.parser.IsRoundWin():
 		// TODO: 解析天凤牌谱 - 注意 skipOutput
+		if d.skipOutput {
+			return nil
+		}
+
+		whos, points := d.parser.ParseRoundWin()
+		d.publishRoundWin(whos, points)
+		if isJSONOutput {
+			return nil
+		}
 
 		if !debugMode {
 			clearConsole()
 		}
 		fmt.Println("和牌，本局结束")
-		whos, points := d.parser.ParseRoundWin()
 		if len(whos) == 3 {
 			color.HiYellow("凤 凰 级 避 铳")
 			if d.parser.GetDataSourceType() == dataSourceTypeMajsoul {
@@ -954,6 +1131,8 @@ func (d *roundData) analysis() error {
 		who, isTsumogiri := d.parser.ParseNukiDora()
 		player := d.players[who]
 		player.nukiDoraNum++
+		// 拔北后同样摸岭上牌
+		player.isRinshanDraw = true
 		if who != 0 {
 			// 减少北的数量
 			d.descLeftCounts(30)
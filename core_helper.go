@@ -15,6 +15,8 @@ const (
 const (
 	dataSourceTypeTenhou = iota
 	dataSourceTypeMajsoul
+	dataSourceTypeSimulator
+	dataSourceTypeMjai
 )
 
 const (
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"fmt"
 	"encoding/json"
+	"github.com/EndlessCheng/mahjong-helper/util"
 	"github.com/EndlessCheng/mahjong-helper/util/debug"
 	"github.com/stretchr/testify/assert"
 )
@@ -174,3 +175,16 @@ func Test_modifySanninPlayerInfoList(t *testing.T) {
 	assert.Equal(newPlayers[2].selfWindTile, 28)
 	assert.Equal(newPlayers[3].selfWindTile, 29)
 }
+
+func Test_roundData_doraList(t *testing.T) {
+	defer util.SetRuleset(util.CurrentRuleset())
+	util.SetRuleset(util.RulesetWRC)
+
+	rd := newGame(nil)
+	rd.doraIndicators = []int{0, 8}
+	assert.Equal(t, []int{1, 0}, rd.doraList())
+
+	// 三麻时 1m 指示 9m，与规则无关
+	rd.playerNumber = 3
+	assert.Equal(t, []int{8, 0}, rd.doraList())
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+)
+
+// 舍牌质量统计：与 AI 推荐的一致率、多承担的铳率、损失的进张、鸣牌判断
+type decisionQuality struct {
+	discardCount      int // 参与统计的舍牌数（有进攻推荐的舍牌）
+	attackMatchCount  int // 与进攻推荐一致的舍牌数
+	defenceCount      int // 有防守推荐的舍牌数
+	defenceMatchCount int // 与防守推荐一致的舍牌数
+
+	// 实际舍牌的铳率超出防守推荐铳率的部分之和
+	excessRisk float64
+
+	// 向听数不变时，实际舍牌比最佳何切少的进张数之和
+	waitsLost int
+	// 实际舍牌导致向听倒退（而最佳何切不倒退）的次数
+	shantenBackCount int
+
+	// AI 判断应该鸣牌但玩家跳过的次数
+	missedCallCount int
+	// 玩家鸣牌但 AI 判断应该跳过的次数
+	badCallCount int
+}
+
+func (q *decisionQuality) add(c *analysisCache) {
+	if c.analysisOpType == analysisOpTypeChiPonKan && c.meldAdvice != nil && !c.isCallAdvised() {
+		q.badCallCount++
+	}
+
+	if c.selfDiscardTile == -1 || c.aiAttackDiscardTile == -1 {
+		return
+	}
+
+	q.discardCount++
+	if c.selfDiscardTile == c.aiAttackDiscardTile {
+		q.attackMatchCount++
+	}
+	if c.aiDefenceDiscardTile != -1 {
+		q.defenceCount++
+		if c.selfDiscardTile == c.aiDefenceDiscardTile {
+			q.defenceMatchCount++
+		}
+		if excessRisk := c.selfDiscardTileRisk - c.aiDefenceDiscardTileRisk; excessRisk > 0 {
+			q.excessRisk += excessRisk
+		}
+	}
+
+	waitsLost, isShantenBack := c.waitsLost()
+	q.waitsLost += waitsLost
+	if isShantenBack {
+		q.shantenBackCount++
+	}
+}
+
+func (q *decisionQuality) merge(other *decisionQuality) {
+	q.discardCount += other.discardCount
+	q.attackMatchCount += other.attackMatchCount
+	q.defenceCount += other.defenceCount
+	q.defenceMatchCount += other.defenceMatchCount
+	q.excessRisk += other.excessRisk
+	q.waitsLost += other.waitsLost
+	q.shantenBackCount += other.shantenBackCount
+	q.missedCallCount += other.missedCallCount
+	q.badCallCount += other.badCallCount
+}
+
+// 进攻一致率，无舍牌时为 0
+func (q *decisionQuality) attackMatchRate() float64 {
+	if q.discardCount == 0 {
+		return 0
+	}
+	return float64(q.attackMatchCount) / float64(q.discardCount)
+}
+
+// 防守一致率，没有防守推荐时为 0
+func (q *decisionQuality) defenceMatchRate() float64 {
+	if q.defenceCount == 0 {
+		return 0
+	}
+	return float64(q.defenceMatchCount) / float64(q.defenceCount)
+}
+
+func (q *decisionQuality) String() string {
+	s := fmt.Sprintf("进攻一致率 %.1f%% (%d/%d)", 100*q.attackMatchRate(), q.attackMatchCount, q.discardCount)
+	if q.defenceCount > 0 {
+		s += fmt.Sprintf("  防守一致率 %.1f%% (%d/%d)  多承担铳率 %.1f%%", 100*q.defenceMatchRate(), q.defenceMatchCount, q.defenceCount, q.excessRisk)
+	}
+	s += fmt.Sprintf("  损失进张 %d", q.waitsLost)
+	if q.shantenBackCount > 0 {
+		s += fmt.Sprintf("  向听倒退 %d 次", q.shantenBackCount)
+	}
+	if q.missedCallCount > 0 {
+		s += fmt.Sprintf("  应鸣未鸣 %d 次", q.missedCallCount)
+	}
+	if q.badCallCount > 0 {
+		s += fmt.Sprintf("  不应鸣牌 %d 次", q.badCallCount)
+	}
+	return s
+}
+
+//
+
+// 实际舍牌相比最佳何切（results14[0]）损失的进张数
+// 若实际舍牌导致向听倒退，则不计进张损失，isShantenBack 为 true
+// 鸣牌后的舍牌没有记录何切分析结果，返回 0
+func (c *analysisCache) waitsLost() (waitsLost int, isShantenBack bool) {
+	if len(c.results14) == 0 || c.selfDiscardTile == -1 {
+		return
+	}
+	best := c.results14[0].Result13
+	for _, result := range c.results14 {
+		if result.DiscardTile != c.selfDiscardTile {
+			continue
+		}
+		if result.Result13.Shanten > best.Shanten {
+			return 0, true
+		}
+		if lost := best.Waits.AllCount() - result.Result13.Waits.AllCount(); lost > 0 {
+			waitsLost = lost
+		}
+		return
+	}
+	return
+}
+
+func (rc *roundAnalysisCache) quality() *decisionQuality {
+	q := &decisionQuality{}
+	if rc == nil {
+		return q
+	}
+	for _, c := range rc.cache {
+		q.add(c)
+	}
+	q.missedCallCount = len(rc.missedCalls)
+	return q
+}
+
+// 整场所有已计算完毕的局的统计
+func (c *gameAnalysisCache) quality() *decisionQuality {
+	q := &decisionQuality{}
+	for _, roundCaches := range c.wholeGameCache {
+		for _, roundCache := range roundCaches {
+			if roundCache != nil && roundCache.isEnd {
+				q.merge(roundCache.quality())
+			}
+		}
+	}
+	return q
+}
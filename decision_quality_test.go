@@ -0,0 +1,65 @@
+package main
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestDecisionQuality(t *testing.T) {
+	// 123456789m 123p 1z2z 切 1z 或 2z 都是一向听，切 1m 向听倒退
+	tiles34, _, err := util.StrToTiles34("123456789m 123p 12z")
+	assert.NoError(t, err)
+	playerInfo := model.NewSimplePlayerInfo(tiles34, nil)
+	_, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
+	results14 = append(results14, incShantenResults14...)
+
+	rc := &roundAnalysisCache{}
+	rc.addAIDiscardTileWhenDrawTile(27, 28, 10, 0, results14)
+	rc.addSelfDiscardTile(28, 0, false)
+	rc.addAIDiscardTileWhenDrawTile(27, 28, 10, 0, results14)
+	rc.addSelfDiscardTile(0, 20, false)
+	rc.addAIDiscardTileWhenDrawTile(27, -1, 0, 0, results14)
+	rc.addSelfDiscardTile(27, 0, false)
+	rc.addAIDiscardTileWhenDrawTile(-1, -1, 0, 0, nil) // 自摸
+
+	q := rc.quality()
+	assert.Equal(t, 3, q.discardCount)
+	assert.Equal(t, 1, q.attackMatchCount)
+	assert.Equal(t, 2, q.defenceCount)
+	assert.Equal(t, 1, q.defenceMatchCount)
+	assert.InDelta(t, 20, q.excessRisk, 1e-9)
+	assert.Equal(t, 0, q.waitsLost)
+	assert.Equal(t, 1, q.shantenBackCount)
+
+	q.merge(rc.quality())
+	assert.Equal(t, 6, q.discardCount)
+	assert.InDelta(t, 1.0/3, q.attackMatchRate(), 1e-9)
+	assert.InDelta(t, 0.5, q.defenceMatchRate(), 1e-9)
+}
+
+func TestDecisionQuality_meld(t *testing.T) {
+	callAdvice := &util.MeldAdvice{Verdict: util.MeldVerdictCall}
+	skipAdvice := &util.MeldAdvice{Verdict: util.MeldVerdictSkip}
+
+	rc := &roundAnalysisCache{}
+	rc.addAIDiscardTileWhenDrawTile(27, -1, 0, 0, nil)
+	rc.addSelfDiscardTile(27, 0, false)
+	rc.addPossibleChiPonKan(0, 0, callAdvice)
+	rc.addPossibleChiPonKan(0, 0, skipAdvice) // 跳过了应鸣的牌
+	rc.addPossibleChiPonKan(0, 0, callAdvice)
+	rc.cancelPossibleChiPonKan() // 他家鸣牌
+	rc.addPossibleChiPonKan(0, 0, skipAdvice)
+	rc.addChiPonKan(meldTypePon) // 鸣了不应鸣的牌
+	rc.addSelfDiscardTile(0, 0, false)
+	rc.addPossibleChiPonKan(0, 0, callAdvice)
+	rc.addAIDiscardTileWhenDrawTile(27, -1, 0, 0, nil) // 跳过了应鸣的牌
+
+	q := rc.quality()
+	assert.Equal(t, 2, q.missedCallCount)
+	assert.Equal(t, 1, q.badCallCount)
+	assert.Equal(t, 2, q.discardCount)
+	assert.Contains(t, q.String(), "应鸣未鸣 2 次")
+	assert.Contains(t, q.String(), "不应鸣牌 1 次")
+}
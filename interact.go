@@ -83,7 +83,7 @@ func interact(humanTilesInfo *model.HumanTilesInfo) error {
 			tiles34[tile]--
 			playerInfo.DiscardTiles = append(playerInfo.DiscardTiles, tile) // 仅判断振听用
 		}
-		if err := analysisPlayerWithRisk(playerInfo, nil); err != nil {
+		if err := analysisPlayerWithRisk(playerInfo, nil, nil); err != nil {
 			fmt.Fprintln(os.Stderr, err)
 		}
 	}
@@ -0,0 +1,592 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"sort"
+)
+
+// -json 模式下的输出格式
+// 牌统一用 1m 9p 7z 这样的字符串表示，铳率表为长度 34 的数组，下标同 util.Mahjong
+
+type yakuJSON struct {
+	Type int    `json:"type"`
+	Name string `json:"name"`
+}
+
+type hand13AnalysisResultJSON struct {
+	Shanten int `json:"shanten"`
+
+	// 进张：牌 -> 剩余枚数
+	Waits          map[string]int `json:"waits"`
+	WaitsCount     int            `json:"waits_count"`
+	DamaWaits      map[string]int `json:"dama_waits"`
+	DamaWaitsCount int            `json:"dama_waits_count"`
+
+	AvgNextShantenWaitsCount float64 `json:"avg_next_shanten_waits_count"`
+	MixedWaitsScore          float64 `json:"mixed_waits_score"`
+
+	// 改良：摸到的牌 -> 改良后的进张数
+	Improves             map[string]int `json:"improves"`
+	ImproveWayCount      int            `json:"improve_way_count"`
+	AvgImproveWaitsCount float64        `json:"avg_improve_waits_count"`
+
+	AgariRate   float64    `json:"agari_rate"`
+	FuritenRate float64    `json:"furiten_rate"`
+	Yaku        []yakuJSON `json:"yaku"`
+	IsPartWait  bool       `json:"is_part_wait"`
+	IsNaki      bool       `json:"is_naki"`
+	DoraCount   int        `json:"dora_count"`
+
+	DamaPoint       float64 `json:"dama_point"`
+	RiichiPoint     float64 `json:"riichi_point"`
+	MixedRoundPoint float64 `json:"mixed_round_point"`
+
+	// 副露手未听牌时，各个役的成立概率
+	YakuPaths *yakuPathsJSON `json:"yaku_paths,omitempty"`
+
+	// 指定 -turns 时，多巡的牌效率
+	Turns *turnsJSON `json:"turns,omitempty"`
+}
+
+type yakuPathJSON struct {
+	Yaku yakuJSON `json:"yaku"`
+	Rate float64  `json:"rate"` // 0-1
+}
+
+type yakuPathsJSON struct {
+	Paths      []yakuPathJSON `json:"paths"`
+	NoYakuRate float64        `json:"no_yaku_rate"` // 0-1
+}
+
+func newYakuPathsJSON(r *util.YakuPathResult) *yakuPathsJSON {
+	if r == nil {
+		return nil
+	}
+	paths := []yakuPathJSON{}
+	for _, p := range r.Paths {
+		paths = append(paths, yakuPathJSON{Yaku: yakuJSON{p.YakuType, util.YakuNameMap[p.YakuType]}, Rate: p.Rate})
+	}
+	return &yakuPathsJSON{Paths: paths, NoYakuRate: r.NoYakuRate}
+}
+
+type turnsJSON struct {
+	Turns          int     `json:"turns"`
+	TenpaiRate     float64 `json:"tenpai_rate"`
+	AgariRate      float64 `json:"agari_rate"`
+	AvgTenpaiTurns float64 `json:"avg_tenpai_turns"`
+	AvgAgariTurns  float64 `json:"avg_agari_turns"`
+}
+
+func newTurnsJSON(r *util.TurnsResult) *turnsJSON {
+	if r == nil {
+		return nil
+	}
+	return &turnsJSON{
+		Turns:          r.Turns,
+		TenpaiRate:     r.TenpaiRate,
+		AgariRate:      r.AgariRate,
+		AvgTenpaiTurns: r.AvgTenpaiTurns,
+		AvgAgariTurns:  r.AvgAgariTurns,
+	}
+}
+
+type hand14AnalysisResultJSON struct {
+	DiscardTile       string                    `json:"discard_tile"`
+	IsDiscardDoraTile bool                      `json:"is_discard_dora_tile"`
+	OpenTiles         []string                  `json:"open_tiles"`       // 鸣牌时用手牌中的哪些牌去鸣牌，不鸣牌时为空
+	Risk              float64                   `json:"risk"`             // 舍牌的综合铳率，无危险度时为 0
+	EV                float64                   `json:"ev"`               // 考虑放铳后的期望收支，无威胁时等于局收支
+	RankPointEV       float64                   `json:"rank_point_ev"`    // 段位战终盘时的期望 pt，未指定 -rank-pt 或非终盘时为 0
+	Riichi            *riichiAdviceJSON         `json:"riichi,omitempty"` // 切牌后听牌时的立直判断
+	Result13          *hand13AnalysisResultJSON `json:"result13"`
+}
+
+var riichiVerdictJSONNames = []string{"riichi", "dama", "tsumo_only"}
+
+type riichiAdviceJSON struct {
+	Verdict         string   `json:"verdict"` // riichi dama tsumo_only
+	CanRiichi       bool     `json:"can_riichi"`
+	RiichiAgariRate float64  `json:"riichi_agari_rate"`
+	DamaAgariRate   float64  `json:"dama_agari_rate"`
+	RiichiPoint     float64  `json:"riichi_point"`
+	DamaPoint       float64  `json:"dama_point"`
+	RiichiEV        float64  `json:"riichi_ev"`
+	DamaEV          float64  `json:"dama_ev"`
+	Reasons         []string `json:"reasons"`
+}
+
+type riskInfoJSON struct {
+	Seat              int       `json:"seat"` // 1-下家 2-对家 3-上家
+	TenpaiRate        float64   `json:"tenpai_rate"`
+	RiskTable         []float64 `json:"risk_table"`
+	LeftNoSujiCount   int       `json:"left_no_suji_count"`
+	IsTsumogiriRiichi bool      `json:"is_tsumogiri_riichi"`
+
+	// 读牌结果
+	HandReading *handReadingJSON `json:"hand_reading,omitempty"`
+}
+
+type handReadingJSON struct {
+	WaitTypeRates   map[string]float64 `json:"wait_type_rates"`        // ryanmen kanchan shanpon tanki
+	HonitsuSuit     string             `json:"honitsu_suit,omitempty"` // m p s
+	HonitsuRate     float64            `json:"honitsu_rate"`
+	ToitoiRate      float64            `json:"toitoi_rate"`
+	HasYakuhai      bool               `json:"has_yakuhai"`
+	YakuhaiPairRate float64            `json:"yakuhai_pair_rate"`
+	ReachTile       string             `json:"reach_tile,omitempty"` // 手切的立直宣言牌
+}
+
+var waitTypeJSONNames = []string{"ryanmen", "kanchan", "shanpon", "tanki"}
+
+func newHandReadingJSON(r *util.HandReading) *handReadingJSON {
+	if r == nil {
+		return nil
+	}
+	j := &handReadingJSON{
+		WaitTypeRates:   map[string]float64{},
+		HonitsuRate:     r.HonitsuRate,
+		ToitoiRate:      r.ToitoiRate,
+		HasYakuhai:      r.HasYakuhai,
+		YakuhaiPairRate: r.YakuhaiPairRate,
+	}
+	for i, rate := range r.WaitTypeRates {
+		j.WaitTypeRates[waitTypeJSONNames[i]] = rate
+	}
+	if r.HonitsuSuit != -1 {
+		j.HonitsuSuit = []string{"m", "p", "s"}[r.HonitsuSuit]
+	}
+	if r.ReachTile != -1 {
+		j.ReachTile = util.Mahjong[r.ReachTile]
+	}
+	return j
+}
+
+type analysisJSON struct {
+	Hands      string `json:"hands"`
+	TargetTile string `json:"target_tile,omitempty"` // 鸣牌分析时的他家舍牌
+	Shanten    int    `json:"shanten"`
+
+	// 3k+1 张牌的分析结果，鸣牌分析时为鸣牌前的分析结果
+	Result13 *hand13AnalysisResultJSON `json:"result13,omitempty"`
+
+	// 3k+2 张牌（或鸣牌后）的何切分析结果
+	Results14           []*hand14AnalysisResultJSON `json:"results14"`
+	IncShantenResults14 []*hand14AnalysisResultJSON `json:"inc_shanten_results14"`
+
+	MixedRiskTable []float64       `json:"mixed_risk_table"`
+	Players        []*riskInfoJSON `json:"players"`
+
+	// 终局时的顺位分析
+	AllLast *allLastJSON `json:"all_last,omitempty"`
+
+	// 鸣牌分析时的鸣牌判断
+	MeldAdvice *meldAdviceJSON `json:"meld_advice,omitempty"`
+
+	// 可以杠时的杠判断，3k+2 张牌时为暗杠和加杠，鸣牌分析时为大明杠
+	KanAdvices []*kanAdviceJSON `json:"kan_advices,omitempty"`
+
+	// 三麻手中有北时的拔北判断
+	NukiAdvice *nukiAdviceJSON `json:"nuki_advice,omitempty"`
+
+	// 指定 -rollout 时，靠前的切牌的蒙特卡罗模拟结果
+	Rollouts []*rolloutResultJSON `json:"rollouts,omitempty"`
+}
+
+type rolloutResultJSON struct {
+	DiscardTile      string    `json:"discard_tile"`
+	Simulations      int       `json:"simulations"`
+	TenpaiRate       float64   `json:"tenpai_rate"`
+	AgariRate        float64   `json:"agari_rate"`
+	AvgPoint         float64   `json:"avg_point"`
+	TenpaiRateByTurn []float64 `json:"tenpai_rate_by_turn"`
+	AgariRateByTurn  []float64 `json:"agari_rate_by_turn"`
+}
+
+func newRolloutResultsJSON(results []*util.RolloutResult) []*rolloutResultJSON {
+	var l []*rolloutResultJSON
+	for _, r := range results {
+		l = append(l, &rolloutResultJSON{
+			DiscardTile:      util.Mahjong[r.DiscardTile],
+			Simulations:      r.Simulations,
+			TenpaiRate:       r.TenpaiRate,
+			AgariRate:        r.AgariRate,
+			AvgPoint:         r.AvgPoint,
+			TenpaiRateByTurn: r.TenpaiRateByTurn,
+			AgariRateByTurn:  r.AgariRateByTurn,
+		})
+	}
+	return l
+}
+
+var kanVerdictJSONNames = []string{"kan", "skip"}
+
+// 下标为 model.MeldType
+var meldTypeJSONNames = []string{"chi", "pon", "ankan", "minkan", "kakan"}
+
+type kanAdviceJSON struct {
+	Type            string   `json:"type"` // ankan minkan kakan
+	Tile            string   `json:"tile"`
+	Verdict         string   `json:"verdict"` // kan skip
+	Confidence      float64  `json:"confidence"`
+	SkipShanten     int      `json:"skip_shanten"`
+	KanShanten      int      `json:"kan_shanten"`
+	SkipWaitsCount  int      `json:"skip_waits_count"`
+	KanWaitsCount   int      `json:"kan_waits_count"`
+	SelfKanDora     float64  `json:"self_kan_dora"`
+	OpponentKanDora float64  `json:"opponent_kan_dora"`
+	RinshanRate     float64  `json:"rinshan_rate"`
+	ChankanRate     float64  `json:"chankan_rate"`
+	EVDiff          float64  `json:"ev_diff"`
+	Reasons         []string `json:"reasons"`
+}
+
+var nukiVerdictJSONNames = []string{"nuki", "keep"}
+
+type nukiAdviceJSON struct {
+	Verdict     string   `json:"verdict"` // nuki keep
+	Confidence  float64  `json:"confidence"`
+	KeepShanten int      `json:"keep_shanten"`
+	NukiShanten int      `json:"nuki_shanten"`
+	IsYakuhai   bool     `json:"is_yakuhai"`
+	RinshanRate float64  `json:"rinshan_rate"`
+	EVDiff      float64  `json:"ev_diff"`
+	Reasons     []string `json:"reasons"`
+}
+
+var meldVerdictJSONNames = []string{"call", "skip"}
+
+type meldAdviceJSON struct {
+	Verdict        string   `json:"verdict"` // call skip
+	Confidence     float64  `json:"confidence"`
+	Type           string   `json:"type"`         // chi pon
+	OpenTiles      []string `json:"open_tiles"`   // 鸣牌时用手牌中的哪些牌去鸣牌
+	DiscardTile    string   `json:"discard_tile"` // 鸣牌后的切牌
+	CallShanten    int      `json:"call_shanten"`
+	SkipShanten    int      `json:"skip_shanten"`
+	CallRoundPoint float64  `json:"call_round_point"`
+	SkipRoundPoint float64  `json:"skip_round_point"`
+	Reasons        []string `json:"reasons"`
+}
+
+type placementTargetJSON struct {
+	Placement           int   `json:"placement"`
+	MinRonPoints        []int `json:"min_ron_points"`
+	MinTsumoChildPoint  int   `json:"min_tsumo_child_point"`
+	MinTsumoParentPoint int   `json:"min_tsumo_parent_point"`
+}
+
+type allLastJSON struct {
+	Scores          []int                  `json:"scores"`
+	Placement       int                    `json:"placement"`
+	Targets         []*placementTargetJSON `json:"targets"`
+	MaxDealInPoints []int                  `json:"max_deal_in_points"`
+	Advices         []string               `json:"advices"`
+}
+
+func tilesToJSON(tiles []int) []string {
+	strs := make([]string, len(tiles))
+	for i, tile := range tiles {
+		strs[i] = util.Mahjong[tile]
+	}
+	return strs
+}
+
+func waitsToJSON(waits util.Waits) map[string]int {
+	m := map[string]int{}
+	for tile, left := range waits {
+		m[util.Mahjong[tile]] = left
+	}
+	return m
+}
+
+func riskTableToJSON(t riskTable) []float64 {
+	if t == nil {
+		return []float64{}
+	}
+	return append([]float64{}, t...)
+}
+
+func newHand13AnalysisResultJSON(r *util.Hand13AnalysisResult) *hand13AnalysisResultJSON {
+	improves := map[string]int{}
+	for tile, waits := range r.Improves {
+		improves[util.Mahjong[tile]] = waits.AllCount()
+	}
+
+	yakuTypes := []int{}
+	for yakuType := range r.YakuTypes {
+		yakuTypes = append(yakuTypes, yakuType)
+	}
+	sort.Ints(yakuTypes)
+	yaku := []yakuJSON{}
+	for _, yakuType := range yakuTypes {
+		name, ok := util.YakuNameMap[yakuType]
+		if !ok {
+			name = util.OldYakuNameMap[yakuType]
+		}
+		yaku = append(yaku, yakuJSON{yakuType, name})
+	}
+
+	return &hand13AnalysisResultJSON{
+		Shanten:                  r.Shanten,
+		Waits:                    waitsToJSON(r.Waits),
+		WaitsCount:               r.Waits.AllCount(),
+		DamaWaits:                waitsToJSON(r.DamaWaits),
+		DamaWaitsCount:           r.DamaWaits.AllCount(),
+		AvgNextShantenWaitsCount: r.AvgNextShantenWaitsCount,
+		MixedWaitsScore:          r.MixedWaitsScore,
+		Improves:                 improves,
+		ImproveWayCount:          r.ImproveWayCount,
+		AvgImproveWaitsCount:     r.AvgImproveWaitsCount,
+		AgariRate:                r.AvgAgariRate,
+		FuritenRate:              r.FuritenRate,
+		Yaku:                     yaku,
+		IsPartWait:               r.IsPartWait,
+		IsNaki:                   r.IsNaki,
+		DoraCount:                r.DoraCount,
+		DamaPoint:                r.DamaPoint,
+		RiichiPoint:              r.RiichiPoint,
+		MixedRoundPoint:          r.MixedRoundPoint,
+		YakuPaths:                newYakuPathsJSON(r.YakuPaths),
+		Turns:                    newTurnsJSON(r.Turns),
+	}
+}
+
+func newRiichiAdviceJSON(a *util.RiichiAdvice) *riichiAdviceJSON {
+	if a == nil {
+		return nil
+	}
+	return &riichiAdviceJSON{
+		Verdict:         riichiVerdictJSONNames[a.Verdict],
+		CanRiichi:       a.CanRiichi,
+		RiichiAgariRate: a.RiichiAgariRate,
+		DamaAgariRate:   a.DamaAgariRate,
+		RiichiPoint:     a.RiichiPoint,
+		DamaPoint:       a.DamaPoint,
+		RiichiEV:        a.RiichiEV,
+		DamaEV:          a.DamaEV,
+		Reasons:         a.Reasons,
+	}
+}
+
+func newMeldAdviceJSON(a *util.MeldAdvice) *meldAdviceJSON {
+	if a == nil {
+		return nil
+	}
+	return &meldAdviceJSON{
+		Verdict:        meldVerdictJSONNames[a.Verdict],
+		Confidence:     a.Confidence,
+		Type:           meldTypeJSONNames[a.MeldType()],
+		OpenTiles:      tilesToJSON(a.Best.OpenTiles),
+		DiscardTile:    util.Mahjong[a.Best.DiscardTile],
+		CallShanten:    a.CallShanten,
+		SkipShanten:    a.SkipShanten,
+		CallRoundPoint: a.CallRoundPoint,
+		SkipRoundPoint: a.SkipRoundPoint,
+		Reasons:        a.Reasons,
+	}
+}
+
+func newKanAdvicesJSON(advices []*util.KanAdvice) (results []*kanAdviceJSON) {
+	for _, a := range advices {
+		results = append(results, &kanAdviceJSON{
+			Type:            meldTypeJSONNames[a.Meld.MeldType],
+			Tile:            util.Mahjong[a.Meld.Tiles[0]],
+			Verdict:         kanVerdictJSONNames[a.Verdict],
+			Confidence:      a.Confidence,
+			SkipShanten:     a.SkipResult13.Shanten,
+			KanShanten:      a.KanResult13.Shanten,
+			SkipWaitsCount:  a.SkipResult13.Waits.AllCount(),
+			KanWaitsCount:   a.KanResult13.Waits.AllCount(),
+			SelfKanDora:     a.SelfKanDora,
+			OpponentKanDora: a.OpponentKanDora,
+			RinshanRate:     a.RinshanRate,
+			ChankanRate:     a.ChankanRate,
+			EVDiff:          a.EVDiff,
+			Reasons:         a.Reasons,
+		})
+	}
+	return
+}
+
+func newNukiAdviceJSON(a *util.NukiAdvice) *nukiAdviceJSON {
+	if a == nil {
+		return nil
+	}
+	return &nukiAdviceJSON{
+		Verdict:     nukiVerdictJSONNames[a.Verdict],
+		Confidence:  a.Confidence,
+		KeepShanten: a.KeepResult13.Shanten,
+		NukiShanten: a.NukiResult13.Shanten,
+		IsYakuhai:   a.IsYakuhai,
+		RinshanRate: a.RinshanRate,
+		EVDiff:      a.EVDiff,
+		Reasons:     a.Reasons,
+	}
+}
+
+func newHand14AnalysisResultListJSON(results14 util.Hand14AnalysisResultList, mixedRiskTable riskTable) []*hand14AnalysisResultJSON {
+	results := []*hand14AnalysisResultJSON{}
+	for _, result := range results14 {
+		risk := 0.0
+		if mixedRiskTable != nil {
+			risk = mixedRiskTable[result.DiscardTile]
+		}
+		results = append(results, &hand14AnalysisResultJSON{
+			DiscardTile:       util.Mahjong[result.DiscardTile],
+			IsDiscardDoraTile: result.IsDiscardDoraTile,
+			OpenTiles:         tilesToJSON(result.OpenTiles),
+			Risk:              risk,
+			EV:                result.EV,
+			RankPointEV:       result.RankPointEV,
+			Riichi:            newRiichiAdviceJSON(result.RiichiAdvice),
+			Result13:          newHand13AnalysisResultJSON(result.Result13),
+		})
+	}
+	return results
+}
+
+// 非终局时返回 nil
+func newAllLastJSON(playerInfo *model.PlayerInfo, result13 *util.Hand13AnalysisResult) *allLastJSON {
+	a := newAllLastAnalysis(playerInfo)
+	if a == nil {
+		return nil
+	}
+	targets := []*placementTargetJSON{}
+	for _, t := range a.Targets {
+		targets = append(targets, &placementTargetJSON{
+			Placement:           t.Placement,
+			MinRonPoints:        t.MinRonPoints,
+			MinTsumoChildPoint:  t.MinTsumoChildPoint,
+			MinTsumoParentPoint: t.MinTsumoParentPoint,
+		})
+	}
+	return &allLastJSON{
+		Scores:          a.Standing.Scores,
+		Placement:       a.Placement,
+		Targets:         targets,
+		MaxDealInPoints: a.MaxDealInPoints,
+		Advices:         allLastAdvices(a, result13),
+	}
+}
+
+func newRiskInfoListJSON(l riskInfoList) []*riskInfoJSON {
+	players := []*riskInfoJSON{}
+	if len(l) == 0 {
+		return players
+	}
+	// l[0] 为自家
+	for i, ri := range l[1:] {
+		players = append(players, &riskInfoJSON{
+			Seat:              i + 1,
+			TenpaiRate:        ri.tenpaiRate,
+			RiskTable:         riskTableToJSON(ri.riskTable),
+			LeftNoSujiCount:   len(ri.leftNoSujiTiles),
+			IsTsumogiriRiichi: ri.isTsumogiriRiichi,
+			HandReading:       newHandReadingJSON(ri.handReading),
+		})
+	}
+	return players
+}
+
+func newEmptyAnalysisJSON(playerInfo *model.PlayerInfo, mixedRiskTable riskTable, riskTables riskInfoList) *analysisJSON {
+	return &analysisJSON{
+		Hands:               humanHands(playerInfo),
+		Results14:           []*hand14AnalysisResultJSON{},
+		IncShantenResults14: []*hand14AnalysisResultJSON{},
+		MixedRiskTable:      riskTableToJSON(mixedRiskTable),
+		Players:             newRiskInfoListJSON(riskTables),
+	}
+}
+
+// 同 analysisPlayerWithRisk，返回结构化的分析结果
+// riskTables 可以为 nil
+func newAnalysisJSON(playerInfo *model.PlayerInfo, mixedRiskTable riskTable, riskTables riskInfoList) (*analysisJSON, error) {
+	result := newEmptyAnalysisJSON(playerInfo, mixedRiskTable, riskTables)
+
+	countOfTiles := util.CountOfTiles34(playerInfo.HandTiles34)
+	switch countOfTiles % 3 {
+	case 1:
+		result13 := util.CalculateShantenWithImproves13(playerInfo)
+		result.Shanten = result13.Shanten
+		result.Result13 = newHand13AnalysisResultJSON(result13)
+		result.AllLast = newAllLastJSON(playerInfo, result13)
+	case 2:
+		shanten, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
+		opponentRisks := riskTables.opponentRisks()
+		sortResults14(results14, opponentRisks, playerInfo.Standing)
+		sortResults14(incShantenResults14, opponentRisks, playerInfo.Standing)
+		results14.CalcRiichiAdvices(playerInfo, opponentRisks)
+		result.Shanten = shanten
+		result.Results14 = newHand14AnalysisResultListJSON(results14, mixedRiskTable)
+		result.IncShantenResults14 = newHand14AnalysisResultListJSON(incShantenResults14, mixedRiskTable)
+		var bestResult13 *util.Hand13AnalysisResult
+		if len(results14) > 0 {
+			bestResult13 = results14[0].Result13
+		}
+		result.AllLast = newAllLastJSON(playerInfo, bestResult13)
+		result.KanAdvices = newKanAdvicesJSON(util.CalcKanAdvices(playerInfo, -1, false, bestResult13AfterSort(results14, incShantenResults14), opponentRisks))
+		result.NukiAdvice = newNukiAdviceJSON(util.CalcNukiAdvice(playerInfo, results14, incShantenResults14, opponentRisks))
+		if rolloutTimeBudget > 0 {
+			result.Rollouts = newRolloutResultsJSON(rolloutDiscards(playerInfo, results14, incShantenResults14))
+		}
+	default:
+		return nil, fmt.Errorf("参数错误: %d 张牌", countOfTiles)
+	}
+	return result, nil
+}
+
+// 同 analysisMeld，返回结构化的分析结果
+// 无法鸣牌时返回 nil
+func newMeldAnalysisJSON(playerInfo *model.PlayerInfo, targetTile34 int, isRedFive bool, allowChi bool, mixedRiskTable riskTable, riskTables riskInfoList) (*analysisJSON, error) {
+	if handsCount := util.CountOfTiles34(playerInfo.HandTiles34); handsCount%3 != 1 {
+		return nil, fmt.Errorf("手牌错误：%d 张牌 %v", handsCount, playerInfo.HandTiles34)
+	}
+	shanten, results14, incShantenResults14 := util.CalculateMeld(playerInfo, targetTile34, isRedFive, allowChi)
+	if len(results14) == 0 && len(incShantenResults14) == 0 {
+		return nil, nil
+	}
+
+	opponentRisks := riskTables.opponentRisks()
+	sortResults14(results14, opponentRisks, playerInfo.Standing)
+	sortResults14(incShantenResults14, opponentRisks, playerInfo.Standing)
+
+	result := newEmptyAnalysisJSON(playerInfo, mixedRiskTable, riskTables)
+	result.TargetTile = util.Mahjong[targetTile34]
+	result.Shanten = shanten
+	result13 := util.CalculateShantenWithImproves13(playerInfo)
+	result.Result13 = newHand13AnalysisResultJSON(result13)
+	result.Results14 = newHand14AnalysisResultListJSON(results14, mixedRiskTable)
+	result.IncShantenResults14 = newHand14AnalysisResultListJSON(incShantenResults14, mixedRiskTable)
+	result.MeldAdvice = newMeldAdviceJSON(util.CalcMeldAdvice(playerInfo, result13, results14, incShantenResults14, opponentRisks))
+	result.KanAdvices = newKanAdvicesJSON(util.CalcKanAdvices(playerInfo, targetTile34, isRedFive, result13, opponentRisks))
+	return result, nil
+}
+
+// 一行一个 JSON，便于其他程序逐行读取
+func printJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return nil
+}
+
+func printAnalysisJSON(playerInfo *model.PlayerInfo, mixedRiskTable riskTable, riskTables riskInfoList) error {
+	result, err := newAnalysisJSON(playerInfo, mixedRiskTable, riskTables)
+	if err != nil {
+		return err
+	}
+	return printJSON(result)
+}
+
+func printMeldAnalysisJSON(playerInfo *model.PlayerInfo, targetTile34 int, isRedFive bool, allowChi bool, mixedRiskTable riskTable, riskTables riskInfoList) error {
+	result, err := newMeldAnalysisJSON(playerInfo, targetTile34, isRedFive, allowChi, mixedRiskTable, riskTables)
+	if err != nil || result == nil {
+		return err
+	}
+	return printJSON(result)
+}
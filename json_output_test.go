@@ -0,0 +1,113 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestAnalysisHumanTilesJSON(t *testing.T) {
+	// 3k+2 张牌
+	result, err := analysisHumanTilesJSON(model.NewSimpleHumanTilesInfo("123m 456p 789s 11223z"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Shanten)
+	assert.Nil(t, result.Result13)
+	if assert.NotEmpty(t, result.Results14) {
+		best := result.Results14[0]
+		assert.Equal(t, "3z", best.DiscardTile)
+		assert.Equal(t, map[string]int{"1z": 2, "2z": 2}, best.Result13.Waits)
+		assert.Equal(t, 4, best.Result13.WaitsCount)
+		if assert.NotNil(t, best.Riichi) {
+			assert.Contains(t, riichiVerdictJSONNames, best.Riichi.Verdict)
+			assert.NotEmpty(t, best.Riichi.Reasons)
+		}
+	}
+
+	// 暗杠
+	result, err = analysisHumanTilesJSON(model.NewSimpleHumanTilesInfo("1111z 234m 567p 78s 99s"))
+	assert.NoError(t, err)
+	if assert.Len(t, result.KanAdvices, 1) {
+		assert.Equal(t, "ankan", result.KanAdvices[0].Type)
+		assert.Equal(t, "1z", result.KanAdvices[0].Tile)
+		assert.Contains(t, kanVerdictJSONNames, result.KanAdvices[0].Verdict)
+	}
+
+	// 三麻拔北
+	humanTilesInfo := model.NewSimpleHumanTilesInfo("11m 234p 567s 789s 4z 1p 5p")
+	humanTilesInfo.IsSanma = true
+	result, err = analysisHumanTilesJSON(humanTilesInfo)
+	assert.NoError(t, err)
+	if assert.NotNil(t, result.NukiAdvice) {
+		assert.Equal(t, "nuki", result.NukiAdvice.Verdict)
+		assert.Equal(t, 0, result.NukiAdvice.NukiShanten)
+	}
+	humanTilesInfo = model.NewSimpleHumanTilesInfo("25m 234p 567s 789s 4z 1p 5p")
+	humanTilesInfo.IsSanma = true
+	_, err = analysisHumanTilesJSON(humanTilesInfo)
+	assert.Error(t, err)
+
+	// 3k+1 张牌
+	result, err = analysisHumanTilesJSON(model.NewSimpleHumanTilesInfo("123m 456p 789s 1122z"))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, result.Shanten)
+	assert.Empty(t, result.Results14)
+	if assert.NotNil(t, result.Result13) {
+		assert.Equal(t, 4, result.Result13.WaitsCount)
+	}
+
+	// 鸣牌
+	result, err = analysisHumanTilesJSON(model.NewSimpleHumanTilesInfo("123m 456p 789s 1123z + 1z"))
+	assert.NoError(t, err)
+	assert.Equal(t, "1z", result.TargetTile)
+	if assert.NotEmpty(t, result.Results14) {
+		assert.Equal(t, []string{"1z", "1z"}, result.Results14[0].OpenTiles)
+		assert.Nil(t, result.Results14[0].Riichi)
+	}
+	if assert.NotNil(t, result.MeldAdvice) {
+		assert.Equal(t, "call", result.MeldAdvice.Verdict)
+		assert.Equal(t, "pon", result.MeldAdvice.Type)
+		assert.Equal(t, 0, result.MeldAdvice.CallShanten)
+		assert.Equal(t, 1, result.MeldAdvice.SkipShanten)
+		assert.NotEmpty(t, result.MeldAdvice.Reasons)
+	}
+
+	_, err = analysisHumanTilesJSON(model.NewSimpleHumanTilesInfo("123m 456p 789s 1123z + 5z"))
+	assert.Error(t, err)
+
+	// 字段名是对外的格式，不能随意改动
+	data, err := json.Marshal(result)
+	assert.NoError(t, err)
+	fields := map[string]interface{}{}
+	assert.NoError(t, json.Unmarshal(data, &fields))
+	for _, key := range []string{"hands", "target_tile", "shanten", "result13", "results14", "inc_shanten_results14", "mixed_risk_table", "players", "meld_advice"} {
+		assert.Contains(t, fields, key)
+	}
+}
+
+func TestRiskInfoListJSON(t *testing.T) {
+	riskTable0 := make(riskTable, 34)
+	riskTable0[0] = 12.5
+	l := riskInfoList{
+		{playerNumber: 4},
+		{playerNumber: 4, tenpaiRate: 100, riskTable: riskTable0, leftNoSujiTiles: []int{0, 8}, handReading: util.ReadHand(util.HandReadingInput{DiscardTiles: []int{27, 4}, ReachTileAt: 1})},
+		{playerNumber: 4},
+		{playerNumber: 4, isTsumogiriRiichi: true},
+	}
+	players := newRiskInfoListJSON(l)
+	assert.Len(t, players, 3)
+	assert.Equal(t, 1, players[0].Seat)
+	assert.Equal(t, 100.0, players[0].TenpaiRate)
+	assert.Equal(t, 12.5, players[0].RiskTable[0])
+	assert.Equal(t, 2, players[0].LeftNoSujiCount)
+	assert.Equal(t, []float64{}, players[1].RiskTable)
+	assert.True(t, players[2].IsTsumogiriRiichi)
+
+	if assert.NotNil(t, players[0].HandReading) {
+		assert.Equal(t, "5m", players[0].HandReading.ReachTile)
+		assert.Empty(t, players[0].HandReading.HonitsuSuit)
+		assert.InDelta(t, 0.6, players[0].HandReading.WaitTypeRates["ryanmen"], 1e-9)
+	}
+	assert.Nil(t, players[1].HandReading)
+}
@@ -7,12 +7,14 @@ import (
 	"github.com/EndlessCheng/mahjong-helper/util/model"
 	"github.com/fatih/color"
 	"math/rand"
+	"os"
 	"strings"
 	"time"
 )
 
 var (
 	considerOldYaku bool
+	rulesetName     string
 
 	isMajsoul     bool
 	isTenhou      bool
@@ -25,18 +27,52 @@ var (
 	showAllYakuTypes       bool
 
 	humanDoraTiles string
+	isSanma        bool
+
+	isJSONOutput bool
+
+	streamClientURL string
+
+	isMjai bool
 
 	port int
 	
 	// 自动出牌相关参数
 	autoPlayerEnabled bool
 	autoPlayerStrategy string
+
+	// 模拟器相关参数
+	simulateGames      int
+	simulateSeed       int64
+	simulateTonpuu     bool
+	simulateStrategies string
+
+	// 牌谱回放相关参数
+	replayTenhouPath string
+	replaySeat       int
+
+	majsoulRecordPath string
+	exportPath        string
+	tenhou6Path       string
+
+	// 计分相关参数
+	scoreHandTiles     string
+	scoreHandOpt       scoreHandOptions
+	humanRoundWindTile string
+	humanSelfWindTile  string
+
+	rankPointName string
+
+	rolloutTimeBudget time.Duration
+
+	sortByTurns bool
 )
 
 func init() {
 	rand.Seed(time.Now().UnixNano())
 
 	flag.BoolVar(&considerOldYaku, "old", false, "允许古役")
+	flag.StringVar(&rulesetName, "rule", "", "指定规则 (tenhou/majsoul/guyi/wrc/ema/mleague)，默认根据平台和对局设置自动选择")
 	flag.BoolVar(&isMajsoul, "majsoul", false, "雀魂助手")
 	flag.BoolVar(&isTenhou, "tenhou", false, "天凤助手")
 	flag.BoolVar(&isAnalysis, "analysis", false, "分析模式")
@@ -51,12 +87,41 @@ func init() {
 	flag.BoolVar(&showAllYakuTypes, "y", false, "同 -yaku")
 	flag.StringVar(&humanDoraTiles, "dora", "", "指定哪些牌是宝牌")
 	flag.StringVar(&humanDoraTiles, "d", "", "同 -dora")
+	flag.BoolVar(&isSanma, "sanma", false, "分析三麻手牌（没有 2-8m，不能吃，可以拔北；听牌率、危险度和和率为由四麻数据换算的近似值）")
+	flag.BoolVar(&isJSONOutput, "json", false, "以 JSON 格式输出分析结果（每行一个 JSON），提示信息输出到 stderr")
+	flag.StringVar(&streamClientURL, "stream-client", "", "连接 /stream 并打印推送的分析结果，如 wss://localhost:12121/stream")
+	flag.BoolVar(&isMjai, "mjai", false, "mjai 协议模式：从 stdin 读取 mjai 事件，向 stdout 输出 AutoPlayer 的决策（策略见 -auto-config）")
 	flag.IntVar(&port, "port", 12121, "指定服务端口")
 	flag.IntVar(&port, "p", 12121, "同 -port")
 	
 	// 自动出牌参数
 	flag.BoolVar(&autoPlayerEnabled, "auto", false, "启用自动出牌")
 	flag.StringVar(&autoPlayerStrategy, "auto-config", "balanced", "自动出牌策略 (aggressive/balanced/defensive)")
+
+	// 模拟器参数
+	flag.IntVar(&simulateGames, "simulate", 0, "本地自对局模拟，指定对局数")
+	flag.Int64Var(&simulateSeed, "sim-seed", 0, "模拟器随机种子（默认使用当前时间）")
+	flag.BoolVar(&simulateTonpuu, "sim-tonpuu", false, "模拟东风战（默认半庄战）")
+	flag.StringVar(&simulateStrategies, "sim-strategies", "aggressive,balanced,defensive,balanced", "模拟器中四家的策略，用逗号分隔")
+
+	// 牌谱回放参数
+	flag.StringVar(&replayTenhouPath, "replay-tenhou", "", "回放天凤牌谱（mjlog、tenhou.net/6 JSON 文件或目录），输出与 AI 推荐不一致的舍牌")
+	flag.IntVar(&replaySeat, "replay-seat", 0, "回放牌谱时分析的座位：0-起家 1-南家 2-西家 3-北家")
+	flag.StringVar(&majsoulRecordPath, "majsoul-record", "", "离线分析下载的雀魂牌谱（JSON 文件或目录）")
+	flag.StringVar(&exportPath, "export", "", "将牌谱分析结果导出为 JSON 文件")
+	flag.StringVar(&tenhou6Path, "tenhou6", "", "将雀魂牌谱转换成 tenhou.net/6 格式，配合 -majsoul-record 使用")
+
+	// 计分参数
+	flag.StringVar(&scoreHandTiles, "score-hand", "", `计算和牌的番符和点数，如 -score-hand "234m 567p 33s 45s # 7777S + 6s"`)
+	flag.BoolVar(&scoreHandOpt.isTsumo, "tsumo", false, "计分时为自摸（默认荣和）")
+	flag.BoolVar(&scoreHandOpt.isRiichi, "riichi", false, "计分时为立直")
+	flag.BoolVar(&scoreHandOpt.isIppatsu, "ippatsu", false, "计分时为一发")
+	flag.StringVar(&scoreHandOpt.humanUraDoraTiles, "ura", "", "计分时指定哪些牌是里宝牌，立直时有效")
+	flag.StringVar(&humanRoundWindTile, "round-wind", "", "场风，如 1z（默认东场）")
+	flag.StringVar(&humanSelfWindTile, "self-wind", "", "自风，如 2z（默认东家）")
+	flag.StringVar(&rankPointName, "rank-pt", "", "段位战终盘时按照期望 pt 排序切牌 (tenhou/tenhouN/majsoul，或四个顺位的 pt 如 90,45,0,-135)")
+	flag.DurationVar(&rolloutTimeBudget, "rollout", 0, "对推荐的切牌进行蒙特卡罗模拟，指定模拟的时间，如 500ms")
+	flag.BoolVar(&sortByTurns, "turns", false, "两三向听时按多巡内的自摸率和听牌率排序切牌（计算较慢）")
 }
 
 const (
@@ -80,6 +145,15 @@ var platforms = map[int][]string{
 	},
 }
 
+// 根据平台或对局设置自动选择规则，用 -rule 指定规则时不做修改
+func autoSetRuleset(r util.Ruleset) {
+	if rulesetName != "" || util.CurrentRuleset() == r {
+		return
+	}
+	util.SetRuleset(r)
+	color.HiGreen("已切换至%s规则", r.Name)
+}
+
 const readmeURL = "https://github.com/EndlessCheng/mahjong-helper/blob/master/README.md"
 const issueURL = "https://github.com/EndlessCheng/mahjong-helper/issues"
 const issueCommonQuestions = "https://github.com/EndlessCheng/mahjong-helper/issues/104"
@@ -132,6 +206,11 @@ func welcome() int {
 func main() {
 	flag.Parse()
 
+	if isJSONOutput || isMjai {
+		// 保证 stdout 只有 JSON
+		color.Output = os.Stderr
+	}
+
 	color.HiGreen("日本麻将助手 %s (by EndlessCheng)", version)
 	if version != versionDev {
 		go checkNewVersion(version)
@@ -139,6 +218,24 @@ func main() {
 
 	util.SetConsiderOldYaku(considerOldYaku)
 
+	if rulesetName != "" {
+		r, err := util.RulesetByName(rulesetName)
+		if err != nil {
+			errorExit(err)
+		}
+		util.SetRuleset(r)
+	} else if isTenhou || replayTenhouPath != "" {
+		util.SetRuleset(util.RulesetTenhou)
+	}
+
+	if rankPointName != "" {
+		t, err := util.RankPointTableByName(rankPointName)
+		if err != nil {
+			errorExit(err)
+		}
+		rankPointTable = &t
+	}
+
 	// 加载自动出牌配置文件
 	if err := LoadAutoPlayerConfig(); err != nil {
 		fmt.Printf("⚠️ 加载自动出牌配置失败: %v，使用默认配置\n", err)
@@ -158,20 +255,44 @@ func main() {
 	humanTilesInfo := &model.HumanTilesInfo{
 		HumanTiles:     humanTiles,
 		HumanDoraTiles: humanDoraTiles,
+		HumanRoundWind: humanRoundWindTile,
+		HumanSelfWind:  humanSelfWindTile,
+		IsSanma:        isSanma,
 	}
 
 	var err error
 	switch {
+	case simulateGames > 0: // 本地自对局模拟
+		if simulateSeed == 0 {
+			simulateSeed = time.Now().UnixNano()
+		}
+		err = runSimulator(simulateGames, simulateSeed, simulateStrategies, simulateTonpuu)
+	case replayTenhouPath != "": // 天凤牌谱回放
+		err = runTenhouRecordReplay(replayTenhouPath, replaySeat)
+	case majsoulRecordPath != "" && tenhou6Path != "": // 雀魂牌谱转换成 tenhou.net/6 格式
+		err = runMajsoulRecordToTenhou6(majsoulRecordPath, tenhou6Path)
+	case majsoulRecordPath != "": // 雀魂牌谱离线分析
+		err = runMajsoulRecordFileAnalysis(majsoulRecordPath, exportPath)
+	case streamClientURL != "": // 实时分析推送的测试客户端
+		err = runStreamClient(streamClientURL)
+	case isMjai: // mjai 协议对局
+		err = runMjai(os.Stdin, os.Stdout, autoPlayerStrategy)
 	case isMajsoul:
 		err = runServer(true, port)
 	case isTenhou || isAnalysis:
 		err = runServer(true, port)
+	case scoreHandTiles != "": // 计分
+		humanTilesInfo.HumanTiles = scoreHandTiles
+		err = runScoreHand(humanTilesInfo, scoreHandOpt)
 	case isInteractive: // 交互模式
 		err = interact(humanTilesInfo)
 	case len(flag.Args()) > 0: // 静态分析
 		_, err = analysisHumanTiles(humanTilesInfo)
 	default: // 服务器模式
 		choose := welcome()
+		if choose == platformTenhou {
+			autoSetRuleset(util.RulesetTenhou)
+		}
 		isHTTPS := choose == platformMajsoul
 		err = runServer(isHTTPS, port)
 	}
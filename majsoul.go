@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"github.com/fatih/color"
 	"github.com/EndlessCheng/mahjong-helper/util"
@@ -64,6 +65,11 @@ type majsoulMessage struct {
 	Tiles interface{} `json:"tiles"` // 一般情况下为 []interface{}, interface{} 即 string，但是暗杠的情况下，该值为一个 string
 	Dora  string      `json:"dora"`
 
+	// ActionNewRound 中为各家点数 [25000,25000,25000,25000]
+	// ActionNoTile 中为 [{"old_scores":[...],"delta_scores":[...]}]
+	Scores   json.RawMessage `json:"scores"`
+	Liqibang *int            `json:"liqibang"`
+
 	// RecordNewRound
 	Tiles0 []string `json:"tiles0"`
 	Tiles1 []string `json:"tiles1"`
@@ -102,12 +108,18 @@ type majsoulMessage struct {
 
 	// ActionHule
 	Hules []struct {
-		Seat          int  `json:"seat"`
-		Zimo          bool `json:"zimo"`
-		PointRong     int  `json:"point_rong"`
-		PointZimoQin  int  `json:"point_zimo_qin"`
-		PointZimoXian int  `json:"point_zimo_xian"`
+		Seat          int      `json:"seat"`
+		Zimo          bool     `json:"zimo"`
+		Qinjia        bool     `json:"qinjia"` // 是否为庄家
+		Yiman         bool     `json:"yiman"`
+		Count         int      `json:"count"` // 番数，役满时为役满倍数
+		Fu            int      `json:"fu"`
+		LiDoras       []string `json:"li_doras"`
+		PointRong     int      `json:"point_rong"`
+		PointZimoQin  int      `json:"point_zimo_qin"`
+		PointZimoXian int      `json:"point_zimo_xian"`
 	} `json:"hules"`
+	DeltaScores []int `json:"delta_scores"`
 
 	// ActionLiuJu
 	// {"liujumanguan":false,"players":[{"tingpai":true,"hand":["3s","3s","4s","5s","6s","1z","1z","7z","7z","7z"],"tings":[{"tile":"1z","haveyi":true},{"tile":"3s","haveyi":true}]},{"tingpai":false},{"tingpai":false},{"tingpai":true,"hand":["4m","0m","6m","6m","6m","4s","4s","4s","5s","7s"],"tings":[{"tile":"6s","haveyi":true}]}],"scores":[{"old_scores":[23000,29000,24000,24000],"delta_scores":[1500,-1500,-1500,1500]}],"gameend":false}
@@ -132,6 +144,8 @@ type majsoulRoundData struct {
 	msg        *majsoulMessage
 
 	selfSeat int // 自家初始座位：0-第一局的东家 1-第一局的南家 2-第一局的西家 3-第一局的北家
+
+	isTonpuu bool // 是否为东风战
 }
 
 func (d *majsoulRoundData) fatalParse(info string, msg string) {
@@ -215,6 +229,8 @@ func (d *majsoulRoundData) SkipMessage() bool {
 		// 特判古役模式
 		isGuyiMode := msg.GameConfig.isGuyiMode()
 		util.SetConsiderOldYaku(isGuyiMode)
+		autoSetRuleset(msg.GameConfig.ruleset())
+		d.isTonpuu = msg.GameConfig.isTonpuu()
 		if isGuyiMode {
 			color.HiGreen("古役模式已开启")
 			time.Sleep(2 * time.Second)
@@ -342,6 +358,33 @@ func (d *majsoulRoundData) ParseInit() (roundNumber int, benNumber int, dealer i
 	return
 }
 
+func (d *majsoulRoundData) ParseStanding() *model.Standing {
+	msg := d.msg
+
+	// ActionNewRound 中的 scores 为各家点数，按座位排列
+	seatScores := []int{}
+	if len(msg.Scores) == 0 || json.Unmarshal(msg.Scores, &seatScores) != nil || len(seatScores) < 3 {
+		return nil
+	}
+
+	standing := &model.Standing{
+		Scores:       make([]int, 4),
+		AbsentPlayer: -1,
+		FirstDealer:  d.parseWho(0),
+		IsTonpuu:     d.isTonpuu,
+	}
+	for seat, score := range seatScores {
+		standing.Scores[d.parseWho(seat)] = score
+	}
+	if len(seatScores) == 3 {
+		standing.AbsentPlayer = d.parseWho(3)
+	}
+	if msg.Liqibang != nil {
+		standing.RiichiSticks = *msg.Liqibang
+	}
+	return standing
+}
+
 func (d *majsoulRoundData) IsSelfDraw() bool {
 	msg := d.msg
 	// ActionDealTile RecordDealTile
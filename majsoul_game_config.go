@@ -1,5 +1,7 @@
 package main
 
+import "github.com/EndlessCheng/mahjong-helper/util"
+
 const (
 	majsoulGameConfigCategoryFriends = 1 // 友人
 	majsoulGameConfigCategoryMatch   = 2 // 段位 比赛
@@ -13,6 +15,12 @@ type majsoulGameConfig struct {
 		Mode       int `json:"mode"`
 		DetailRule *struct {
 			GuyiMode int `json:"guyi_mode"`
+
+			DoraCount           *int `json:"dora_count"` // 赤宝牌数
+			Shiduan             *int `json:"shiduan"`    // 食断 1=有 0=无
+			HaveQieshangmanguan bool `json:"have_qieshangmanguan"`
+			DisableMultiYukaman bool `json:"disable_multi_yukaman"`
+			HaveZimosun         bool `json:"have_zimosun"` // 三麻自摸损
 		} `json:"detail_rule"`
 	} `json:"mode"`
 }
@@ -20,3 +28,30 @@ type majsoulGameConfig struct {
 func (c *majsoulGameConfig) isGuyiMode() bool {
 	return c != nil && c.Mode != nil && c.Mode.DetailRule != nil && c.Mode.DetailRule.GuyiMode == 1
 }
+
+// mode: 1=四人东 2=四人南 11=三人东 12=三人南
+func (c *majsoulGameConfig) isTonpuu() bool {
+	return c != nil && c.Mode != nil && c.Mode.Mode%10 == 1
+}
+
+// 段位场没有 detail_rule，友人场按 detail_rule 修改规则
+func (c *majsoulGameConfig) ruleset() util.Ruleset {
+	r := util.RulesetMajsoul
+	if c == nil || c.Mode == nil || c.Mode.DetailRule == nil {
+		return r
+	}
+	rule := c.Mode.DetailRule
+	if rule.GuyiMode == 1 {
+		r = util.RulesetMajsoulGuyi
+	}
+	if rule.DoraCount != nil {
+		r.NumRedFives = *rule.DoraCount
+	}
+	if rule.Shiduan != nil {
+		r.OpenTanyao = *rule.Shiduan == 1
+	}
+	r.KiriageMangan = rule.HaveQieshangmanguan
+	r.MultipleYakuman = !rule.DisableMultiYukaman
+	r.SanmaTsumoLoss = rule.HaveZimosun
+	return r
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_majsoulGameConfigRuleset(t *testing.T) {
+	c := &majsoulGameConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"category":2,"mode":{"mode":1,"detail_rule":{"guyi_mode":1}}}`), c))
+	assert.Equal(t, util.RulesetMajsoulGuyi, c.ruleset())
+
+	c = &majsoulGameConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"category":2,"mode":{"mode":1}}`), c))
+	assert.Equal(t, util.RulesetMajsoul, c.ruleset())
+
+	c = &majsoulGameConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"category":1,"mode":{"mode":1,"detail_rule":{"dora_count":0,"have_qieshangmanguan":true,"disable_multi_yukaman":true}}}`), c))
+	r := c.ruleset()
+	assert.Equal(t, 0, r.NumRedFives)
+	assert.True(t, r.KiriageMangan)
+	assert.False(t, r.MultipleYakuman)
+	assert.True(t, r.OpenTanyao)
+
+	// 友人场无食断
+	c = &majsoulGameConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"category":1,"mode":{"mode":1,"detail_rule":{"time_fixed":60,"time_add":0,"dora_count":3,"shiduan":0,"init_point":25000}}}`), c))
+	assert.False(t, c.ruleset().OpenTanyao)
+}
+
+func Test_majsoulGameConfigIsTonpuu(t *testing.T) {
+	c := &majsoulGameConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"category":2,"mode":{"mode":11}}`), c))
+	assert.True(t, c.isTonpuu())
+
+	c = &majsoulGameConfig{}
+	assert.NoError(t, json.Unmarshal([]byte(`{"category":2,"mode":{"mode":2}}`), c))
+	assert.False(t, c.isTonpuu())
+
+	assert.False(t, (*majsoulGameConfig)(nil).isTonpuu())
+}
+
+func Test_majsoulRoundData_ParseStanding(t *testing.T) {
+	d := &majsoulRoundData{selfSeat: 1}
+	d.roundData = newGame(d)
+	// 三麻南1局，自家为第一局的南家
+	d.reset(4, 0, 3)
+	d.msg = &majsoulMessage{Scores: json.RawMessage(`[40000,30000,35000]`), Liqibang: new(int)}
+	*d.msg.Liqibang = 1
+
+	standing := d.ParseStanding()
+	if !assert.NotNil(t, standing) {
+		return
+	}
+	// 自家座位 1，下家座位 2，起家座位 0 为上家，座位 3 不存在（对家）
+	assert.Equal(t, []int{30000, 35000, 0, 40000}, standing.Scores)
+	assert.Equal(t, 2, standing.AbsentPlayer)
+	assert.Equal(t, 3, standing.FirstDealer)
+	assert.Equal(t, 1, standing.RiichiSticks)
+
+	d.msg = &majsoulMessage{}
+	assert.Nil(t, d.ParseStanding())
+}
@@ -0,0 +1,393 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// 离线分析通过 platform/majsoul.DownloadRecords 下载的雀魂牌谱
+// 文件格式为 {"head": {...}, "details": [{"name": "RecordNewRound", "data": {...}}, ...]}
+// 也支持只有 details 的 [{"name": ..., "data": ...}, ...]
+
+type majsoulRecordFile struct {
+	Head    *majsoulRecordBaseInfo `json:"head"`
+	Details []*majsoulRecordAction `json:"details"`
+}
+
+func loadMajsoulRecordFile(filePath string) (*majsoulRecordFile, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &majsoulRecordFile{}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		err = json.Unmarshal(data, &record.Details)
+	} else {
+		err = json.Unmarshal(data, record)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析牌谱 %s 失败: %v", filePath, err)
+	}
+	if len(record.Details) == 0 {
+		return nil, fmt.Errorf("数据异常：牌谱 %s 内容为空", filePath)
+	}
+
+	for _, action := range record.Details {
+		action.fillDefaultValues()
+	}
+	return record, nil
+}
+
+// 下载的牌谱是用 encoding/json 序列化 protobuf 消息得到的，零值字段都被省略了
+// 这里按照网页发来的数据格式补全
+func (a *majsoulRecordAction) fillDefaultValues() {
+	msg := a.Action
+	if msg == nil {
+		a.Action = &majsoulMessage{}
+		return
+	}
+
+	fillInt := func(p **int) {
+		if *p == nil {
+			*p = new(int)
+		}
+	}
+	fillBool := func(p **bool) {
+		if *p == nil {
+			*p = new(bool)
+		}
+	}
+
+	switch a.Name {
+	case "RecordNewRound":
+		fillInt(&msg.Chang)
+		fillInt(&msg.Ju)
+		fillInt(&msg.Ben)
+		// IsInit 依赖 md5 判断
+		if msg.MD5 == "" {
+			msg.MD5 = "-"
+		}
+	case "RecordDealTile", "RecordChiPengGang", "RecordAnGangAddGang":
+		fillInt(&msg.Seat)
+	case "RecordDiscardTile":
+		fillInt(&msg.Seat)
+		fillBool(&msg.IsLiqi)
+		fillBool(&msg.IsWliqi)
+		fillBool(&msg.Moqie)
+	case "RecordBaBei":
+		fillInt(&msg.Seat)
+		fillBool(&msg.Moqie)
+	}
+}
+
+// 牌谱中的所有座位
+func (f *majsoulRecordFile) seats() (seats []int) {
+	if f.Head != nil && len(f.Head.Accounts) > 0 {
+		f.Head.sort()
+		for _, account := range f.Head.Accounts {
+			seats = append(seats, account.Seat)
+		}
+		return
+	}
+	for _, action := range f.Details {
+		if action.Name == "RecordNewRound" {
+			if len(action.Action.Tiles3) > 0 {
+				return []int{0, 1, 2, 3}
+			}
+			return []int{0, 1, 2}
+		}
+	}
+	return
+}
+
+func (f *majsoulRecordFile) uuid() string {
+	if f.Head == nil {
+		return ""
+	}
+	return f.Head.UUID
+}
+
+func (f *majsoulRecordFile) account(seat int) *_majsoulRecordAccount {
+	if f.Head == nil {
+		return nil
+	}
+	for i, account := range f.Head.Accounts {
+		if account.Seat == seat {
+			return &f.Head.Accounts[i]
+		}
+	}
+	return nil
+}
+
+//
+
+type majsoulRoundAnalysis struct {
+	roundNumber int
+	benNumber   int
+	cache       *roundAnalysisCache
+}
+
+func (a *majsoulRoundAnalysis) roundName() string {
+	return fmt.Sprintf("%s%d局%d本场", util.MahjongZH[27+a.roundNumber/4], a.roundNumber%4+1, a.benNumber)
+}
+
+type majsoulSeatAnalysis struct {
+	seat     int
+	nickname string
+	rounds   []*majsoulRoundAnalysis
+}
+
+func (a *majsoulSeatAnalysis) quality() *decisionQuality {
+	q := &decisionQuality{}
+	for _, round := range a.rounds {
+		q.merge(round.cache.quality())
+	}
+	return q
+}
+
+type majsoulRecordAnalysis struct {
+	uuid  string
+	seats []*majsoulSeatAnalysis
+}
+
+// 对牌谱中的每一局、每个座位计算 AI 舍牌推荐
+func analysisMajsoulRecordFile(f *majsoulRecordFile) (*majsoulRecordAnalysis, error) {
+	roundActionsList, err := parseMajsoulRecordAction(f.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	if f.Head != nil {
+		util.SetConsiderOldYaku(f.Head.Config.isGuyiMode())
+	}
+
+	// 离线分析时没有当前账号，随便设置一个以免消息被跳过
+	if gameConf.currentActiveMajsoulAccountID == -1 {
+		gameConf.setMajsoulAccountID(1)
+	}
+	defer resetAnalysisCache()
+
+	result := &majsoulRecordAnalysis{uuid: f.uuid()}
+	isCanceled := func() bool { return false }
+	for _, seat := range f.seats() {
+		seatAnalysis := &majsoulSeatAnalysis{seat: seat}
+		if account := f.account(seat); account != nil {
+			seatAnalysis.nickname = account.Nickname
+		}
+
+		analysisCache := newGameAnalysisCache(f.uuid(), seat)
+		setAnalysisCache(analysisCache)
+		for _, actions := range roundActionsList {
+			roundCache, err := analysisCache.analysisMajsoulRoundActions(actions, isCanceled)
+			if err != nil {
+				return nil, err
+			}
+			if roundCache == nil {
+				continue
+			}
+			data := actions[0].Action
+			seatAnalysis.rounds = append(seatAnalysis.rounds, &majsoulRoundAnalysis{
+				roundNumber: 4*(*data.Chang) + *data.Ju,
+				benNumber:   *data.Ben,
+				cache:       roundCache,
+			})
+		}
+		result.seats = append(result.seats, seatAnalysis)
+	}
+	return result, nil
+}
+
+func (a *majsoulRecordAnalysis) print() {
+	for _, seatAnalysis := range a.seats {
+		fmt.Printf("%s家 %s\n", seatNameZH[seatAnalysis.seat], seatAnalysis.nickname)
+		fmt.Println()
+		for _, round := range seatAnalysis.rounds {
+			fmt.Println(round.roundName())
+			round.cache.print()
+		}
+		fmt.Println("整场统计　" + seatAnalysis.quality().String())
+		fmt.Println()
+	}
+}
+
+//
+
+type analysisCacheExport struct {
+	Turn                     int     `json:"turn"`
+	SelfDiscardTile          string  `json:"self_discard_tile"`
+	SelfDiscardTileRisk      float64 `json:"self_discard_tile_risk"`
+	IsRiichiWhenDiscard      bool    `json:"is_riichi_when_discard"`
+	AIAttackDiscardTile      string  `json:"ai_attack_discard_tile"`
+	AIAttackDiscardTileRisk  float64 `json:"ai_attack_discard_tile_risk"`
+	AIDefenceDiscardTile     string  `json:"ai_defence_discard_tile"`
+	AIDefenceDiscardTileRisk float64 `json:"ai_defence_discard_tile_risk"`
+}
+
+type decisionQualityExport struct {
+	DiscardCount      int     `json:"discard_count"`
+	AttackMatchCount  int     `json:"attack_match_count"`
+	AttackMatchRate   float64 `json:"attack_match_rate"`
+	DefenceCount      int     `json:"defence_count"`
+	DefenceMatchCount int     `json:"defence_match_count"`
+	DefenceMatchRate  float64 `json:"defence_match_rate"`
+	ExcessRisk        float64 `json:"excess_risk"`
+	WaitsLost         int     `json:"waits_lost"`
+	ShantenBackCount  int     `json:"shanten_back_count"`
+	MissedCallCount   int     `json:"missed_call_count"`
+	BadCallCount      int     `json:"bad_call_count"`
+}
+
+type majsoulRoundAnalysisExport struct {
+	RoundNumber int                    `json:"round_number"`
+	BenNumber   int                    `json:"ben_number"`
+	Turns       []*analysisCacheExport `json:"turns"`
+	Quality     *decisionQualityExport `json:"quality"`
+}
+
+type majsoulSeatAnalysisExport struct {
+	Seat     int                           `json:"seat"`
+	Nickname string                        `json:"nickname"`
+	Rounds   []*majsoulRoundAnalysisExport `json:"rounds"`
+	Quality  *decisionQualityExport        `json:"quality"`
+}
+
+type majsoulRecordAnalysisExport struct {
+	UUID  string                       `json:"uuid"`
+	Seats []*majsoulSeatAnalysisExport `json:"seats"`
+}
+
+// 无牌时为空字符串
+func exportTile(tile int) string {
+	if tile < 0 {
+		return ""
+	}
+	return util.Mahjong[tile]
+}
+
+func (c *analysisCache) export(turn int) *analysisCacheExport {
+	return &analysisCacheExport{
+		Turn:                     turn,
+		SelfDiscardTile:          exportTile(c.selfDiscardTile),
+		SelfDiscardTileRisk:      c.selfDiscardTileRisk,
+		IsRiichiWhenDiscard:      c.isRiichiWhenDiscard,
+		AIAttackDiscardTile:      exportTile(c.aiAttackDiscardTile),
+		AIAttackDiscardTileRisk:  c.aiAttackDiscardTileRisk,
+		AIDefenceDiscardTile:     exportTile(c.aiDefenceDiscardTile),
+		AIDefenceDiscardTileRisk: c.aiDefenceDiscardTileRisk,
+	}
+}
+
+func (q *decisionQuality) export() *decisionQualityExport {
+	return &decisionQualityExport{
+		DiscardCount:      q.discardCount,
+		AttackMatchCount:  q.attackMatchCount,
+		AttackMatchRate:   q.attackMatchRate(),
+		DefenceCount:      q.defenceCount,
+		DefenceMatchCount: q.defenceMatchCount,
+		DefenceMatchRate:  q.defenceMatchRate(),
+		ExcessRisk:        q.excessRisk,
+		WaitsLost:         q.waitsLost,
+		ShantenBackCount:  q.shantenBackCount,
+		MissedCallCount:   q.missedCallCount,
+		BadCallCount:      q.badCallCount,
+	}
+}
+
+func (a *majsoulRecordAnalysis) export() *majsoulRecordAnalysisExport {
+	recordExport := &majsoulRecordAnalysisExport{UUID: a.uuid}
+	for _, seatAnalysis := range a.seats {
+		seatExport := &majsoulSeatAnalysisExport{
+			Seat:     seatAnalysis.seat,
+			Nickname: seatAnalysis.nickname,
+			Quality:  seatAnalysis.quality().export(),
+		}
+		for _, round := range seatAnalysis.rounds {
+			roundExport := &majsoulRoundAnalysisExport{
+				RoundNumber: round.roundNumber,
+				BenNumber:   round.benNumber,
+				Quality:     round.cache.quality().export(),
+			}
+			for i, c := range round.cache.cache {
+				roundExport.Turns = append(roundExport.Turns, c.export(i+1))
+			}
+			seatExport.Rounds = append(seatExport.Rounds, roundExport)
+		}
+		recordExport.Seats = append(recordExport.Seats, seatExport)
+	}
+	return recordExport
+}
+
+//
+
+// path 为文件或目录，目录下只读取 .json 文件
+func majsoulRecordFilePaths(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	fileInfos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	filePaths := []string{}
+	for _, fi := range fileInfos {
+		if !fi.IsDir() && strings.ToLower(filepath.Ext(fi.Name())) == ".json" {
+			filePaths = append(filePaths, filepath.Join(path, fi.Name()))
+		}
+	}
+	return filePaths, nil
+}
+
+// exportPath 不为空时将分析结果导出为 JSON 文件，否则打印到终端
+func runMajsoulRecordFileAnalysis(path string, exportPath string) error {
+	filePaths, err := majsoulRecordFilePaths(path)
+	if err != nil {
+		return err
+	}
+	if len(filePaths) == 0 {
+		return fmt.Errorf("%s 中没有找到雀魂牌谱", path)
+	}
+
+	exports := []*majsoulRecordAnalysisExport{}
+	for _, filePath := range filePaths {
+		record, err := loadMajsoulRecordFile(filePath)
+		if err != nil {
+			return err
+		}
+		if exportPath == "" && record.Head != nil {
+			fmt.Print(record.Head)
+			fmt.Println()
+		}
+
+		analysis, err := analysisMajsoulRecordFile(record)
+		if err != nil {
+			return fmt.Errorf("分析牌谱 %s 失败: %v", filePath, err)
+		}
+
+		if exportPath == "" {
+			analysis.print()
+		} else {
+			exports = append(exports, analysis.export())
+			fmt.Printf("%s 分析完成\n", filePath)
+		}
+	}
+
+	if exportPath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(exports, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(exportPath, data, 0644)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 零值字段已被省略，同 DownloadRecords 的输出
+const testMajsoulRecordFile = `{
+  "head": {"uuid": "test-uuid", "accounts": [{"account_id": 2, "seat": 1, "nickname": "B"}, {"account_id": 1, "nickname": "A"}, {"account_id": 3, "seat": 2, "nickname": "C"}, {"account_id": 4, "seat": 3, "nickname": "D"}]},
+  "details": [
+    {"name": "RecordNewRound", "data": {"dora": "1z", "md5": "x",
+      "tiles0": ["1m", "2m", "3m", "4m", "5m", "6m", "7m", "8m", "9m", "1p", "2p", "3p", "1z", "2z"],
+      "tiles1": ["1s", "2s", "3s", "4s", "5s", "6s", "7s", "8s", "9s", "4p", "5p", "6p", "7p"],
+      "tiles2": ["1m", "2m", "3m", "4m", "5m", "6m", "7m", "8m", "9m", "1p", "2p", "3p", "3z"],
+      "tiles3": ["1s", "2s", "3s", "4s", "5s", "6s", "7s", "8s", "9s", "4p", "5p", "6p", "4z"]}},
+    {"name": "RecordDiscardTile", "data": {"tile": "2z"}},
+    {"name": "RecordDealTile", "data": {"seat": 1, "tile": "7z"}},
+    {"name": "RecordDiscardTile", "data": {"seat": 1, "tile": "7z", "moqie": true}},
+    {"name": "RecordDealTile", "data": {"seat": 2, "tile": "6z"}},
+    {"name": "RecordDiscardTile", "data": {"seat": 2, "tile": "6z", "moqie": true}},
+    {"name": "RecordDealTile", "data": {"seat": 3, "tile": "5z"}},
+    {"name": "RecordDiscardTile", "data": {"seat": 3, "tile": "5z", "moqie": true}},
+    {"name": "RecordDealTile", "data": {"tile": "1z"}},
+    {"name": "RecordDiscardTile", "data": {"tile": "1z", "moqie": true}},
+    {"name": "RecordNoTile", "data": {}}
+  ]
+}`
+
+func TestAnalysisMajsoulRecordFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "majsoul-record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "test-uuid.json")
+	if err := ioutil.WriteFile(filePath, []byte(testMajsoulRecordFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	record, err := loadMajsoulRecordFile(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2, 3}, record.seats())
+
+	analysis, err := analysisMajsoulRecordFile(record)
+	assert.NoError(t, err)
+	assert.Len(t, analysis.seats, 4)
+
+	seat0 := analysis.seats[0]
+	assert.Equal(t, "A", seat0.nickname)
+	assert.Len(t, seat0.rounds, 1)
+	cache := seat0.rounds[0].cache.cache
+	assert.Len(t, cache, 2)
+	assert.Equal(t, 28, cache[0].selfDiscardTile)
+	assert.Equal(t, 27, cache[1].selfDiscardTile)
+	assert.NotEqual(t, -1, cache[0].aiAttackDiscardTile)
+
+	seat1 := analysis.seats[1]
+	assert.Len(t, seat1.rounds[0].cache.cache, 1)
+	assert.Equal(t, 33, seat1.rounds[0].cache.cache[0].selfDiscardTile)
+
+	exportPath := filepath.Join(dir, "export.out")
+	assert.NoError(t, runMajsoulRecordFileAnalysis(dir, exportPath))
+	data, err := ioutil.ReadFile(exportPath)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"self_discard_tile": "2z"`)
+}
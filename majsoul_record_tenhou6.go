@@ -0,0 +1,438 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/platform/tenhou"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 将雀魂牌谱转换成 tenhou.net/6 格式，便于在天凤牌谱查看器及其他牌谱工具中打开
+
+var majsoulLiuJuNames = map[int]string{
+	1: "九種九牌",
+	2: "四風連打",
+	3: "四槓散了",
+	4: "四家立直",
+	5: "三家和了",
+}
+
+func majsoulTileToTenhou6Tile(majsoulTile string) (int, error) {
+	tile34, isRedFive, err := util.StrToTile34(majsoulTile)
+	if err != nil {
+		return -1, err
+	}
+	return tenhou.Tile34ToTenhou6Tile(tile34, isRedFive), nil
+}
+
+func majsoulTilesToTenhou6Tiles(majsoulTiles []string) ([]int, error) {
+	tiles := make([]int, len(majsoulTiles))
+	for i, majsoulTile := range majsoulTiles {
+		tile, err := majsoulTileToTenhou6Tile(majsoulTile)
+		if err != nil {
+			return nil, err
+		}
+		tiles[i] = tile
+	}
+	return tiles, nil
+}
+
+func joinTenhou6Tiles(tiles []int) string {
+	s := ""
+	for _, tile := range tiles {
+		s += strconv.Itoa(tile)
+	}
+	return s
+}
+
+// 役满、满贯等的名称，不足满贯时返回空字符串
+func tenhou6LimitName(han int, fu int, isYakuman bool) string {
+	switch {
+	case isYakuman:
+		return "役満"
+	case han >= 13:
+		return "数え役満"
+	case han >= 11:
+		return "三倍満"
+	case han >= 8:
+		return "倍満"
+	case han >= 6:
+		return "跳満"
+	case han >= 5 || han == 4 && fu >= 40 || han == 3 && fu >= 70:
+		return "満貫"
+	default:
+		return ""
+	}
+}
+
+type tenhou6RoundBuilder struct {
+	round        *tenhou.Tenhou6Round
+	playerNumber int
+
+	pons            []map[int]string // 各家的碰 tile34 -> 碰的字符串，用于加杠
+	lastDiscardSeat int              // 最近一次舍牌或加杠的座位，用于荣和
+}
+
+func (b *tenhou6RoundBuilder) updateDoras(majsoulDoras []string) error {
+	if len(majsoulDoras) <= len(b.round.DoraIndicators) {
+		return nil
+	}
+	doras, err := majsoulTilesToTenhou6Tiles(majsoulDoras)
+	if err != nil {
+		return err
+	}
+	b.round.DoraIndicators = doras
+	return nil
+}
+
+func (b *tenhou6RoundBuilder) newRound(msg *majsoulMessage) error {
+	if len(msg.Tiles3) > 0 {
+		b.playerNumber = 4
+	} else {
+		b.playerNumber = 3
+	}
+	n := b.playerNumber
+
+	r := &tenhou.Tenhou6Round{
+		RoundNumber: 4*(*msg.Chang) + *msg.Ju,
+		BenNumber:   *msg.Ben,
+		Hais:        make([][]int, n),
+		Takes:       make([][]interface{}, n),
+		Discards:    make([][]interface{}, n),
+	}
+	b.round = r
+	b.pons = make([]map[int]string, n)
+	b.lastDiscardSeat = -1
+
+	if msg.Liqibang != nil {
+		r.ReachStickNumber = *msg.Liqibang
+	}
+	if len(msg.Scores) > 0 {
+		if err := json.Unmarshal(msg.Scores, &r.Scores); err != nil {
+			return err
+		}
+	}
+	if len(r.Scores) < n {
+		r.Scores = make([]int, n)
+		for i := range r.Scores {
+			r.Scores[i] = 25000
+		}
+	}
+
+	doras := msg.Doras
+	if len(doras) == 0 && msg.Dora != "" {
+		doras = []string{msg.Dora}
+	}
+	if err := b.updateDoras(doras); err != nil {
+		return err
+	}
+
+	for seat, majsoulTiles := range [][]string{msg.Tiles0, msg.Tiles1, msg.Tiles2, msg.Tiles3}[:n] {
+		tiles, err := majsoulTilesToTenhou6Tiles(majsoulTiles)
+		if err != nil {
+			return err
+		}
+		if len(tiles) == 14 {
+			// 庄家的第 14 张牌视作第一次摸牌
+			r.Takes[seat] = append(r.Takes[seat], tiles[13])
+			tiles = tiles[:13]
+		}
+		r.Hais[seat] = tiles
+		b.pons[seat] = map[int]string{}
+	}
+	return nil
+}
+
+// 吃碰大明杠，被鸣的牌前面加上 c p m，其位置表示来源：上家在最前，对家在第二张，下家在最后
+func (b *tenhou6RoundBuilder) chiPengGang(msg *majsoulMessage) error {
+	seat := *msg.Seat
+	majsoulTiles := (&majsoulRoundData{}).normalTiles(msg.Tiles)
+	if len(majsoulTiles) != len(msg.Froms) {
+		return fmt.Errorf("数据异常：鸣牌 %v 来源 %v", majsoulTiles, msg.Froms)
+	}
+
+	calledTile, fromSeat := -1, -1
+	selfTiles := []int{}
+	for i, majsoulTile := range majsoulTiles {
+		tile, err := majsoulTileToTenhou6Tile(majsoulTile)
+		if err != nil {
+			return err
+		}
+		if msg.Froms[i] != seat {
+			calledTile, fromSeat = tile, msg.Froms[i]
+		} else {
+			selfTiles = append(selfTiles, tile)
+		}
+	}
+	if calledTile == -1 {
+		return fmt.Errorf("数据异常：鸣牌 %v 来源 %v", majsoulTiles, msg.Froms)
+	}
+
+	called := strconv.Itoa(calledTile)
+	var meld string
+	switch msg.Type {
+	case majsoulMeldTypeChi:
+		meld = "c" + called + joinTenhou6Tiles(selfTiles)
+	case majsoulMeldTypePon, majsoulMeldTypeMinkanOrKakan:
+		letter := "p"
+		if msg.Type == majsoulMeldTypeMinkanOrKakan {
+			letter = "m"
+		}
+		switch fromSeat {
+		case (seat + b.playerNumber - 1) % b.playerNumber: // 上家
+			meld = letter + called + joinTenhou6Tiles(selfTiles)
+		case (seat + 1) % b.playerNumber: // 下家
+			meld = joinTenhou6Tiles(selfTiles) + letter + called
+		default: // 对家
+			meld = joinTenhou6Tiles(selfTiles[:1]) + letter + called + joinTenhou6Tiles(selfTiles[1:])
+		}
+		if msg.Type == majsoulMeldTypePon {
+			calledTile34, _ := tenhou.Tenhou6TileToTile34(calledTile)
+			b.pons[seat][calledTile34] = meld
+		}
+	default:
+		return fmt.Errorf("数据异常：鸣牌类型 %d", msg.Type)
+	}
+
+	b.round.Takes[seat] = append(b.round.Takes[seat], meld)
+	if msg.Type == majsoulMeldTypeMinkanOrKakan {
+		b.round.Discards[seat] = append(b.round.Discards[seat], 0)
+	}
+	return nil
+}
+
+// 暗杠如 424242a42，加杠为在碰的字符串中把 p 替换成 k 加上加杠的牌，如 37k373737
+func (b *tenhou6RoundBuilder) anGangAddGang(msg *majsoulMessage) error {
+	seat := *msg.Seat
+	majsoulTiles := (&majsoulRoundData{}).normalTiles(msg.Tiles)
+	tile34, isRedFive, err := util.StrToTile34(majsoulTiles[0])
+	if err != nil {
+		return err
+	}
+	tile := tenhou.Tile34ToTenhou6Tile(tile34, false)
+
+	var meld string
+	switch msg.Type {
+	case majsoulMeldTypeAnkan:
+		lastTile := tile
+		if tile34 < 27 && tile34%9 == 4 {
+			// 杠5意味着一定有赤5
+			lastTile = tenhou.Tile34ToTenhou6Tile(tile34, true)
+		}
+		meld = joinTenhou6Tiles([]int{tile, tile, tile}) + "a" + strconv.Itoa(lastTile)
+	case majsoulMeldTypeMinkanOrKakan:
+		pon, ok := b.pons[seat][tile34]
+		if !ok {
+			return fmt.Errorf("数据异常：%d 号座位加杠 %s 前没有碰", seat, majsoulTiles[0])
+		}
+		meld = strings.Replace(pon, "p", "k"+strconv.Itoa(tenhou.Tile34ToTenhou6Tile(tile34, isRedFive)), 1)
+		b.lastDiscardSeat = seat
+	default:
+		return fmt.Errorf("数据异常：杠的类型 %d", msg.Type)
+	}
+
+	b.round.Discards[seat] = append(b.round.Discards[seat], meld)
+	return b.updateDoras(msg.Doras)
+}
+
+func (b *tenhou6RoundBuilder) hule(msg *majsoulMessage) {
+	result := []interface{}{"和了"}
+	for i, hule := range msg.Hules {
+		deltas := make([]int, b.playerNumber)
+		if i == 0 && len(msg.DeltaScores) == b.playerNumber {
+			// 多家和了时，点数变化都记在第一家上
+			deltas = msg.DeltaScores
+		}
+
+		fromSeat := b.lastDiscardSeat
+		if hule.Zimo {
+			fromSeat = hule.Seat
+		}
+
+		point := ""
+		if limitName := tenhou6LimitName(hule.Count, hule.Fu, hule.Yiman); limitName != "" {
+			point = limitName
+		} else {
+			point = fmt.Sprintf("%d符%d飜", hule.Fu, hule.Count)
+		}
+		switch {
+		case !hule.Zimo:
+			point += fmt.Sprintf("%d点", hule.PointRong)
+		case hule.Qinjia:
+			point += fmt.Sprintf("%d点∀", hule.PointZimoXian)
+		default:
+			point += fmt.Sprintf("%d-%d点", hule.PointZimoXian, hule.PointZimoQin)
+		}
+
+		result = append(result, deltas, []interface{}{hule.Seat, fromSeat, hule.Seat, point})
+
+		if len(b.round.UraDoraIndicators) == 0 && len(hule.LiDoras) > 0 {
+			b.round.UraDoraIndicators, _ = majsoulTilesToTenhou6Tiles(hule.LiDoras)
+		}
+	}
+	b.round.Result = result
+}
+
+func (b *tenhou6RoundBuilder) noTile(msg *majsoulMessage) {
+	var scores []struct {
+		DeltaScores []int `json:"delta_scores"`
+	}
+	b.round.Result = []interface{}{"流局"}
+	if len(msg.Scores) > 0 && json.Unmarshal(msg.Scores, &scores) == nil && len(scores) > 0 && len(scores[0].DeltaScores) > 0 {
+		b.round.Result = append(b.round.Result, scores[0].DeltaScores)
+	}
+}
+
+func newTenhou6Round(actions majsoulRoundActions) (*tenhou.Tenhou6Round, error) {
+	if len(actions) == 0 || actions[0].Name != "RecordNewRound" {
+		return nil, fmt.Errorf("数据异常：未收到 RecordNewRound")
+	}
+
+	b := &tenhou6RoundBuilder{}
+	if err := b.newRound(actions[0].Action); err != nil {
+		return nil, err
+	}
+	r := b.round
+
+	for _, action := range actions[1:] {
+		msg := action.Action
+		if msg.Seat != nil && *msg.Seat >= b.playerNumber {
+			return nil, fmt.Errorf("数据异常：座位 %d", *msg.Seat)
+		}
+
+		switch action.Name {
+		case "RecordDealTile":
+			seat := *msg.Seat
+			tile, err := majsoulTileToTenhou6Tile(msg.Tile)
+			if err != nil {
+				return nil, err
+			}
+			r.Takes[seat] = append(r.Takes[seat], tile)
+			if err := b.updateDoras(msg.Doras); err != nil {
+				return nil, err
+			}
+		case "RecordDiscardTile":
+			seat := *msg.Seat
+			tile, err := majsoulTileToTenhou6Tile(msg.Tile)
+			if err != nil {
+				return nil, err
+			}
+			if *msg.Moqie {
+				tile = tenhou.Tenhou6Tsumogiri
+			}
+			var discard interface{} = tile
+			if *msg.IsLiqi || *msg.IsWliqi {
+				discard = "r" + strconv.Itoa(tile)
+			}
+			r.Discards[seat] = append(r.Discards[seat], discard)
+			b.lastDiscardSeat = seat
+			if err := b.updateDoras(msg.Doras); err != nil {
+				return nil, err
+			}
+		case "RecordChiPengGang":
+			if err := b.chiPengGang(msg); err != nil {
+				return nil, err
+			}
+		case "RecordAnGangAddGang":
+			if err := b.anGangAddGang(msg); err != nil {
+				return nil, err
+			}
+		case "RecordBaBei":
+			r.Discards[*msg.Seat] = append(r.Discards[*msg.Seat], "f44")
+		case "RecordHule":
+			b.hule(msg)
+		case "RecordNoTile":
+			b.noTile(msg)
+		case "RecordLiuJu":
+			name, ok := majsoulLiuJuNames[msg.Type]
+			if !ok {
+				name = "流局"
+			}
+			r.Result = []interface{}{name}
+		}
+	}
+	return r, nil
+}
+
+func newTenhou6Log(f *majsoulRecordFile) (*tenhou.Tenhou6Log, error) {
+	roundActionsList, err := parseMajsoulRecordAction(f.Details)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &tenhou.Tenhou6Log{
+		Title: []string{"雀魂 " + f.uuid(), ""},
+		Rule:  tenhou.Tenhou6Rule{Disp: "雀魂", Aka: 1},
+	}
+	if f.Head != nil && f.Head.StartTime > 0 {
+		l.Title[1] = time.Unix(f.Head.StartTime, 0).Format("2006/01/02 15:04")
+	}
+	for _, seat := range f.seats() {
+		name := seatNameZH[seat] + "家"
+		if account := f.account(seat); account != nil {
+			name = account.Nickname
+		}
+		l.Name = append(l.Name, name)
+	}
+
+	for _, actions := range roundActionsList {
+		r, err := newTenhou6Round(actions)
+		if err != nil {
+			return nil, err
+		}
+		l.Log = append(l.Log, r)
+	}
+	return l, nil
+}
+
+// outPath 为文件或目录，转换多个牌谱时需为目录，文件名与原牌谱相同
+func runMajsoulRecordToTenhou6(path string, outPath string) error {
+	filePaths, err := majsoulRecordFilePaths(path)
+	if err != nil {
+		return err
+	}
+	if len(filePaths) == 0 {
+		return fmt.Errorf("%s 中没有找到雀魂牌谱", path)
+	}
+
+	isOutDir := len(filePaths) > 1
+	if fi, err := os.Stat(outPath); err == nil && fi.IsDir() {
+		isOutDir = true
+	}
+	if isOutDir {
+		if err := os.MkdirAll(outPath, 0755); err != nil {
+			return err
+		}
+	}
+
+	for _, filePath := range filePaths {
+		record, err := loadMajsoulRecordFile(filePath)
+		if err != nil {
+			return err
+		}
+		l, err := newTenhou6Log(record)
+		if err != nil {
+			return fmt.Errorf("转换牌谱 %s 失败: %v", filePath, err)
+		}
+		data, err := json.Marshal(l)
+		if err != nil {
+			return err
+		}
+
+		dst := outPath
+		if isOutDir {
+			dst = filepath.Join(outPath, filepath.Base(filePath))
+		}
+		if err := ioutil.WriteFile(dst, data, 0644); err != nil {
+			return err
+		}
+		fmt.Printf("%s 已转换为 %s\n", filePath, dst)
+	}
+	return nil
+}
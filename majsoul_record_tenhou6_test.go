@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/EndlessCheng/mahjong-helper/platform/tenhou"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// 东家切北，南家碰，西家摸切立直，东家荣和
+const testMajsoulRecordFileWithMeld = `{
+  "details": [
+    {"name": "RecordNewRound", "data": {"doras": ["1z"], "md5": "x", "scores": [25000, 25000, 25000, 25000],
+      "tiles0": ["1m", "2m", "3m", "4p", "5p", "6p", "7s", "8s", "9s", "1z", "1z", "7m", "8m", "4z"],
+      "tiles1": ["1s", "2s", "3s", "4s", "5s", "6s", "7s", "8s", "4z", "4z", "2p", "3p", "4m"],
+      "tiles2": ["1p", "2p", "3p", "4p", "5p", "6p", "7p", "8p", "9p", "2s", "3s", "4s", "6z"],
+      "tiles3": ["1s", "2s", "3s", "4s", "5s", "6s", "7s", "8s", "9s", "4p", "0p", "6p", "3z"]}},
+    {"name": "RecordDiscardTile", "data": {"tile": "4z"}},
+    {"name": "RecordChiPengGang", "data": {"seat": 1, "type": 1, "tiles": ["4z", "4z", "4z"], "froms": [1, 1, 0]}},
+    {"name": "RecordDiscardTile", "data": {"seat": 1, "tile": "4m"}},
+    {"name": "RecordDealTile", "data": {"seat": 2, "tile": "9m"}},
+    {"name": "RecordDiscardTile", "data": {"seat": 2, "tile": "9m", "moqie": true, "is_liqi": true}},
+    {"name": "RecordHule", "data": {"hules": [{"qinjia": true, "count": 2, "fu": 40, "point_rong": 3900}], "delta_scores": [3900, 0, -3900, 0]}}
+  ]
+}`
+
+func TestNewTenhou6Log(t *testing.T) {
+	record := &majsoulRecordFile{}
+	assert.NoError(t, json.Unmarshal([]byte(testMajsoulRecordFileWithMeld), record))
+	for _, action := range record.Details {
+		action.fillDefaultValues()
+	}
+
+	l, err := newTenhou6Log(record)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []string{"东家", "南家", "西家", "北家"}, l.Name)
+	assert.Len(t, l.Log, 1)
+
+	r := l.Log[0]
+	assert.Equal(t, []int{41}, r.DoraIndicators)
+	assert.Len(t, r.Hais[0], 13)
+	assert.Equal(t, []interface{}{44}, r.Takes[0])
+	assert.Contains(t, r.Hais[3], 52)
+	assert.Equal(t, []interface{}{"p444444"}, r.Takes[1])
+	assert.Equal(t, []interface{}{"r60"}, r.Discards[2])
+	assert.Equal(t, []interface{}{"和了", []int{3900, 0, -3900, 0}, []interface{}{0, 2, 0, "40符2飜3900点"}}, r.Result)
+
+	// 转换后的牌谱可以回放
+	data, err := json.Marshal(l)
+	assert.NoError(t, err)
+	tenhouRecord, err := tenhou.ParseTenhou6JSON(data)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, _, err = replayTenhouRecord(tenhouRecord, 0)
+	assert.NoError(t, err)
+}
+
+func TestRunMajsoulRecordToTenhou6(t *testing.T) {
+	dir, err := ioutil.TempDir("", "majsoul-record")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filePath := filepath.Join(dir, "test-uuid.json")
+	if err := ioutil.WriteFile(filePath, []byte(testMajsoulRecordFile), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	outPath := filepath.Join(dir, "tenhou6.json")
+	assert.NoError(t, runMajsoulRecordToTenhou6(filePath, outPath))
+
+	l := &tenhou.Tenhou6Log{}
+	data, err := ioutil.ReadFile(outPath)
+	assert.NoError(t, err)
+	assert.NoError(t, json.Unmarshal(data, l))
+	assert.Equal(t, []string{"A", "B", "C", "D"}, l.Name)
+	assert.Equal(t, []interface{}{"流局"}, l.Log[0].Result)
+
+	record, err := loadTenhouRecord(outPath)
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, _, err = replayTenhouRecord(record, 0)
+	assert.NoError(t, err)
+}
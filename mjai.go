@@ -0,0 +1,617 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// mjai 协议适配（兼容 Mortal / mjai.app 等本地对战环境）
+// 从 stdin 逐行读取事件（单个事件或事件数组），每行输出一个 JSON 作为响应
+// 事件通过 DataParser 接口喂给 roundData.analysis()，决策由 AutoPlayer 给出
+// 暂不支持鸣牌、杠、九种九牌，只会切牌、立直、和牌
+
+const (
+	mjaiTypeNone  = "none"
+	mjaiTypeDahai = "dahai"
+	mjaiTypeReach = "reach"
+	mjaiTypeHora  = "hora"
+)
+
+// 初始牌山中可摸的牌数（136 - 14 张王牌 - 4*13 张配牌）
+const mjaiInitLeftDrawTilesCount = 70
+
+type mjaiEvent struct {
+	Type string `json:"type"`
+
+	// start_game
+	ID    int      `json:"id"`
+	Names []string `json:"names,omitempty"`
+
+	// start_kyoku
+	Bakaze     string     `json:"bakaze,omitempty"`
+	Kyoku      int        `json:"kyoku,omitempty"`
+	Honba      int        `json:"honba"`
+	Kyotaku    int        `json:"kyotaku"`
+	Oya        int        `json:"oya"`
+	Scores     []int      `json:"scores,omitempty"`
+	Tehais     [][]string `json:"tehais,omitempty"`
+	DoraMarker string     `json:"dora_marker,omitempty"` // 也用于 dora 事件
+
+	// 绝对座位 0-3
+	Actor     int      `json:"actor"`
+	Target    int      `json:"target"`
+	Pai       string   `json:"pai,omitempty"`
+	Consumed  []string `json:"consumed,omitempty"`
+	Tsumogiri bool     `json:"tsumogiri"`
+
+	// hora ryukyoku
+	Deltas []int `json:"deltas,omitempty"`
+}
+
+// 响应，只有 type 为 none 时不带 actor
+type mjaiAction struct {
+	Type      string `json:"type"`
+	Actor     *int   `json:"actor,omitempty"`
+	Target    *int   `json:"target,omitempty"`
+	Pai       string `json:"pai,omitempty"`
+	Tsumogiri *bool  `json:"tsumogiri,omitempty"`
+}
+
+var mjaiHonorTiles = []string{"E", "S", "W", "N", "P", "F", "C"}
+
+// 1m-9m 1p-9p 1s-9s E S W N P F C，赤5为 5mr 5pr 5sr
+// 无法解析时返回 -1
+func parseMjaiTile(tile string) (tile34 int, isRedFive bool) {
+	for i, honorTile := range mjaiHonorTiles {
+		if tile == honorTile {
+			return 27 + i, false
+		}
+	}
+	if len(tile) == 3 && tile[0] == '5' && tile[2] == 'r' {
+		isRedFive = true
+		tile = tile[:2]
+	}
+	if len(tile) != 2 || tile[0] < '1' || tile[0] > '9' {
+		return -1, false
+	}
+	tileType := strings.IndexByte("mps", tile[1])
+	if tileType == -1 {
+		return -1, false
+	}
+	return 9*tileType + int(tile[0]-'1'), isRedFive
+}
+
+func parseMjaiTiles(tiles []string) (tiles34 []int, numRedFives []int) {
+	numRedFives = make([]int, 3)
+	for _, tile := range tiles {
+		tile34, isRedFive := parseMjaiTile(tile)
+		if tile34 == -1 {
+			continue
+		}
+		tiles34 = append(tiles34, tile34)
+		if isRedFive {
+			numRedFives[tile34/9]++
+		}
+	}
+	return
+}
+
+//
+
+type mjaiRoundData struct {
+	*roundData
+
+	id  int // 自家的绝对座位
+	msg *mjaiEvent
+}
+
+func newMjaiRoundData() *mjaiRoundData {
+	d := &mjaiRoundData{}
+	d.roundData = newGame(d)
+	// stdout 用于输出响应
+	d.roundData.skipOutput = true
+	d.roundData.playerNumber = 4
+	return d
+}
+
+// 绝对座位转为相对座位 0=自家, 1=下家, 2=对家, 3=上家
+func (d *mjaiRoundData) relativeSeat(actor int) int {
+	return (actor - d.id + 4) % 4
+}
+
+func (d *mjaiRoundData) GetDataSourceType() int {
+	return dataSourceTypeMjai
+}
+
+func (d *mjaiRoundData) GetSelfSeat() int {
+	return -1
+}
+
+func (d *mjaiRoundData) GetMessage() string {
+	data, _ := json.Marshal(d.msg)
+	return string(data)
+}
+
+func (d *mjaiRoundData) SkipMessage() bool {
+	switch d.msg.Type {
+	case "start_kyoku", "dahai", "chi", "pon", "daiminkan", "kakan", "ankan", "reach", "dora", "hora", "ryukyoku":
+		return false
+	case "tsumo":
+		// 他家摸牌没有信息
+		return d.msg.Actor != d.id
+	default:
+		return true
+	}
+}
+
+func (d *mjaiRoundData) IsLogin() bool {
+	return false
+}
+
+func (d *mjaiRoundData) HandleLogin() {
+}
+
+func (d *mjaiRoundData) IsInit() bool {
+	return d.msg.Type == "start_kyoku"
+}
+
+func (d *mjaiRoundData) ParseInit() (roundNumber int, benNumber int, dealer int, doraIndicators []int, handTiles []int, numRedFives []int) {
+	d.playerNumber = 4
+	roundWindTile, _ := parseMjaiTile(d.msg.Bakaze)
+	roundNumber = 4*(roundWindTile-27) + d.msg.Kyoku - 1
+	benNumber = d.msg.Honba
+	dealer = d.relativeSeat(d.msg.Oya)
+	doraIndicator, _ := parseMjaiTile(d.msg.DoraMarker)
+	doraIndicators = []int{doraIndicator}
+	handTiles, numRedFives = parseMjaiTiles(d.msg.Tehais[d.id])
+	return
+}
+
+func (d *mjaiRoundData) ParseStanding() *model.Standing {
+	if len(d.msg.Scores) != 4 {
+		return nil
+	}
+	standing := &model.Standing{
+		Scores:       make([]int, 4),
+		AbsentPlayer: -1,
+		FirstDealer:  d.relativeSeat(0),
+		RiichiSticks: d.msg.Kyotaku,
+	}
+	for actor, score := range d.msg.Scores {
+		standing.Scores[d.relativeSeat(actor)] = score
+	}
+	return standing
+}
+
+func (d *mjaiRoundData) IsSelfDraw() bool {
+	return d.msg.Type == "tsumo" && d.msg.Actor == d.id
+}
+
+func (d *mjaiRoundData) ParseSelfDraw() (tile int, isRedFive bool, kanDoraIndicator int) {
+	tile, isRedFive = parseMjaiTile(d.msg.Pai)
+	return tile, isRedFive, -1
+}
+
+func (d *mjaiRoundData) IsDiscard() bool {
+	return d.msg.Type == "dahai"
+}
+
+func (d *mjaiRoundData) ParseDiscard() (who int, discardTile int, isRedFive bool, isTsumogiri bool, isReach bool, canBeMeld bool, kanDoraIndicator int) {
+	who = d.relativeSeat(d.msg.Actor)
+	discardTile, isRedFive = parseMjaiTile(d.msg.Pai)
+	return who, discardTile, isRedFive, d.msg.Tsumogiri, false, who != 0, -1
+}
+
+func (d *mjaiRoundData) IsOpen() bool {
+	switch d.msg.Type {
+	case "chi", "pon", "daiminkan", "kakan", "ankan":
+		return true
+	default:
+		return false
+	}
+}
+
+func (d *mjaiRoundData) ParseOpen() (who int, meld *model.Meld, kanDoraIndicator int) {
+	who = d.relativeSeat(d.msg.Actor)
+
+	selfTiles, selfNumRedFives := parseMjaiTiles(d.msg.Consumed)
+	sort.Ints(selfTiles)
+	containRedFive := selfNumRedFives[0]+selfNumRedFives[1]+selfNumRedFives[2] > 0
+
+	meld = &model.Meld{SelfTiles: selfTiles}
+	if d.msg.Type == "ankan" {
+		meld.MeldType = meldTypeAnkan
+		meld.Tiles = selfTiles
+		meld.CalledTile = selfTiles[0]
+		meld.ContainRedFive = containRedFive
+		return who, meld, -1
+	}
+
+	calledTile, isCalledTileRedFive := parseMjaiTile(d.msg.Pai)
+	meld.Tiles = append(append([]int{}, selfTiles...), calledTile)
+	sort.Ints(meld.Tiles)
+	meld.CalledTile = calledTile
+	meld.ContainRedFive = containRedFive || isCalledTileRedFive
+	switch d.msg.Type {
+	case "chi":
+		meld.MeldType = meldTypeChi
+	case "pon":
+		meld.MeldType = meldTypePon
+	case "daiminkan":
+		meld.MeldType = meldTypeMinkan
+	case "kakan":
+		meld.MeldType = meldTypeKakan
+	}
+	meld.RedFiveFromOthers = isCalledTileRedFive && meld.MeldType != meldTypeKakan
+	return who, meld, -1
+}
+
+func (d *mjaiRoundData) IsReach() bool {
+	// 立直宣言，之后的 dahai 为宣言牌
+	return d.msg.Type == "reach"
+}
+
+func (d *mjaiRoundData) ParseReach() (who int) {
+	return d.relativeSeat(d.msg.Actor)
+}
+
+func (d *mjaiRoundData) IsFuriten() bool {
+	return false
+}
+
+func (d *mjaiRoundData) IsRoundWin() bool {
+	return d.msg.Type == "hora"
+}
+
+func (d *mjaiRoundData) ParseRoundWin() (whos []int, points []int) {
+	point := 0
+	if d.msg.Actor < len(d.msg.Deltas) {
+		point = d.msg.Deltas[d.msg.Actor]
+	}
+	return []int{d.relativeSeat(d.msg.Actor)}, []int{point}
+}
+
+func (d *mjaiRoundData) IsRyuukyoku() bool {
+	return d.msg.Type == "ryukyoku"
+}
+
+func (d *mjaiRoundData) ParseRyuukyoku() (type_ int, whos []int, points []int) {
+	return 0, nil, nil
+}
+
+func (d *mjaiRoundData) IsNukiDora() bool {
+	return false
+}
+
+func (d *mjaiRoundData) ParseNukiDora() (who int, isTsumogiri bool) {
+	return -1, false
+}
+
+func (d *mjaiRoundData) IsNewDora() bool {
+	return d.msg.Type == "dora"
+}
+
+func (d *mjaiRoundData) ParseNewDora() (kanDoraIndicator int) {
+	kanDoraIndicator, _ = parseMjaiTile(d.msg.DoraMarker)
+	return
+}
+
+//
+
+type mjaiBot struct {
+	parser     *mjaiRoundData
+	autoPlayer *AutoPlayer
+
+	// 以下为本局的真实数据，用于判断和牌、立直
+	id             int
+	roundWindTile  int
+	oya            int
+	scores         []int
+	doraIndicators []int
+	leftDrawCount  int
+
+	hands        []string // mjai 格式
+	drawTile     string   // 刚摸到的牌，切牌后清空
+	discardTiles []int    // 0-33
+	isReached    bool
+	isFuriten    bool // 同巡振听或立直后见逃
+
+	reachDiscardTile string // 立直宣言后要切的牌
+
+	isGameEnd bool
+}
+
+func newMjaiBot(strategy string) *mjaiBot {
+	config := autoPlayerConfig
+	config.Enabled = true
+	config.AutoDiscard = true
+	config.AutoRiichi = true
+	config.AutoAgari = true
+	config.ConfirmActions = false
+	config.DelaySeconds = 0
+	config.Strategy = strategy
+	return &mjaiBot{
+		parser:     newMjaiRoundData(),
+		autoPlayer: NewAutoPlayer(&config),
+	}
+}
+
+func (b *mjaiBot) tiles34() []int {
+	tiles34 := make([]int, 34)
+	for _, tile := range b.hands {
+		if tile34, _ := parseMjaiTile(tile); tile34 != -1 {
+			tiles34[tile34]++
+		}
+	}
+	return tiles34
+}
+
+func (b *mjaiBot) removeTile(tile string) {
+	for i, t := range b.hands {
+		if t == tile {
+			b.hands = append(b.hands[:i], b.hands[i+1:]...)
+			return
+		}
+	}
+}
+
+// 选出要切的牌，优先摸切，其次切非赤5
+func (b *mjaiBot) chooseTile(tile34 int) (tile string, isTsumogiri bool) {
+	if t, _ := parseMjaiTile(b.drawTile); t == tile34 {
+		return b.drawTile, true
+	}
+	for _, t := range b.hands {
+		if _t, isRedFive := parseMjaiTile(t); _t == tile34 {
+			tile = t
+			if !isRedFive {
+				break
+			}
+		}
+	}
+	return
+}
+
+// 判断 tiles34 (13 张) 是否舍牌振听
+func (b *mjaiBot) isDiscardFuriten(tiles34 []int) bool {
+	for _, tile := range b.discardTiles {
+		tiles34[tile]++
+		isAgari := util.CalculateShanten(tiles34) == -1
+		tiles34[tile]--
+		if isAgari {
+			return true
+		}
+	}
+	return false
+}
+
+// tiles34 为和牌时的 14 张牌，ron 时 winTile 不在 b.hands 中
+func (b *mjaiBot) calcPoint(tiles34 []int, winTile string, isTsumo bool) *util.PointResult {
+	hands := b.hands
+	if !isTsumo {
+		hands = append(append([]string{}, b.hands...), winTile)
+	}
+	_, numRedFives := parseMjaiTiles(hands)
+	winTile34, _ := parseMjaiTile(winTile)
+	playerInfo := &model.PlayerInfo{
+		HandTiles34:   tiles34,
+		DoraTiles:     model.DoraList(b.doraIndicators, false),
+		NumRedFives:   numRedFives,
+		IsTsumo:       isTsumo,
+		WinTile:       winTile34,
+		RoundWindTile: b.roundWindTile,
+		SelfWindTile:  27 + (b.id-b.oya+4)%4,
+		IsParent:      b.id == b.oya,
+		IsRiichi:      b.isReached,
+		DiscardTiles:  b.discardTiles,
+	}
+	b.parser.roundData.fillWinSituation(playerInfo)
+	return util.CalcPoint(playerInfo)
+}
+
+func (b *mjaiBot) newAction(type_ string) *mjaiAction {
+	return &mjaiAction{Type: type_, Actor: &b.id}
+}
+
+func (b *mjaiBot) newDahaiAction(tile string, isTsumogiri bool) *mjaiAction {
+	action := b.newAction(mjaiTypeDahai)
+	action.Pai = tile
+	action.Tsumogiri = &isTsumogiri
+	return action
+}
+
+// 自家摸牌后的决策：自摸、立直或切牌
+func (b *mjaiBot) onSelfDraw() *mjaiAction {
+	tiles34 := b.tiles34()
+	if util.CalculateShanten(tiles34) == -1 && b.calcPoint(tiles34, b.drawTile, true).Point > 0 {
+		action := b.newAction(mjaiTypeHora)
+		action.Target = &b.id
+		action.Pai = b.drawTile
+		return action
+	}
+
+	if b.isReached {
+		return b.newDahaiAction(b.drawTile, true)
+	}
+
+	d := b.parser.roundData
+	playerInfo := d.newModelPlayerInfo()
+	riskTables := d.analysisTilesRisk()
+	decision := b.autoPlayer.MakeDecision(playerInfo, riskTables.mixedRiskTable(), riskTables.opponentRisks(), -1, false, false, false)
+
+	discardTile34, _ := parseMjaiTile(b.drawTile)
+	if decision.Action == "discard" && decision.Tile >= 0 && tiles34[decision.Tile] > 0 {
+		discardTile34 = decision.Tile
+	}
+	tile, isTsumogiri := b.chooseTile(discardTile34)
+
+	// 剩余不到 4 张或不足 1000 点时无法立直
+	if b.autoPlayer.config.AutoRiichi && !d.players[0].isNaki && b.id < len(b.scores) && b.scores[b.id] >= 1000 && b.leftDrawCount >= 4 {
+		tiles34[discardTile34]--
+		if util.CalculateShanten(tiles34) == 0 {
+			b.reachDiscardTile = tile
+			return b.newAction(mjaiTypeReach)
+		}
+	}
+
+	return b.newDahaiAction(tile, isTsumogiri)
+}
+
+// 他家舍牌或加杠后的决策：荣和或跳过
+func (b *mjaiBot) onOtherDiscard(who int, tile string) *mjaiAction {
+	tile34, _ := parseMjaiTile(tile)
+	tiles34 := b.tiles34()
+	if b.isFuriten || b.isDiscardFuriten(tiles34) {
+		return nil
+	}
+	tiles34[tile34]++
+	if util.CalculateShanten(tiles34) != -1 {
+		return nil
+	}
+	if b.calcPoint(tiles34, tile, false).Point == 0 {
+		// 无役见逃
+		b.isFuriten = true
+		return nil
+	}
+	action := b.newAction(mjaiTypeHora)
+	action.Target = &who
+	action.Pai = tile
+	return action
+}
+
+// 处理一个事件，needAction 为 false 时只更新状态
+func (b *mjaiBot) handleEvent(event *mjaiEvent, needAction bool) (action *mjaiAction, err error) {
+	switch event.Type {
+	case "start_game":
+		b.id = event.ID
+	case "end_game":
+		b.isGameEnd = true
+	case "start_kyoku":
+		if b.id >= len(event.Tehais) {
+			return nil, fmt.Errorf("start_kyoku 缺少 %d 号座位的手牌", b.id)
+		}
+		b.roundWindTile, _ = parseMjaiTile(event.Bakaze)
+		b.oya = event.Oya
+		b.scores = event.Scores
+		doraIndicator, _ := parseMjaiTile(event.DoraMarker)
+		b.doraIndicators = []int{doraIndicator}
+		b.leftDrawCount = mjaiInitLeftDrawTilesCount
+		b.hands = append([]string{}, event.Tehais[b.id]...)
+		b.drawTile = ""
+		b.discardTiles = nil
+		b.isReached = false
+		b.isFuriten = false
+		b.reachDiscardTile = ""
+	case "tsumo":
+		b.leftDrawCount--
+		if event.Actor == b.id {
+			if tile34, _ := parseMjaiTile(event.Pai); tile34 == -1 {
+				return nil, fmt.Errorf("无法解析自家摸牌 %s", event.Pai)
+			}
+			b.hands = append(b.hands, event.Pai)
+			b.drawTile = event.Pai
+			if !b.isReached {
+				// 同巡振听解除
+				b.isFuriten = false
+			}
+		}
+	case "dahai":
+		if event.Actor == b.id {
+			b.removeTile(event.Pai)
+			tile34, _ := parseMjaiTile(event.Pai)
+			b.discardTiles = append(b.discardTiles, tile34)
+			b.drawTile = ""
+		}
+	case "reach":
+		if event.Actor == b.id {
+			b.isReached = true
+		}
+	case "dora":
+		doraIndicator, _ := parseMjaiTile(event.DoraMarker)
+		b.doraIndicators = append(b.doraIndicators, doraIndicator)
+	}
+
+	b.parser.id = b.id
+	b.parser.msg = event
+	if err := b.parser.analysis(); err != nil {
+		// 状态不同步不影响自家手牌，继续对局
+		fmt.Fprintln(os.Stderr, "mjai:", err)
+	}
+
+	if !needAction {
+		return nil, nil
+	}
+
+	switch {
+	case event.Type == "tsumo" && event.Actor == b.id:
+		action = b.onSelfDraw()
+	case event.Type == "reach" && event.Actor == b.id && b.reachDiscardTile != "":
+		tile := b.reachDiscardTile
+		b.reachDiscardTile = ""
+		action = b.newDahaiAction(tile, tile == b.drawTile)
+	case event.Type == "dahai" && event.Actor != b.id:
+		action = b.onOtherDiscard(event.Actor, event.Pai)
+	case event.Type == "kakan" && event.Actor != b.id:
+		// 抢杠
+		action = b.onOtherDiscard(event.Actor, event.Pai)
+	}
+	return action, nil
+}
+
+// 一行可以是单个事件，也可以是事件数组（此时只对最后一个事件做出决策）
+func (b *mjaiBot) handleLine(line []byte) (action *mjaiAction, err error) {
+	var events []*mjaiEvent
+	if line[0] == '[' {
+		err = json.Unmarshal(line, &events)
+	} else {
+		event := &mjaiEvent{}
+		err = json.Unmarshal(line, event)
+		events = []*mjaiEvent{event}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("无法解析 mjai 事件 %s: %v", line, err)
+	}
+
+	for i, event := range events {
+		if action, err = b.handleEvent(event, i == len(events)-1); err != nil {
+			return nil, err
+		}
+	}
+	if action == nil {
+		action = &mjaiAction{Type: mjaiTypeNone}
+	}
+	return action, nil
+}
+
+func runMjai(in io.Reader, out io.Writer, strategy string) error {
+	bot := newMjaiBot(strategy)
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		line := []byte(strings.TrimSpace(scanner.Text()))
+		if len(line) == 0 {
+			continue
+		}
+		action, err := bot.handleLine(line)
+		if err != nil {
+			return err
+		}
+		data, err := json.Marshal(action)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintln(out, string(data)); err != nil {
+			return err
+		}
+		if bot.isGameEnd {
+			return nil
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestParseMjaiTile(t *testing.T) {
+	for tile, expected := range map[string]int{"1m": 0, "9p": 17, "5s": 22, "E": 27, "N": 30, "C": 33, "5z": -1, "?": -1} {
+		tile34, isRedFive := parseMjaiTile(tile)
+		assert.Equal(t, expected, tile34, tile)
+		assert.False(t, isRedFive, tile)
+	}
+
+	tile34, isRedFive := parseMjaiTile("5pr")
+	assert.Equal(t, 13, tile34)
+	assert.True(t, isRedFive)
+}
+
+func TestRunMjai(t *testing.T) {
+	hiddenTehais := `["?","?","?","?","?","?","?","?","?","?","?","?","?"]`
+	// 每行事件及期望的响应
+	steps := [][2]string{
+		{`{"type":"start_game","id":0,"names":["a","b","c","d"]}`, `{"type":"none"}`},
+
+		// 东1局：断幺 6s 单骑，摸 N 后立直，立直后摸切，他家切 6s 荣和
+		{`{"type":"start_kyoku","bakaze":"E","kyoku":1,"honba":0,"kyotaku":0,"oya":0,"dora_marker":"1p","scores":[25000,25000,25000,25000],` +
+			`"tehais":[["2m","3m","4m","4m","5mr","6m","6p","7p","8p","3s","4s","5s","6s"],` + hiddenTehais + `,` + hiddenTehais + `,` + hiddenTehais + `]}`, `{"type":"none"}`},
+		{`{"type":"tsumo","actor":0,"pai":"N"}`, `{"type":"reach","actor":0}`},
+		{`{"type":"reach","actor":0}`, `{"type":"dahai","actor":0,"pai":"N","tsumogiri":true}`},
+		{`[{"type":"dahai","actor":0,"pai":"N","tsumogiri":true},{"type":"reach_accepted","actor":0},{"type":"tsumo","actor":1,"pai":"?"}]`, `{"type":"none"}`},
+		{`[{"type":"dahai","actor":1,"pai":"W","tsumogiri":true},{"type":"tsumo","actor":2,"pai":"?"},{"type":"dahai","actor":2,"pai":"1m","tsumogiri":false}]`, `{"type":"none"}`},
+		{`[{"type":"tsumo","actor":3,"pai":"?"},{"type":"dahai","actor":3,"pai":"C","tsumogiri":true},{"type":"tsumo","actor":0,"pai":"9p"}]`, `{"type":"dahai","actor":0,"pai":"9p","tsumogiri":true}`},
+		{`[{"type":"dahai","actor":0,"pai":"9p","tsumogiri":true},{"type":"tsumo","actor":1,"pai":"?"},{"type":"dahai","actor":1,"pai":"6s","tsumogiri":true}]`, `{"type":"hora","actor":0,"target":1,"pai":"6s"}`},
+		{`[{"type":"hora","actor":0,"target":1,"pai":"6s","deltas":[8000,-8000,0,0]},{"type":"end_kyoku"}]`, `{"type":"none"}`},
+
+		// 东2局：234m 567m 123p 678s 9s 单骑，默听无役，见逃后同巡振听，自摸和了
+		{`{"type":"start_kyoku","bakaze":"E","kyoku":2,"honba":0,"kyotaku":0,"oya":1,"dora_marker":"E","scores":[33000,17000,25000,25000],` +
+			`"tehais":[["2m","3m","4m","5m","6m","7m","1p","2p","3p","6s","7s","8s","9s"],` + hiddenTehais + `,` + hiddenTehais + `,` + hiddenTehais + `]}`, `{"type":"none"}`},
+		{`[{"type":"tsumo","actor":1,"pai":"?"},{"type":"dahai","actor":1,"pai":"9s","tsumogiri":true}]`, `{"type":"none"}`},
+		{`[{"type":"tsumo","actor":2,"pai":"?"},{"type":"dahai","actor":2,"pai":"9s","tsumogiri":true}]`, `{"type":"none"}`},
+		{`[{"type":"tsumo","actor":3,"pai":"?"},{"type":"dahai","actor":3,"pai":"N","tsumogiri":true},{"type":"tsumo","actor":0,"pai":"9s"}]`, `{"type":"hora","actor":0,"target":0,"pai":"9s"}`},
+		{`[{"type":"hora","actor":0,"target":0,"pai":"9s","deltas":[1500,-700,-400,-400]},{"type":"end_kyoku"}]`, `{"type":"none"}`},
+
+		{`{"type":"end_game"}`, `{"type":"none"}`},
+	}
+
+	events := []string{}
+	expected := []string{}
+	for _, step := range steps {
+		events = append(events, step[0])
+		expected = append(expected, step[1])
+	}
+	// end_game 之后的事件不再处理
+	events = append(events, `{"type":"start_game","id":1}`)
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, runMjai(strings.NewReader(strings.Join(events, "\n")), out, "balanced"))
+	assert.Equal(t, expected, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
+
+func TestRunMjaiChankan(t *testing.T) {
+	hiddenTehais := `["?","?","?","?","?","?","?","?","?","?","?","?","?"]`
+	// 234m 567m 123p 67s 99s 无役听 58s（点数不足无法立直），对家加杠 8s 时抢杠
+	steps := [][2]string{
+		{`{"type":"start_game","id":0,"names":["a","b","c","d"]}`, `{"type":"none"}`},
+		{`{"type":"start_kyoku","bakaze":"E","kyoku":2,"honba":0,"kyotaku":0,"oya":1,"dora_marker":"E","scores":[800,25000,25000,25000],` +
+			`"tehais":[["2m","3m","4m","5m","6m","7m","1p","2p","3p","6s","7s","9s","9s"],` + hiddenTehais + `,` + hiddenTehais + `,` + hiddenTehais + `]}`, `{"type":"none"}`},
+		{`[{"type":"tsumo","actor":1,"pai":"?"},{"type":"dahai","actor":1,"pai":"8s","tsumogiri":true},{"type":"pon","actor":2,"target":1,"pai":"8s","consumed":["8s","8s"]}]`, `{"type":"none"}`},
+		{`{"type":"dahai","actor":2,"pai":"N","tsumogiri":false}`, `{"type":"none"}`},
+		{`[{"type":"tsumo","actor":3,"pai":"?"},{"type":"dahai","actor":3,"pai":"C","tsumogiri":true},{"type":"tsumo","actor":0,"pai":"E"}]`, `{"type":"dahai","actor":0,"pai":"E","tsumogiri":true}`},
+		{`[{"type":"dahai","actor":0,"pai":"E","tsumogiri":true},{"type":"tsumo","actor":1,"pai":"?"},{"type":"dahai","actor":1,"pai":"W","tsumogiri":true}]`, `{"type":"none"}`},
+		{`[{"type":"tsumo","actor":2,"pai":"?"},{"type":"kakan","actor":2,"pai":"8s","consumed":["8s","8s","8s"]}]`, `{"type":"hora","actor":0,"target":2,"pai":"8s"}`},
+		{`{"type":"end_game"}`, `{"type":"none"}`},
+	}
+
+	events := []string{}
+	expected := []string{}
+	for _, step := range steps {
+		events = append(events, step[0])
+		expected = append(expected, step[1])
+	}
+
+	out := &bytes.Buffer{}
+	assert.NoError(t, runMjai(strings.NewReader(strings.Join(events, "\n")), out, "balanced"))
+	assert.Equal(t, expected, strings.Split(strings.TrimSpace(out.String()), "\n"))
+}
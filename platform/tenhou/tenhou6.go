@@ -0,0 +1,722 @@
+package tenhou
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tenhou.net/6 牌谱格式，即天凤牌谱查看器使用的 JSON 格式，也是各种牌谱转换工具的通用格式
+// {"title": [...], "name": [...], "rule": {"disp": "...", "aka": 1}, "log": [局, 局, ...]}
+// 每一局为一个数组：
+// [[场数, 本场数, 立直棒数], [各家点数], [宝牌指示牌], [里宝牌指示牌], 配牌0, 摸牌0, 舍牌0, 配牌1, ..., [结果]]
+// 牌：11-19 万 21-29 饼 31-39 索 41-47 东南西北白发中 51-53 赤5万 赤5饼 赤5索
+// 摸牌：数字为摸到的牌，字符串为吃碰杠，如 c275226（吃上家的 27），37p3737（碰对家的 37），m39393939（大明杠上家的 39）
+// 舍牌：数字为切出的牌，60 为摸切，0 为大明杠后的占位；字符串为 r 立直、a 暗杠、k 加杠、f 拔北，如 r45 424242a42 37k373737
+
+const (
+	Tenhou6Tsumogiri = 60
+
+	tenhou6KanPlaceholder = 0
+)
+
+type Tenhou6Rule struct {
+	Disp string `json:"disp"`
+	Aka  int    `json:"aka"` // 是否有赤宝牌
+}
+
+type Tenhou6Log struct {
+	Title []string        `json:"title"`
+	Name  []string        `json:"name"`
+	Rule  Tenhou6Rule     `json:"rule"`
+	Log   []*Tenhou6Round `json:"log"`
+}
+
+type Tenhou6Round struct {
+	RoundNumber      int // 东1局为 0，南1局为 4（三麻也是如此）
+	BenNumber        int
+	ReachStickNumber int
+
+	Scores            []int
+	DoraIndicators    []int
+	UraDoraIndicators []int
+
+	// 下标为座位（0 为起家）
+	Hais     [][]int
+	Takes    [][]interface{} // int 或 string
+	Discards [][]interface{} // int 或 string
+
+	// ["和了", [各家点数变化], [和牌者, 放铳者, 包牌者, "30符1飜1000点", "立直(1飜)", ...], ...]
+	// ["流局", [各家点数变化]] ["九種九牌"] 等
+	Result []interface{}
+}
+
+func (r *Tenhou6Round) MarshalJSON() ([]byte, error) {
+	ints := func(a []int) []int {
+		if a == nil {
+			return []int{}
+		}
+		return a
+	}
+	tiles := func(a []interface{}) []interface{} {
+		if a == nil {
+			return []interface{}{}
+		}
+		return a
+	}
+
+	round := []interface{}{
+		[]int{r.RoundNumber, r.BenNumber, r.ReachStickNumber},
+		ints(r.Scores),
+		ints(r.DoraIndicators),
+		ints(r.UraDoraIndicators),
+	}
+	for seat := range r.Hais {
+		round = append(round, ints(r.Hais[seat]), tiles(r.Takes[seat]), tiles(r.Discards[seat]))
+	}
+	round = append(round, tiles(r.Result))
+	return json.Marshal(round)
+}
+
+func (r *Tenhou6Round) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	if len(raw) < 5 || (len(raw)-5)%3 != 0 {
+		return fmt.Errorf("tenhou.net/6 牌谱格式错误：一局有 %d 项", len(raw))
+	}
+
+	var head []int
+	if err := json.Unmarshal(raw[0], &head); err != nil || len(head) != 3 {
+		return fmt.Errorf("tenhou.net/6 牌谱格式错误：%s", raw[0])
+	}
+	r.RoundNumber, r.BenNumber, r.ReachStickNumber = head[0], head[1], head[2]
+	for i, p := range []*[]int{&r.Scores, &r.DoraIndicators, &r.UraDoraIndicators} {
+		if err := json.Unmarshal(raw[1+i], p); err != nil {
+			return err
+		}
+	}
+
+	playerNumber := (len(raw) - 5) / 3
+	r.Hais = make([][]int, playerNumber)
+	r.Takes = make([][]interface{}, playerNumber)
+	r.Discards = make([][]interface{}, playerNumber)
+	for seat := 0; seat < playerNumber; seat++ {
+		i := 4 + 3*seat
+		if err := json.Unmarshal(raw[i], &r.Hais[seat]); err != nil {
+			return err
+		}
+		var err error
+		if r.Takes[seat], err = unmarshalTenhou6Tiles(raw[i+1]); err != nil {
+			return err
+		}
+		if r.Discards[seat], err = unmarshalTenhou6Tiles(raw[i+2]); err != nil {
+			return err
+		}
+	}
+	return json.Unmarshal(raw[len(raw)-1], &r.Result)
+}
+
+// 数字统一转换成 int
+func unmarshalTenhou6Tiles(data []byte) ([]interface{}, error) {
+	var tiles []interface{}
+	if err := json.Unmarshal(data, &tiles); err != nil {
+		return nil, err
+	}
+	for i, tile := range tiles {
+		switch t := tile.(type) {
+		case float64:
+			tiles[i] = int(t)
+		case string:
+		default:
+			return nil, fmt.Errorf("tenhou.net/6 牌谱格式错误：%v", tile)
+		}
+	}
+	return tiles, nil
+}
+
+// 11-19 21-29 31-39 41-47 51-53 转换成 0-33，无法解析时返回 -1
+func Tenhou6TileToTile34(tile int) (tile34 int, isRedFive bool) {
+	switch {
+	case tile >= 11 && tile <= 39 && tile%10 != 0:
+		return 9*(tile/10-1) + tile%10 - 1, false
+	case tile >= 41 && tile <= 47:
+		return 27 + tile - 41, false
+	case tile >= 51 && tile <= 53:
+		return 9*(tile-51) + 4, true
+	default:
+		return -1, false
+	}
+}
+
+func Tile34ToTenhou6Tile(tile34 int, isRedFive bool) int {
+	if isRedFive {
+		return 51 + tile34/9
+	}
+	return 10*(tile34/9+1) + tile34%9 + 1
+}
+
+//
+
+// 天凤 mjlog 中的赤5编号
+func isRedFive136(tile136 int) bool {
+	return tile136 == 16 || tile136 == 52 || tile136 == 88
+}
+
+// 天凤 mjlog 中的副露编号，解析见 tenhouRoundData._parseTenhouMeld
+func encodeChi(tiles136 []int, calledIndex int, offset int) int {
+	base := tiles136[0] / 4
+	base7 := (base/9)*7 + base%9
+	bits := (base7*3 + calledIndex) << 10
+	for i, tile136 := range tiles136 {
+		bits |= (tile136 - 4*(base+i)) << uint(3+2*i)
+	}
+	return bits | 0x4 | offset
+}
+
+// t4 为碰子之外的那张牌（加杠时为加上的牌）的编号
+func encodePonOrKakan(base int, calledIndex int, t4 int, isKakan bool, offset int) int {
+	bits := (base*3+calledIndex)<<9 | t4<<5 | offset
+	if isKakan {
+		return bits | 0x10
+	}
+	return bits | 0x8
+}
+
+func encodeKan(calledTile136 int, offset int) int {
+	return calledTile136<<8 | offset
+}
+
+func encodeNuki(tile136 int) int {
+	return tile136<<8 | 0x20
+}
+
+//
+
+type tenhou6Pon struct {
+	base        int
+	calledIndex int
+	offset      int
+}
+
+// 将 tenhou.net/6 牌谱转换成 mjlog 中的操作序列
+type tenhou6Converter struct {
+	playerNumber int
+	actions      []*RecordAction
+
+	// 以下为当前局的数据，牌用 mjlog 中的 0-135 编号
+	used        []bool
+	hands       [][]int
+	latestDraws []int
+	lastDiscard int
+	pons        []map[int]*tenhou6Pon // tile34 -> 碰
+	doraIndex   int                   // 下一个杠宝牌指示牌的下标
+}
+
+func (c *tenhou6Converter) newAction(tag string) *RecordAction {
+	action := &RecordAction{XMLName: xml.Name{Local: tag}}
+	action.Tag = tag
+	c.actions = append(c.actions, action)
+	return action
+}
+
+// 为新出现的牌分配编号，普通的 5 优先使用非赤5的编号
+func (c *tenhou6Converter) allocate(tile int) (int, error) {
+	tile34, isRedFive := Tenhou6TileToTile34(tile)
+	if tile34 == -1 {
+		return -1, fmt.Errorf("tenhou.net/6 牌谱格式错误：无法解析牌 %d", tile)
+	}
+	order := []int{0, 1, 2, 3}
+	if isRedFive {
+		order = []int{0}
+	} else if tile34 < 27 && tile34%9 == 4 {
+		order = []int{1, 2, 3, 0}
+	}
+	for _, i := range order {
+		if tile136 := 4*tile34 + i; !c.used[tile136] {
+			c.used[tile136] = true
+			return tile136, nil
+		}
+	}
+	return -1, fmt.Errorf("tenhou.net/6 牌谱格式错误：牌 %d 超过 4 枚", tile)
+}
+
+func (c *tenhou6Converter) removeFromHand(who int, tile int) (int, error) {
+	tile34, isRedFive := Tenhou6TileToTile34(tile)
+	idx := -1
+	for i, tile136 := range c.hands[who] {
+		if tile136/4 != tile34 {
+			continue
+		}
+		if isRedFive136(tile136) == isRedFive {
+			idx = i
+			break
+		}
+		if idx == -1 {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		return -1, fmt.Errorf("tenhou.net/6 牌谱格式错误：%d 号座位手牌中没有 %d", who, tile)
+	}
+	tile136 := c.hands[who][idx]
+	c.hands[who] = append(c.hands[who][:idx], c.hands[who][idx+1:]...)
+	return tile136, nil
+}
+
+func (c *tenhou6Converter) relativeSeat(who int, offset int) int {
+	return (who + offset) % c.playerNumber
+}
+
+// 解析鸣牌字符串，返回字母的位置、被鸣的牌和手牌中的牌
+func parseTenhou6Meld(meld string, letters string) (letterIndex int, calledTile int, selfTiles []int, err error) {
+	letterIndex = strings.IndexAny(meld, letters)
+	if letterIndex == -1 || letterIndex%2 != 0 || (len(meld)-1)%2 != 0 {
+		return -1, -1, nil, fmt.Errorf("tenhou.net/6 牌谱格式错误：无法解析 %s", meld)
+	}
+	digits := meld[:letterIndex] + meld[letterIndex+1:]
+	for i := 0; i < len(digits); i += 2 {
+		tile, err := strconv.Atoi(digits[i : i+2])
+		if err != nil {
+			return -1, -1, nil, fmt.Errorf("tenhou.net/6 牌谱格式错误：无法解析 %s", meld)
+		}
+		if i == letterIndex {
+			calledTile = tile
+		} else {
+			selfTiles = append(selfTiles, tile)
+		}
+	}
+	return
+}
+
+// 吃碰杠的来源，返回相对于鸣牌者的偏移（1=下家 2=对家 3=上家）
+func (c *tenhou6Converter) meldOffset(meld string, letterIndex int) int {
+	switch {
+	case meld[0] == 'c' || letterIndex == 0:
+		return c.playerNumber - 1
+	case letterIndex == len(meld)-3:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// 他家舍牌后，轮到谁行动
+func (c *tenhou6Converter) nextWho(r *Tenhou6Round, who int, takeIndexes []int) int {
+	discardTile34 := c.lastDiscard / 4
+	callerOf := func(q int, letters string) bool {
+		if takeIndexes[q] >= len(r.Takes[q]) {
+			return false
+		}
+		meld, ok := r.Takes[q][takeIndexes[q]].(string)
+		if !ok {
+			return false
+		}
+		letterIndex, calledTile, _, err := parseTenhou6Meld(meld, letters)
+		if err != nil {
+			return false
+		}
+		calledTile34, _ := Tenhou6TileToTile34(calledTile)
+		return calledTile34 == discardTile34 && c.relativeSeat(q, c.meldOffset(meld, letterIndex)) == who
+	}
+
+	// 碰杠优先于吃
+	for offset := 1; offset < c.playerNumber; offset++ {
+		if q := c.relativeSeat(who, offset); callerOf(q, "pm") {
+			return q
+		}
+	}
+	return c.relativeSeat(who, 1)
+}
+
+// 舍牌后没有人再摸牌或鸣牌，且有人荣和了 who，说明这张舍牌被荣和了
+func (c *tenhou6Converter) isRonned(r *Tenhou6Round, who int, takeIndexes []int) bool {
+	for q, takes := range r.Takes {
+		if takeIndexes[q] < len(takes) {
+			return false
+		}
+	}
+	if len(r.Result) == 0 {
+		return false
+	}
+	if name, _ := r.Result[0].(string); name != "和了" {
+		return false
+	}
+	for i := 2; i < len(r.Result); i += 2 {
+		info, ok := r.Result[i].([]interface{})
+		if !ok || len(info) < 2 {
+			continue
+		}
+		winner, _ := toInt(info[0])
+		fromWho, _ := toInt(info[1])
+		if winner != who && fromWho == who {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *tenhou6Converter) newDora(r *Tenhou6Round) error {
+	if c.doraIndex >= len(r.DoraIndicators) {
+		return nil
+	}
+	tile136, err := c.allocate(r.DoraIndicators[c.doraIndex])
+	if err != nil {
+		return err
+	}
+	c.doraIndex++
+	c.newAction("DORA").Hai = strconv.Itoa(tile136)
+	return nil
+}
+
+func (c *tenhou6Converter) newMeldAction(who int, bits int) {
+	action := c.newAction("N")
+	action.Who = strconv.Itoa(who)
+	action.Meld = strconv.Itoa(bits)
+}
+
+// 吃碰大明杠
+func (c *tenhou6Converter) call(who int, meld string) (isMinkan bool, err error) {
+	letterIndex, calledTile, selfTiles, err := parseTenhou6Meld(meld, "cpm")
+	if err != nil {
+		return false, err
+	}
+	if calledTile34, _ := Tenhou6TileToTile34(calledTile); c.lastDiscard == -1 || calledTile34 != c.lastDiscard/4 {
+		return false, fmt.Errorf("tenhou.net/6 牌谱格式错误：%s 与舍牌不一致", meld)
+	}
+	selfTiles136 := []int{}
+	for _, tile := range selfTiles {
+		tile136, err := c.removeFromHand(who, tile)
+		if err != nil {
+			return false, err
+		}
+		selfTiles136 = append(selfTiles136, tile136)
+	}
+	offset := c.meldOffset(meld, letterIndex)
+	calledTile136 := c.lastDiscard
+	c.lastDiscard = -1
+	c.latestDraws[who] = -1
+
+	var bits int
+	switch meld[letterIndex] {
+	case 'c':
+		tiles136 := append([]int{calledTile136}, selfTiles136...)
+		for i := range tiles136 {
+			for j := i + 1; j < len(tiles136); j++ {
+				if tiles136[j] < tiles136[i] {
+					tiles136[i], tiles136[j] = tiles136[j], tiles136[i]
+				}
+			}
+		}
+		calledIndex := 0
+		for i, tile136 := range tiles136 {
+			if tile136 == calledTile136 {
+				calledIndex = i
+			}
+		}
+		bits = encodeChi(tiles136, calledIndex, offset)
+	case 'p':
+		base := calledTile136 / 4
+		used := [4]bool{}
+		used[calledTile136%4] = true
+		for _, tile136 := range selfTiles136 {
+			used[tile136%4] = true
+		}
+		t4, calledIndex := 0, 0
+		for i, idx := 0, 0; i < 4; i++ {
+			if !used[i] {
+				t4 = i
+				continue
+			}
+			if i == calledTile136%4 {
+				calledIndex = idx
+			}
+			idx++
+		}
+		c.pons[who][base] = &tenhou6Pon{base: base, calledIndex: calledIndex, offset: offset}
+		bits = encodePonOrKakan(base, calledIndex, t4, false, offset)
+	case 'm':
+		isMinkan = true
+		bits = encodeKan(calledTile136, offset)
+	}
+	c.newMeldAction(who, bits)
+	return
+}
+
+// 暗杠加杠拔北
+func (c *tenhou6Converter) selfKan(r *Tenhou6Round, who int, meld string) error {
+	letterIndex, calledTile, selfTiles, err := parseTenhou6Meld(meld, "akf")
+	if err != nil {
+		return err
+	}
+	c.latestDraws[who] = -1
+
+	switch meld[letterIndex] {
+	case 'a':
+		for _, tile := range append(selfTiles, calledTile) {
+			if _, err := c.removeFromHand(who, tile); err != nil {
+				return err
+			}
+		}
+		calledTile34, _ := Tenhou6TileToTile34(calledTile)
+		c.newMeldAction(who, encodeKan(4*calledTile34, 0))
+	case 'k':
+		tile136, err := c.removeFromHand(who, calledTile)
+		if err != nil {
+			return err
+		}
+		pon, ok := c.pons[who][tile136/4]
+		if !ok {
+			return fmt.Errorf("tenhou.net/6 牌谱格式错误：%s 没有对应的碰", meld)
+		}
+		// 抢杠时荣和的是这张牌
+		c.lastDiscard = tile136
+		c.newMeldAction(who, encodePonOrKakan(pon.base, pon.calledIndex, tile136%4, true, pon.offset))
+	case 'f':
+		tile136, err := c.removeFromHand(who, calledTile)
+		if err != nil {
+			return err
+		}
+		c.newMeldAction(who, encodeNuki(tile136))
+		return nil
+	}
+	return c.newDora(r)
+}
+
+func (c *tenhou6Converter) discard(who int, tile int) error {
+	var tile136 int
+	if tile == Tenhou6Tsumogiri {
+		if c.latestDraws[who] == -1 {
+			return fmt.Errorf("tenhou.net/6 牌谱格式错误：%d 号座位没有摸牌却摸切", who)
+		}
+		tile136 = c.latestDraws[who]
+		for i, t := range c.hands[who] {
+			if t == tile136 {
+				c.hands[who] = append(c.hands[who][:i], c.hands[who][i+1:]...)
+				break
+			}
+		}
+	} else {
+		var err error
+		if tile136, err = c.removeFromHand(who, tile); err != nil {
+			return err
+		}
+	}
+	c.latestDraws[who] = -1
+	c.lastDiscard = tile136
+	c.newAction(string('D'+byte(who)) + strconv.Itoa(tile136))
+	return nil
+}
+
+// 解析 JSON 得到的数字为 float64
+func toInt(v interface{}) (int, bool) {
+	switch v := v.(type) {
+	case int:
+		return v, true
+	case float64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
+
+var tenhou6PointReg = regexp.MustCompile(`(\d+)(?:-(\d+))?点(∀)?`)
+var tenhou6FuReg = regexp.MustCompile(`(\d+)符`)
+
+// 30符1飜1000点 满贯8000点 30符2飜500-1000点 40符2飜1300点∀
+func parseTenhou6Point(s string) (fu int, point int) {
+	if matches := tenhou6FuReg.FindStringSubmatch(s); matches != nil {
+		fu, _ = strconv.Atoi(matches[1])
+	}
+	matches := tenhou6PointReg.FindStringSubmatch(s)
+	if matches == nil {
+		return
+	}
+	point, _ = strconv.Atoi(matches[1])
+	switch {
+	case matches[3] != "":
+		point *= 3
+	case matches[2] != "":
+		parentPoint, _ := strconv.Atoi(matches[2])
+		point = 2*point + parentPoint
+	}
+	return
+}
+
+func (c *tenhou6Converter) result(r *Tenhou6Round) error {
+	if len(r.Result) == 0 {
+		return nil
+	}
+	if name, _ := r.Result[0].(string); name != "和了" {
+		c.newAction("RYUUKYOKU")
+		return nil
+	}
+
+	for i := 1; i+1 < len(r.Result); i += 2 {
+		info, ok := r.Result[i+1].([]interface{})
+		if !ok || len(info) < 2 {
+			return fmt.Errorf("tenhou.net/6 牌谱格式错误：%v", r.Result)
+		}
+		who, _ := toInt(info[0])
+		fu, point := 0, 0
+		if len(info) > 3 {
+			pointInfo, _ := info[3].(string)
+			fu, point = parseTenhou6Point(pointInfo)
+		}
+		if point == 0 {
+			// 无法解析时使用点数变化
+			if deltas, ok := r.Result[i].([]interface{}); ok && who < len(deltas) {
+				point, _ = toInt(deltas[who])
+			}
+		}
+		action := c.newAction("AGARI")
+		action.Who = strconv.Itoa(who)
+		action.Ten = fmt.Sprintf("%d,%d,0", fu, point)
+	}
+	return nil
+}
+
+func (c *tenhou6Converter) convertRound(r *Tenhou6Round) error {
+	n := c.playerNumber
+	if len(r.Hais) != n || len(r.DoraIndicators) == 0 || len(r.Scores) < n {
+		return fmt.Errorf("tenhou.net/6 牌谱格式错误：数据不完整")
+	}
+
+	c.used = make([]bool, 136)
+	c.hands = make([][]int, n)
+	c.latestDraws = make([]int, n)
+	c.lastDiscard = -1
+	c.pons = make([]map[int]*tenhou6Pon, n)
+	c.doraIndex = 1
+
+	doraIndicator, err := c.allocate(r.DoraIndicators[0])
+	if err != nil {
+		return err
+	}
+	hais := make([]string, 4)
+	for seat, hai := range r.Hais {
+		tiles := []string{}
+		for _, tile := range hai {
+			tile136, err := c.allocate(tile)
+			if err != nil {
+				return err
+			}
+			c.hands[seat] = append(c.hands[seat], tile136)
+			tiles = append(tiles, strconv.Itoa(tile136))
+		}
+		hais[seat] = strings.Join(tiles, ",")
+		c.latestDraws[seat] = -1
+		c.pons[seat] = map[int]*tenhou6Pon{}
+	}
+	ten := []string{"0", "0", "0", "0"}
+	for seat := 0; seat < n; seat++ {
+		ten[seat] = strconv.Itoa(r.Scores[seat] / 100)
+	}
+	dealer := r.RoundNumber % 4
+	init := c.newAction("INIT")
+	init.Seed = fmt.Sprintf("%d,%d,%d,0,0,%d", r.RoundNumber, r.BenNumber, r.ReachStickNumber, doraIndicator)
+	init.Ten = strings.Join(ten, ",")
+	init.Dealer = strconv.Itoa(dealer)
+	init.Hai0, init.Hai1, init.Hai2, init.Hai3 = hais[0], hais[1], hais[2], hais[3]
+
+	takeIndexes := make([]int, n)
+	discardIndexes := make([]int, n)
+	for who := dealer; takeIndexes[who] < len(r.Takes[who]); {
+		switch take := r.Takes[who][takeIndexes[who]].(type) {
+		case int:
+			tile136, err := c.allocate(take)
+			if err != nil {
+				return err
+			}
+			c.hands[who] = append(c.hands[who], tile136)
+			c.latestDraws[who] = tile136
+			c.newAction(string('T'+byte(who)) + strconv.Itoa(tile136))
+		case string:
+			isMinkan, err := c.call(who, take)
+			if err != nil {
+				return err
+			}
+			if isMinkan {
+				// 大明杠后舍牌处为占位的 0，接着摸岭上牌
+				takeIndexes[who]++
+				discardIndexes[who]++
+				if err := c.newDora(r); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		takeIndexes[who]++
+
+		if discardIndexes[who] >= len(r.Discards[who]) {
+			// 自摸或九种九牌
+			break
+		}
+		discard := r.Discards[who][discardIndexes[who]]
+		discardIndexes[who]++
+		switch d := discard.(type) {
+		case int:
+			if d == tenhou6KanPlaceholder {
+				return fmt.Errorf("tenhou.net/6 牌谱格式错误：%d 号座位的舍牌 0 没有对应的大明杠", who)
+			}
+			if err := c.discard(who, d); err != nil {
+				return err
+			}
+		case string:
+			if strings.HasPrefix(d, "r") {
+				tile, err := strconv.Atoi(d[1:])
+				if err != nil {
+					return fmt.Errorf("tenhou.net/6 牌谱格式错误：无法解析 %s", d)
+				}
+				reach := c.newAction("REACH")
+				reach.Who, reach.Step = strconv.Itoa(who), "1"
+				if err := c.discard(who, tile); err != nil {
+					return err
+				}
+				// 立直宣言牌被荣和时，立直不成立
+				if !c.isRonned(r, who, takeIndexes) {
+					reach = c.newAction("REACH")
+					reach.Who, reach.Step = strconv.Itoa(who), "2"
+				}
+				break
+			}
+			if err := c.selfKan(r, who, d); err != nil {
+				return err
+			}
+			// 接着摸岭上牌（或拔北后的补牌）
+			continue
+		}
+
+		who = c.nextWho(r, who, takeIndexes)
+	}
+
+	return c.result(r)
+}
+
+// 转换成与 mjlog 相同的操作序列，这样就可以用 tenhouRoundData 回放
+func (l *Tenhou6Log) Record() (*Record, error) {
+	record := &Record{XMLName: xml.Name{Local: "mjloggm"}}
+	for i, r := range l.Log {
+		c := &tenhou6Converter{playerNumber: len(r.Hais)}
+		if c.playerNumber != 3 && c.playerNumber != 4 {
+			return nil, fmt.Errorf("tenhou.net/6 牌谱格式错误：第 %d 局有 %d 家", i+1, c.playerNumber)
+		}
+		if err := c.convertRound(r); err != nil {
+			return nil, fmt.Errorf("第 %d 局: %v", i+1, err)
+		}
+		record.Actions = append(record.Actions, c.actions...)
+	}
+	return record, nil
+}
+
+func ParseTenhou6JSON(data []byte) (*Record, error) {
+	l := &Tenhou6Log{}
+	if err := json.Unmarshal(data, l); err != nil {
+		return nil, err
+	}
+	return l.Record()
+}
@@ -0,0 +1,117 @@
+package tenhou
+
+import (
+	"encoding/json"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// 二家碰三家，四家摸切立直，二家加杠，四家自摸
+const testTenhou6JSON = `{"title": ["", ""], "name": ["A", "B", "C", "D"], "rule": {"disp": "般南喰赤", "aka": 1}, "log": [[
+  [0, 0, 0], [25000, 25000, 25000, 25000], [44, 43], [],
+  [11, 12, 13, 14, 15, 16, 17, 18, 19, 21, 22, 23, 41], [41, 47], [60, 60],
+  [31, 32, 33, 34, 35, 36, 37, 38, 39, 24, 25, 26, 27], [45, 46], [60, 60],
+  [11, 12, 13, 14, 51, 16, 17, 18, 19, 21, 22, 45, 45], ["p454545", 45, 28], [22, "k45454545", 60],
+  [31, 32, 33, 34, 53, 36, 37, 38, 39, 24, 25, 26, 43], [46, 28], ["r60"],
+  ["和了", [-1300, -1300, -2600, 6200], [3, 3, 3, "30符2飜700-1300点", "立直(1飜)", "門前清自摸和(1飜)"]]
+]]}`
+
+func TestTenhou6TileToTile34(t *testing.T) {
+	for tile, tile34 := range map[int]int{11: 0, 19: 8, 25: 13, 39: 26, 41: 27, 47: 33, 10: -1, 48: -1} {
+		_tile34, isRedFive := Tenhou6TileToTile34(tile)
+		assert.Equal(t, tile34, _tile34, tile)
+		assert.False(t, isRedFive)
+		if tile34 != -1 {
+			assert.Equal(t, tile, Tile34ToTenhou6Tile(tile34, false))
+		}
+	}
+
+	tile34, isRedFive := Tenhou6TileToTile34(52)
+	assert.Equal(t, 13, tile34)
+	assert.True(t, isRedFive)
+	assert.Equal(t, 52, Tile34ToTenhou6Tile(13, true))
+}
+
+func TestParseTenhou6JSON(t *testing.T) {
+	record, err := ParseTenhou6JSON([]byte(testTenhou6JSON))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	tags := []string{}
+	for _, action := range record.Actions {
+		tag := action.Tag
+		if len(tag) > 1 && tag[1] >= '0' && tag[1] <= '9' {
+			// 摸牌舍牌只保留座位
+			tag = tag[:1]
+		}
+		tags = append(tags, tag)
+	}
+	assert.Equal(t, []string{
+		"INIT",
+		"T", "D", "U", "E", "N", "F", "W", "REACH", "G", "REACH",
+		"T", "D", "U", "E", "V", "N", "DORA", "V", "F", "W",
+		"AGARI",
+	}, tags)
+
+	init := record.Actions[0]
+	assert.Equal(t, "0,0,0,0,0,120", init.Seed)
+	assert.Equal(t, "250,250,250,250", init.Ten)
+	assert.Equal(t, "0", init.Dealer)
+	// 赤5的编号为 16 52 88
+	assert.Contains(t, init.Hai2, "16")
+	assert.Contains(t, init.Hai3, "88")
+
+	// 碰上家的白
+	pon := record.Actions[5]
+	assert.Equal(t, "2", pon.Who)
+	assert.Equal(t, "T109", record.Actions[1].Tag, "第二张东")
+	assert.Equal(t, "D109", record.Actions[2].Tag, "摸切")
+
+	// 摸切立直
+	assert.Equal(t, record.Actions[7].Tag[1:], record.Actions[9].Tag[1:])
+
+	agari := record.Actions[len(record.Actions)-1]
+	assert.Equal(t, "3", agari.Who)
+	assert.Equal(t, "30,2700,0", agari.Ten)
+}
+
+// 四家第二巡立直，宣言牌被起家荣和
+const testTenhou6RonReachJSON = `{"title": ["", ""], "name": ["A", "B", "C", "D"], "rule": {"disp": "般南喰赤", "aka": 1}, "log": [[
+  [0, 0, 0], [25000, 25000, 25000, 25000], [44, 43], [],
+  [11, 12, 13, 14, 15, 16, 17, 18, 19, 21, 22, 23, 41], [41, 47], [60, 60],
+  [31, 32, 33, 34, 35, 36, 37, 38, 39, 24, 25, 26, 27], [45, 46], [60, 60],
+  [11, 12, 13, 14, 51, 16, 17, 18, 19, 21, 22, 45, 45], ["p454545", 45, 28], [22, "k45454545", 60],
+  [31, 32, 33, 34, 53, 36, 37, 38, 39, 24, 25, 26, 43], [46, 28], [60, "r60"],
+  ["和了", [1000, 0, 0, -1000], [0, 3, 0, "30符1飜1000点", "役牌 白(1飜)"]]
+]]}`
+
+func TestParseTenhou6JSON_ronReach(t *testing.T) {
+	record, err := ParseTenhou6JSON([]byte(testTenhou6RonReachJSON))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	reachSteps := []string{}
+	for _, action := range record.Actions {
+		if action.Tag == "REACH" {
+			reachSteps = append(reachSteps, action.Step)
+		}
+	}
+	// 没有立直成功的 step 2
+	assert.Equal(t, []string{"1"}, reachSteps)
+	assert.Equal(t, "AGARI", record.Actions[len(record.Actions)-1].Tag)
+	assert.Equal(t, "G", record.Actions[len(record.Actions)-2].Tag[:1])
+}
+
+func TestTenhou6RoundMarshalJSON(t *testing.T) {
+	l := &Tenhou6Log{}
+	assert.NoError(t, json.Unmarshal([]byte(testTenhou6JSON), l))
+	data, err := json.Marshal(l)
+	assert.NoError(t, err)
+
+	l2 := &Tenhou6Log{}
+	assert.NoError(t, json.Unmarshal(data, l2))
+	assert.Equal(t, l, l2)
+	assert.Equal(t, []interface{}{"p454545", 45, 28}, l2.Log[0].Takes[2])
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+)
+
+// 段位战 pt 表，由 -rank-pt 指定，为 nil 时不按照期望 pt 排序
+var rankPointTable *util.RankPointTable
+
+// 是否按照期望 pt 排序：指定了 pt 表且处于终盘
+func useRankPoint(standing *model.Standing) bool {
+	return rankPointTable != nil && standing != nil && standing.IsPresent(0) && standing.IsFinalRounds()
+}
+
+// 指定 -turns 时两三向听先按多巡的牌效率排序，有威胁时按照期望收支排序，终盘时再按照期望 pt 排序
+func sortResults14(results14 util.Hand14AnalysisResultList, opponentRisks util.OpponentRiskList, standing *model.Standing) {
+	if sortByTurns && len(results14) > 0 {
+		if shanten := results14[0].Result13.Shanten; shanten >= 2 && shanten <= 3 {
+			results14.SortByTurns(0)
+		}
+	}
+	results14.SortByEV(opponentRisks)
+	if useRankPoint(standing) {
+		results14.SortByRankPoint(opponentRisks, standing, *rankPointTable)
+	}
+}
+
+// 综合向听不变和向听倒退的切牌：有威胁时按照期望收支排序，终盘时再按照期望 pt 排序
+// results14 和 incShantenResults14 需要先由 sortResults14 排序
+func mergeResults14(results14 util.Hand14AnalysisResultList, incShantenResults14 util.Hand14AnalysisResultList, opponentRisks util.OpponentRiskList, standing *model.Standing) util.Hand14AnalysisResultList {
+	merged := results14.MergeByEV(incShantenResults14, opponentRisks)
+	if useRankPoint(standing) {
+		merged.SortByRankPoint(opponentRisks, standing, *rankPointTable)
+	}
+	return merged
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func Test_sortResults14(t *testing.T) {
+	defer func(old *util.RankPointTable) { rankPointTable = old }(rankPointTable)
+
+	// 南4局，自家二位落后一位 5000 点，四位立直
+	// 切 1m 立直，和牌即可逆转；切 3m 为现物，但很难和牌
+	standing := &model.Standing{
+		Scores:       []int{28000, 33000, 22000, 16000},
+		AbsentPlayer: -1,
+		RiichiSticks: 1,
+		RoundNumber:  7,
+		Dealer:       2,
+	}
+	risk34 := make(util.RiskTiles34, 34)
+	risk34[0] = 10
+	opponents := util.OpponentRiskList{{Who: 3, TenpaiRate: 100, RiskTiles34: risk34, RonPoint: 5200}}
+	newList := func() util.Hand14AnalysisResultList {
+		return util.Hand14AnalysisResultList{
+			{DiscardTile: 0, Result13: &util.Hand13AnalysisResult{Shanten: 0, AvgAgariRate: 40, RiichiPoint: 7700, MixedRoundPoint: 800}},
+			{DiscardTile: 2, Result13: &util.Hand13AnalysisResult{Shanten: 1, MixedRoundPoint: 500}},
+		}
+	}
+
+	// 未指定 pt 表时按期望收支排序，切 3m
+	rankPointTable = nil
+	l := newList()
+	sortResults14(l, opponents, standing)
+	assert.Equal(t, 2, l[0].DiscardTile)
+	assert.Equal(t, 0.0, l[0].RankPointEV)
+
+	// 按期望 pt 排序时，为了一位切 1m
+	table := util.TenhouRankPointTable(7)
+	rankPointTable = &table
+	l = newList()
+	sortResults14(l, opponents, standing)
+	assert.Equal(t, 0, l[0].DiscardTile)
+	assert.True(t, l[0].RankPointEV > l[1].RankPointEV)
+
+	// 东场不按 pt 排序
+	standing.RoundNumber = 1
+	l = newList()
+	sortResults14(l, opponents, standing)
+	assert.Equal(t, 2, l[0].DiscardTile)
+	assert.False(t, useRankPoint(nil))
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"strings"
+)
+
+// 牌谱复盘：记录某家在每次舍牌前的手牌，以及 AI 的进攻推荐和防守推荐
+// 他家舍牌时若可以鸣牌，记录 AI 的鸣牌判断
+
+type discardReview struct {
+	roundNumber int
+	benNumber   int
+	turn        int // 巡目，从 1 开始
+
+	playerInfo *model.PlayerInfo // 舍牌前的手牌
+
+	selfDiscardTile     int
+	isRiichiWhenDiscard bool
+
+	aiAttackDiscardTile  int
+	aiDefenceDiscardTile int // 无危险时为 -1
+
+	mixedRiskTable      riskTable
+	results14           util.Hand14AnalysisResultList
+	incShantenResults14 util.Hand14AnalysisResultList
+}
+
+// 在自家舍牌前调用，此时手牌为 3k+2 张
+func newDiscardReview(d *roundData, turn int) *discardReview {
+	playerInfo := d.newModelPlayerInfo()
+	playerInfo.HandTiles34 = append([]int{}, playerInfo.HandTiles34...)
+	mixedRiskTable := d.analysisTilesRisk().mixedRiskTable()
+	shanten, results14, incShantenResults14 := util.CalculateShantenWithImproves14(playerInfo)
+
+	return &discardReview{
+		roundNumber:          d.roundNumber,
+		benNumber:            d.benNumber,
+		turn:                 turn,
+		playerInfo:           playerInfo,
+		selfDiscardTile:      -1,
+		aiAttackDiscardTile:  _simpleBestDiscardTile(playerInfo, shanten, results14, incShantenResults14),
+		aiDefenceDiscardTile: mixedRiskTable.getBestDefenceTile(playerInfo.HandTiles34),
+		mixedRiskTable:       mixedRiskTable,
+		results14:            results14,
+		incShantenResults14:  incShantenResults14,
+	}
+}
+
+func (r *discardReview) setSelfDiscardTile(tile int, isRiichiWhenDiscard bool) {
+	r.selfDiscardTile = tile
+	r.isRiichiWhenDiscard = isRiichiWhenDiscard
+}
+
+// 切掉 tile 后的分析结果，找不到时返回 nil
+func (r *discardReview) result13(tile int) *util.Hand13AnalysisResult {
+	for _, results := range []util.Hand14AnalysisResultList{r.results14, r.incShantenResults14} {
+		for _, result := range results {
+			if result.DiscardTile == tile {
+				return result.Result13
+			}
+		}
+	}
+	return nil
+}
+
+// 切掉 tile 后的向听数和进张数
+func (r *discardReview) shantenAndWaitsCount(tile int) (shanten int, waitsCount int) {
+	result13 := r.result13(tile)
+	if result13 == nil {
+		return -1, 0
+	}
+	return result13.Shanten, result13.Waits.AllCount()
+}
+
+func (r *discardReview) risk(tile int) float64 {
+	if tile < 0 {
+		return 0
+	}
+	return r.mixedRiskTable[tile]
+}
+
+// 实际舍牌既不是进攻推荐也不是防守推荐
+func (r *discardReview) isDisagreement() bool {
+	if r.selfDiscardTile == -1 || r.aiAttackDiscardTile == -1 {
+		return false
+	}
+	return r.selfDiscardTile != r.aiAttackDiscardTile && r.selfDiscardTile != r.aiDefenceDiscardTile
+}
+
+func recordRoundName(roundNumber int, benNumber int) string {
+	return fmt.Sprintf("%s%d局%d本场", util.MahjongZH[27+roundNumber/4], roundNumber%4+1, benNumber)
+}
+
+func (r *discardReview) roundName() string {
+	return recordRoundName(r.roundNumber, r.benNumber)
+}
+
+func (r *discardReview) descTile(tile int) string {
+	if tile == -1 {
+		return "--"
+	}
+	result13 := r.result13(tile)
+	if result13 == nil {
+		return fmt.Sprintf("%s（铳率 %.1f%%）", util.MahjongZH[tile], r.risk(tile))
+	}
+	return fmt.Sprintf("%s（%s %d 进张，铳率 %.1f%%）", util.MahjongZH[tile], util.NumberToChineseShanten(result13.Shanten), result13.Waits.AllCount(), r.risk(tile))
+}
+
+func (r *discardReview) String() string {
+	selfInfo := r.descTile(r.selfDiscardTile)
+	if r.isRiichiWhenDiscard {
+		selfInfo += "[立直]"
+	}
+
+	attackShanten, attackWaitsCount := r.shantenAndWaitsCount(r.aiAttackDiscardTile)
+	selfShanten, selfWaitsCount := r.shantenAndWaitsCount(r.selfDiscardTile)
+	return fmt.Sprintf("%s 第%d巡 %s\n  实际 %s\n  进攻 %s\n  防守 %s\n  差值 向听 %+d 进张 %+d 铳率 %+.1f%%",
+		r.roundName(), r.turn, humanHands(r.playerInfo),
+		selfInfo,
+		r.descTile(r.aiAttackDiscardTile),
+		r.descTile(r.aiDefenceDiscardTile),
+		selfShanten-attackShanten, selfWaitsCount-attackWaitsCount, r.risk(r.selfDiscardTile)-r.risk(r.aiAttackDiscardTile),
+	)
+}
+
+type discardReviewList []*discardReview
+
+func (l discardReviewList) disagreements() (disagreements discardReviewList) {
+	for _, r := range l {
+		if r.isDisagreement() {
+			disagreements = append(disagreements, r)
+		}
+	}
+	return
+}
+
+//
+
+type meldReview struct {
+	roundNumber int
+	benNumber   int
+	turn        int // 自家已经舍牌的巡目
+
+	playerInfo *model.PlayerInfo // 鸣牌前的手牌
+	calledTile int               // 可以鸣的他家舍牌
+
+	advice   *util.MeldAdvice
+	isCalled bool // 实际是否鸣牌
+}
+
+// 在他家舍牌后调用，此时手牌为 3k+1 张
+// who 为舍牌者，无法鸣牌时返回 nil
+func newMeldReview(d *roundData, turn int, who int, calledTile int, isRedFive bool) *meldReview {
+	playerInfo := d.newModelPlayerInfo()
+	// 河底牌不能鸣牌
+	if playerInfo.LeftDrawTilesCount == 0 {
+		return nil
+	}
+	playerInfo.HandTiles34 = append([]int{}, playerInfo.HandTiles34...)
+	allowChi := d.allowChi(who, playerInfo)
+	_, results14, incShantenResults14 := util.CalculateMeld(playerInfo, calledTile, isRedFive, allowChi)
+	skipResult13 := util.CalculateShantenWithImproves13(playerInfo)
+	advice := util.CalcMeldAdvice(playerInfo, skipResult13, results14, incShantenResults14, d.analysisTilesRisk().opponentRisks())
+	if advice == nil {
+		return nil
+	}
+	return &meldReview{
+		roundNumber: d.roundNumber,
+		benNumber:   d.benNumber,
+		turn:        turn,
+		playerInfo:  playerInfo,
+		calledTile:  calledTile,
+		advice:      advice,
+	}
+}
+
+// AI 判断应该鸣牌但没有鸣
+func (r *meldReview) isMissedCall() bool {
+	return !r.isCalled && r.advice.Verdict == util.MeldVerdictCall
+}
+
+// 鸣牌了但 AI 判断应该跳过
+func (r *meldReview) isBadCall() bool {
+	return r.isCalled && r.advice.Verdict == util.MeldVerdictSkip
+}
+
+func (r *meldReview) String() string {
+	action := "跳过"
+	if r.isCalled {
+		action = "鸣牌"
+	}
+	return fmt.Sprintf("%s 第%d巡后 %s %s %s?\n  实际 %s\n  AI %s：%s",
+		recordRoundName(r.roundNumber, r.benNumber), r.turn, humanHands(r.playerInfo), model.SepTargetTile, util.MahjongZH[r.calledTile],
+		action,
+		r.advice, strings.Join(r.advice.Reasons, "；"),
+	)
+}
+
+type meldReviewList []*meldReview
+
+// 应鸣未鸣和不应鸣牌的情况
+func (l meldReviewList) disagreements() (disagreements meldReviewList) {
+	for _, r := range l {
+		if r.isMissedCall() || r.isBadCall() {
+			disagreements = append(disagreements, r)
+		}
+	}
+	return
+}
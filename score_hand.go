@@ -0,0 +1,145 @@
+package main
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/fatih/color"
+	"strings"
+)
+
+// 计分时的和牌状况
+type scoreHandOptions struct {
+	isTsumo           bool
+	isRiichi          bool
+	isIppatsu         bool
+	humanUraDoraTiles string
+}
+
+// 解析 "手牌 # 副露 + 和了牌"，计算所有有役的手牌拆解下的计分明细
+func scoreHumanHand(humanTilesInfo *model.HumanTilesInfo, opt scoreHandOptions) (playerInfo *model.PlayerInfo, scores []*util.HandScore, err error) {
+	defer func() {
+		if er := recover(); er != nil {
+			err = fmt.Errorf("输入错误: %v", er)
+		}
+	}()
+
+	playerInfo, winTile, isRedFive, err := parseHumanTilesInfo(humanTilesInfo)
+	if err != nil {
+		return
+	}
+	if winTile == -1 {
+		return nil, nil, fmt.Errorf("输入错误：请用 + 指定和了牌，如 %s + 3m", humanTilesInfo.HumanTiles)
+	}
+	if opt.isIppatsu && !opt.isRiichi {
+		return nil, nil, fmt.Errorf("输入错误：一发需要立直")
+	}
+
+	playerInfo.HandTiles34[winTile]++
+	if isRedFive {
+		playerInfo.NumRedFives[winTile/9]++
+	}
+	playerInfo.WinTile = winTile
+	playerInfo.IsTsumo = opt.isTsumo
+	playerInfo.IsRiichi = opt.isRiichi
+	playerInfo.IsIppatsu = opt.isIppatsu
+	// 未指定自风时默认为东家
+	playerInfo.IsParent = playerInfo.SelfWindTile == 27
+	if opt.humanUraDoraTiles != "" {
+		if playerInfo.UraDoraTiles, _, err = util.StrToTiles(opt.humanUraDoraTiles); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !util.IsAgari(playerInfo.HandTiles34) {
+		return nil, nil, fmt.Errorf("%s 未和牌", util.Tiles34ToStr(playerInfo.HandTiles34))
+	}
+	scores = util.ScoreHand(playerInfo)
+	if len(scores) == 0 {
+		return nil, nil, fmt.Errorf("%s 无役", util.Tiles34ToStr(playerInfo.HandTiles34))
+	}
+	return
+}
+
+// 役种及其番数，如 "立直(1番) 平和(1番)"
+func yakuTypesWithHanToStr(yakuTypes []int, isNaki bool, isYakuman bool) string {
+	names := []string{}
+	for _, t := range yakuTypes {
+		name := util.YakuNameMap[t]
+		if name == "" {
+			name = util.OldYakuNameMap[t]
+		}
+		if isYakuman {
+			names = append(names, name)
+		} else {
+			names = append(names, fmt.Sprintf("%s(%d番)", name, util.CalcYakuHan([]int{t}, isNaki)))
+		}
+	}
+	return strings.Join(names, " ")
+}
+
+func printHandScores(playerInfo *model.PlayerInfo, scores []*util.HandScore) {
+	isNaki := playerInfo.IsNaki()
+	for i, s := range scores {
+		if len(scores) > 1 {
+			if i == 0 {
+				fmt.Printf("拆解 %d（高点法）：%s\n", i+1, s.DivideResult)
+			} else {
+				fmt.Printf("拆解 %d：%s\n", i+1, s.DivideResult)
+			}
+		} else {
+			fmt.Println(s.DivideResult)
+		}
+
+		isYakuman := s.YakumanTimes > 0
+		fmt.Println("役种：" + yakuTypesWithHanToStr(s.YakuTypes, isNaki, isYakuman))
+		if isYakuman {
+			color.HiRed("%s %d点", s.LimitName(), s.Point)
+		} else {
+			fmt.Printf("宝牌 %d  赤宝牌 %d  里宝牌 %d\n", s.NumDora, s.NumRedFive, s.NumUraDora)
+
+			fuItems := []string{}
+			sumFu := 0
+			for _, item := range s.FuItems {
+				fuItems = append(fuItems, fmt.Sprintf("%s %d", item.Name, item.Fu))
+				sumFu += item.Fu
+			}
+			fuStr := strings.Join(fuItems, " + ")
+			if sumFu != s.Fu {
+				fuStr += fmt.Sprintf(" = %d → %d符", sumFu, s.Fu)
+			} else {
+				fuStr += fmt.Sprintf(" = %d符", s.Fu)
+			}
+			fmt.Println("符数：" + fuStr)
+
+			info := fmt.Sprintf("%d番%d符 %d点", s.Han, s.Fu, s.Point)
+			if name := s.LimitName(); name != "" {
+				info = name + " " + info
+			}
+			color.HiGreen(info)
+		}
+
+		switch {
+		case !playerInfo.IsTsumo:
+			fmt.Printf("放铳者支付 %d\n", s.RonPoint)
+		case playerInfo.IsParent:
+			fmt.Printf("各家支付 %d\n", s.TsumoChildPoint)
+		default:
+			fmt.Printf("子家支付 %d  亲家支付 %d\n", s.TsumoChildPoint, s.TsumoParentPoint)
+		}
+
+		if i+1 < len(scores) {
+			fmt.Println()
+		}
+	}
+}
+
+// 命令行计分：-score-hand "手牌 # 副露 + 和了牌"
+func runScoreHand(humanTilesInfo *model.HumanTilesInfo, opt scoreHandOptions) error {
+	playerInfo, scores, err := scoreHumanHand(humanTilesInfo, opt)
+	if err != nil {
+		return err
+	}
+	printHandScores(playerInfo, scores)
+	return nil
+}
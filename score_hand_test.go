@@ -0,0 +1,44 @@
+package main
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScoreHumanHand(t *testing.T) {
+	assert := assert.New(t)
+
+	humanTilesInfo := &model.HumanTilesInfo{
+		HumanTiles:     "234m 567p 33s 34s # 7777S + 0s",
+		HumanDoraTiles: "4s",
+		HumanSelfWind:  "3z",
+	}
+	playerInfo, scores, err := scoreHumanHand(humanTilesInfo, scoreHandOptions{isTsumo: true, isRiichi: true, humanUraDoraTiles: "2m"})
+	if !assert.NoError(err) || !assert.Len(scores, 1) {
+		return
+	}
+	assert.False(playerInfo.IsParent)
+	s := scores[0]
+	// 立直 自摸 断幺，宝牌 1 赤 1 里 1
+	assert.Equal(1, s.NumDora)
+	assert.Equal(1, s.NumRedFive)
+	assert.Equal(1, s.NumUraDora)
+	assert.Equal(6, s.Han)
+	// 符底 20 + 暗杠 16 + 自摸 2 = 38 → 40
+	assert.Equal(40, s.Fu)
+	assert.Equal(3000, s.TsumoChildPoint)
+	assert.Equal(6000, s.TsumoParentPoint)
+
+	_, _, err = scoreHumanHand(&model.HumanTilesInfo{HumanTiles: "234m 567p 33s 456s 789s"}, scoreHandOptions{})
+	assert.Error(err, "未指定和了牌")
+
+	_, _, err = scoreHumanHand(&model.HumanTilesInfo{HumanTiles: "234m 567p 33s 456s 78s + 1s"}, scoreHandOptions{})
+	assert.Error(err, "未和牌")
+
+	_, _, err = scoreHumanHand(&model.HumanTilesInfo{HumanTiles: "234m 567p 33s 456s 79s + 8s"}, scoreHandOptions{})
+	assert.Error(err, "无役")
+
+	_, _, err = scoreHumanHand(&model.HumanTilesInfo{HumanTiles: "234m 567p 33s 456s 78s + 9s"}, scoreHandOptions{isIppatsu: true})
+	assert.Error(err, "一发需要立直")
+}
@@ -86,13 +86,38 @@ func (h *mjHandler) analysis(c echo.Context) error {
 	d := struct {
 		Reset bool   `json:"reset"`
 		Tiles string `json:"tiles"`
+		JSON  bool   `json:"json"` // 以 JSON 格式返回分析结果
+
+		// 可选项
+		Dora      string `json:"dora"`
+		RoundWind string `json:"round_wind"`
+		SelfWind  string `json:"self_wind"`
+		Discards  string `json:"discards"`
+		Turn      int    `json:"turn"`
 	}{}
 	if err := c.Bind(&d); err != nil {
 		fmt.Println(err)
 		return c.String(http.StatusBadRequest, err.Error())
 	}
 
-	if _, err := analysisHumanTiles(model.NewSimpleHumanTilesInfo(d.Tiles)); err != nil {
+	humanTilesInfo := &model.HumanTilesInfo{
+		HumanTiles:        d.Tiles,
+		HumanDoraTiles:    d.Dora,
+		HumanRoundWind:    d.RoundWind,
+		HumanSelfWind:     d.SelfWind,
+		HumanDiscardTiles: d.Discards,
+		Turn:              d.Turn,
+	}
+
+	if d.JSON || isJSONOutput {
+		result, err := analysisHumanTilesJSON(humanTilesInfo)
+		if err != nil {
+			return c.JSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+		}
+		return c.JSON(http.StatusOK, result)
+	}
+
+	if _, err := analysisHumanTiles(humanTilesInfo); err != nil {
 		fmt.Println(err)
 		return c.String(http.StatusBadRequest, err.Error())
 	}
@@ -525,6 +550,8 @@ func runServer(isHTTPS bool, port int) (err error) {
 	e.POST("/analysis", h.analysis)
 	e.POST("/tenhou", h.analysisTenhou)
 	e.POST("/majsoul", h.analysisMajsoul)
+	e.GET("/stream", h.stream)
+	e.GET("/ui", h.webUI)
 
 	// code.js 也用的该端口
 	if port == 0 {
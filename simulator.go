@@ -0,0 +1,769 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// 本地自对局模拟器
+// 用固定的随机种子洗牌，四家各由一个 AutoPlayer 操作
+// 所有事件都通过 DataParser 接口喂给各家的 roundData.analysis()，和实战时看到的数据一致
+// 暂不模拟鸣牌、杠、九种九牌、四风连打等，不计里宝牌、一发
+
+const (
+	simulatorMessageInit = iota
+	simulatorMessageSelfDraw
+	simulatorMessageDiscard
+	simulatorMessageRoundWin
+	simulatorMessageRyuukyoku
+)
+
+const (
+	simulatorInitPoint = 25000
+
+	// 牌山 136 张，其中王牌 14 张
+	simulatorWallTilesCount = 136
+	simulatorDeadWallCount  = 14
+)
+
+var simulatorStrategies = []string{"aggressive", "balanced", "defensive"}
+
+type simulatorMessage struct {
+	Type int `json:"type"`
+
+	RoundNumber    int   `json:"round_number,omitempty"`
+	BenNumber      int   `json:"ben_number,omitempty"`
+	Dealer         int   `json:"dealer,omitempty"`
+	DoraIndicators []int `json:"dora_indicators,omitempty"`
+	Tiles          []int `json:"tiles,omitempty"`
+	NumRedFives    []int `json:"num_red_fives,omitempty"`
+	Scores         []int `json:"scores,omitempty"` // 相对座位
+	RiichiSticks   int   `json:"riichi_sticks,omitempty"`
+	FirstDealer    int   `json:"first_dealer,omitempty"`
+	IsTonpuu       bool  `json:"is_tonpuu,omitempty"`
+
+	// 相对座位 0=自家, 1=下家, 2=对家, 3=上家
+	Who         int  `json:"who"`
+	Tile        int  `json:"tile"`
+	IsRedFive   bool `json:"is_red_five,omitempty"`
+	IsTsumogiri bool `json:"is_tsumogiri,omitempty"`
+	IsReach     bool `json:"is_reach,omitempty"`
+
+	Whos   []int `json:"whos,omitempty"`
+	Points []int `json:"points,omitempty"`
+}
+
+type simulatorRoundData struct {
+	*roundData
+
+	msg *simulatorMessage
+}
+
+func newSimulatorRoundData() *simulatorRoundData {
+	d := &simulatorRoundData{}
+	d.roundData = newGame(d)
+	d.roundData.skipOutput = true
+	d.roundData.playerNumber = 4
+	return d
+}
+
+func (d *simulatorRoundData) GetDataSourceType() int {
+	return dataSourceTypeSimulator
+}
+
+func (d *simulatorRoundData) GetSelfSeat() int {
+	return -1
+}
+
+func (d *simulatorRoundData) GetMessage() string {
+	data, _ := json.Marshal(d.msg)
+	return string(data)
+}
+
+func (d *simulatorRoundData) SkipMessage() bool {
+	return false
+}
+
+func (d *simulatorRoundData) IsLogin() bool {
+	return false
+}
+
+func (d *simulatorRoundData) HandleLogin() {
+}
+
+func (d *simulatorRoundData) IsInit() bool {
+	return d.msg.Type == simulatorMessageInit
+}
+
+func (d *simulatorRoundData) ParseInit() (roundNumber int, benNumber int, dealer int, doraIndicators []int, handTiles []int, numRedFives []int) {
+	d.playerNumber = 4
+	return d.msg.RoundNumber, d.msg.BenNumber, d.msg.Dealer, d.msg.DoraIndicators, d.msg.Tiles, d.msg.NumRedFives
+}
+
+func (d *simulatorRoundData) ParseStanding() *model.Standing {
+	if len(d.msg.Scores) == 0 {
+		return nil
+	}
+	return &model.Standing{
+		Scores:       d.msg.Scores,
+		AbsentPlayer: -1,
+		FirstDealer:  d.msg.FirstDealer,
+		RiichiSticks: d.msg.RiichiSticks,
+		IsTonpuu:     d.msg.IsTonpuu,
+	}
+}
+
+func (d *simulatorRoundData) IsSelfDraw() bool {
+	return d.msg.Type == simulatorMessageSelfDraw
+}
+
+func (d *simulatorRoundData) ParseSelfDraw() (tile int, isRedFive bool, kanDoraIndicator int) {
+	return d.msg.Tile, d.msg.IsRedFive, -1
+}
+
+func (d *simulatorRoundData) IsDiscard() bool {
+	return d.msg.Type == simulatorMessageDiscard
+}
+
+func (d *simulatorRoundData) ParseDiscard() (who int, discardTile int, isRedFive bool, isTsumogiri bool, isReach bool, canBeMeld bool, kanDoraIndicator int) {
+	return d.msg.Who, d.msg.Tile, d.msg.IsRedFive, d.msg.IsTsumogiri, d.msg.IsReach, false, -1
+}
+
+func (d *simulatorRoundData) IsOpen() bool {
+	return false
+}
+
+func (d *simulatorRoundData) ParseOpen() (who int, meld *model.Meld, kanDoraIndicator int) {
+	panic("[simulatorRoundData.ParseOpen] 模拟器暂不支持鸣牌")
+}
+
+func (d *simulatorRoundData) IsReach() bool {
+	// 立直宣言包含在舍牌中，见 ParseDiscard
+	return false
+}
+
+func (d *simulatorRoundData) ParseReach() (who int) {
+	return -1
+}
+
+func (d *simulatorRoundData) IsFuriten() bool {
+	return false
+}
+
+func (d *simulatorRoundData) IsRoundWin() bool {
+	return d.msg.Type == simulatorMessageRoundWin
+}
+
+func (d *simulatorRoundData) ParseRoundWin() (whos []int, points []int) {
+	return d.msg.Whos, d.msg.Points
+}
+
+func (d *simulatorRoundData) IsRyuukyoku() bool {
+	return d.msg.Type == simulatorMessageRyuukyoku
+}
+
+func (d *simulatorRoundData) ParseRyuukyoku() (type_ int, whos []int, points []int) {
+	return 0, d.msg.Whos, d.msg.Points
+}
+
+func (d *simulatorRoundData) IsNukiDora() bool {
+	return false
+}
+
+func (d *simulatorRoundData) ParseNukiDora() (who int, isTsumogiri bool) {
+	return -1, false
+}
+
+func (d *simulatorRoundData) IsNewDora() bool {
+	return false
+}
+
+func (d *simulatorRoundData) ParseNewDora() (kanDoraIndicator int) {
+	return -1
+}
+
+//
+
+type simulatorSeat struct {
+	strategy   string
+	parser     *simulatorRoundData
+	autoPlayer *AutoPlayer
+
+	point int
+
+	// 以下为裁判视角下本局的真实数据
+	hands        []int // 136 编码
+	discardTiles []int // 0-33
+	isReached    bool
+	isFuriten    bool // 同巡振听或立直后见逃
+}
+
+func newSimulatorSeat(strategy string) *simulatorSeat {
+	config := autoPlayerConfig
+	config.Enabled = true
+	config.AutoDiscard = true
+	config.AutoRiichi = true
+	config.AutoAgari = true
+	config.ConfirmActions = false
+	config.DelaySeconds = 0
+	config.Strategy = strategy
+	return &simulatorSeat{
+		strategy:   strategy,
+		parser:     newSimulatorRoundData(),
+		autoPlayer: NewAutoPlayer(&config),
+		point:      simulatorInitPoint,
+	}
+}
+
+func (s *simulatorSeat) tiles34() []int {
+	tiles34 := make([]int, 34)
+	for _, tile := range s.hands {
+		tiles34[tile/4]++
+	}
+	return tiles34
+}
+
+func (s *simulatorSeat) numRedFives() []int {
+	numRedFives := make([]int, 3)
+	for _, tile := range s.hands {
+		if isSimulatorRedFive(tile) {
+			numRedFives[tile/36]++
+		}
+	}
+	return numRedFives
+}
+
+// 切出一张牌，优先切非赤5
+func (s *simulatorSeat) removeTile(tile34 int, drawTile int) (tile int) {
+	idx := -1
+	for i, t := range s.hands {
+		if t/4 != tile34 {
+			continue
+		}
+		if t == drawTile {
+			idx = i
+			break
+		}
+		if idx == -1 || isSimulatorRedFive(s.hands[idx]) {
+			idx = i
+		}
+	}
+	if idx == -1 {
+		panic(fmt.Sprintf("[simulatorSeat.removeTile] 代码有误: 手牌中没有 %s", util.MahjongZH[tile34]))
+	}
+	tile = s.hands[idx]
+	s.hands = append(s.hands[:idx], s.hands[idx+1:]...)
+	return
+}
+
+// 判断 tiles34 (13 张) 是否舍牌振听
+func (s *simulatorSeat) isDiscardFuriten(tiles34 []int) bool {
+	for _, tile := range s.discardTiles {
+		tiles34[tile]++
+		isAgari := util.CalculateShanten(tiles34) == -1
+		tiles34[tile]--
+		if isAgari {
+			return true
+		}
+	}
+	return false
+}
+
+// 规则没有赤宝牌时，均视作普通的5
+func isSimulatorRedFive(tile int) bool {
+	if util.CurrentRuleset().NumRedFives == 0 {
+		return false
+	}
+	return tile == redFiveMan || tile == redFivePin || tile == redFiveSou
+}
+
+//
+
+type simulatorStats struct {
+	strategy string
+
+	games      int
+	rounds     int
+	pointSum   int
+	rankSum    int
+	rankCounts [4]int
+
+	winCount       int
+	tsumoCount     int
+	dealInCount    int
+	riichiCount    int
+	winPointSum    int
+	dealInPointSum int
+}
+
+func (s *simulatorStats) String() string {
+	if s.games == 0 || s.rounds == 0 {
+		return s.strategy + ": 无数据"
+	}
+	games := float64(s.games)
+	rounds := float64(s.rounds)
+	avgWinPoint, avgDealInPoint := 0.0, 0.0
+	if s.winCount > 0 {
+		avgWinPoint = float64(s.winPointSum) / float64(s.winCount)
+	}
+	if s.dealInCount > 0 {
+		avgDealInPoint = float64(s.dealInPointSum) / float64(s.dealInCount)
+	}
+	return fmt.Sprintf("%-10s 平均顺位 %.2f 平均得点 %6.0f 一位率 %5.1f%% 四位率 %5.1f%% 和了率 %5.1f%% (自摸 %5.1f%%) 放铳率 %5.1f%% 立直率 %5.1f%% 平均打点 %5.0f 平均铳点 %5.0f",
+		s.strategy,
+		float64(s.rankSum)/games,
+		float64(s.pointSum)/games,
+		float64(s.rankCounts[0])/games*100,
+		float64(s.rankCounts[3])/games*100,
+		float64(s.winCount)/rounds*100,
+		float64(s.tsumoCount)/rounds*100,
+		float64(s.dealInCount)/rounds*100,
+		float64(s.riichiCount)/rounds*100,
+		avgWinPoint,
+		avgDealInPoint,
+	)
+}
+
+type simulatorStatsList []*simulatorStats
+
+func (l simulatorStatsList) find(strategy string) *simulatorStats {
+	for _, stats := range l {
+		if stats.strategy == strategy {
+			return stats
+		}
+	}
+	return nil
+}
+
+//
+
+type simulator struct {
+	rand       *rand.Rand
+	strategies []string
+	isTonpuu   bool
+
+	stats simulatorStatsList
+
+	// 当前半庄的数据
+	seats        []*simulatorSeat
+	roundNumber  int
+	benNumber    int
+	riichiSticks int
+	dealer       int // 绝对座位，0 为第一局的东家
+
+	// 当前局的数据
+	wall           []int // 136 编码，不含王牌
+	wallIndex      int
+	doraIndicators []int // 0-33
+}
+
+// strategies: 四家（第一局的东南西北）的策略，每个半庄结束后轮换座位
+func newSimulator(seed int64, strategies []string, isTonpuu bool) (*simulator, error) {
+	if len(strategies) != 4 {
+		return nil, fmt.Errorf("参数错误: 需要指定四家的策略，当前为 %d 家", len(strategies))
+	}
+	stats := simulatorStatsList{}
+	for _, strategy := range strategies {
+		if !util.InStrings(strategy, simulatorStrategies) {
+			return nil, fmt.Errorf("参数错误: 未知策略 %s（可选 %s）", strategy, strings.Join(simulatorStrategies, "/"))
+		}
+		if stats.find(strategy) == nil {
+			stats = append(stats, &simulatorStats{strategy: strategy})
+		}
+	}
+	return &simulator{
+		rand:       rand.New(rand.NewSource(seed)),
+		strategies: strategies,
+		isTonpuu:   isTonpuu,
+		stats:      stats,
+	}, nil
+}
+
+// 将绝对座位 who 转换成 seat 视角下的相对座位
+func (*simulator) relativeSeat(seat int, who int) int {
+	return (who - seat + 4) % 4
+}
+
+// seat 视角下各家的点数
+func (s *simulator) relativeScores(seat int) []int {
+	scores := make([]int, 4)
+	for who, st := range s.seats {
+		scores[s.relativeSeat(seat, who)] = st.point
+	}
+	return scores
+}
+
+// 向各家发送消息，whos 为空时发送给所有人
+func (s *simulator) send(newMsg func(seat int) *simulatorMessage, whos ...int) error {
+	if len(whos) == 0 {
+		whos = []int{0, 1, 2, 3}
+	}
+	for _, seat := range whos {
+		d := s.seats[seat].parser
+		d.msg = newMsg(seat)
+		if err := d.analysis(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// 新的半庄，每个半庄轮换一次座位
+func (s *simulator) newGame(gameIndex int) {
+	s.seats = make([]*simulatorSeat, 4)
+	for i := range s.seats {
+		s.seats[i] = newSimulatorSeat(s.strategies[(i+gameIndex)%4])
+	}
+	s.roundNumber = 0
+	s.benNumber = 0
+	s.riichiSticks = 0
+	s.dealer = 0
+}
+
+func (s *simulator) playGame(gameIndex int) error {
+	s.newGame(gameIndex)
+
+	maxRoundNumber := 8
+	if s.isTonpuu {
+		maxRoundNumber = 4
+	}
+	for s.roundNumber < maxRoundNumber {
+		if err := s.playRound(); err != nil {
+			return err
+		}
+		for _, seat := range s.seats {
+			s.stats.find(seat.strategy).rounds++
+		}
+		// 被飞
+		if s.hasBusted() {
+			break
+		}
+	}
+
+	for rank, seat := range s.rankedSeats() {
+		stats := s.stats.find(seat.strategy)
+		stats.games++
+		stats.pointSum += seat.point
+		stats.rankSum += rank + 1
+		stats.rankCounts[rank]++
+	}
+	return nil
+}
+
+func (s *simulator) hasBusted() bool {
+	for _, seat := range s.seats {
+		if seat.point < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// 按点数排序，同点时起家优先
+func (s *simulator) rankedSeats() []*simulatorSeat {
+	seats := append([]*simulatorSeat{}, s.seats...)
+	sort.SliceStable(seats, func(i, j int) bool {
+		return seats[i].point > seats[j].point
+	})
+	return seats
+}
+
+func (s *simulator) playRound() error {
+	tiles := s.rand.Perm(simulatorWallTilesCount)
+	deadWall := tiles[simulatorWallTilesCount-simulatorDeadWallCount:]
+	s.wall = tiles[:simulatorWallTilesCount-simulatorDeadWallCount]
+	s.wallIndex = 0
+	s.doraIndicators = []int{deadWall[4] / 4}
+
+	for i := 0; i < 4; i++ {
+		seat := s.seats[(s.dealer+i)%4]
+		seat.hands = append([]int{}, s.wall[13*i:13*i+13]...)
+		seat.discardTiles = []int{}
+		seat.isReached = false
+		seat.isFuriten = false
+	}
+	s.wallIndex = 52
+
+	if err := s.send(func(seat int) *simulatorMessage {
+		return &simulatorMessage{
+			Type:           simulatorMessageInit,
+			RoundNumber:    s.roundNumber,
+			BenNumber:      s.benNumber,
+			Dealer:         s.relativeSeat(seat, s.dealer),
+			DoraIndicators: append([]int{}, s.doraIndicators...),
+			Tiles:          util.Tiles34ToTiles(s.seats[seat].tiles34()),
+			NumRedFives:    s.seats[seat].numRedFives(),
+			Scores:         s.relativeScores(seat),
+			RiichiSticks:   s.riichiSticks,
+			FirstDealer:    s.relativeSeat(seat, 0),
+			IsTonpuu:       s.isTonpuu,
+		}
+	}); err != nil {
+		return err
+	}
+
+	for who := s.dealer; s.wallIndex < len(s.wall); who = (who + 1) % 4 {
+		seat := s.seats[who]
+
+		// 摸牌
+		drawTile := s.wall[s.wallIndex]
+		s.wallIndex++
+		seat.hands = append(seat.hands, drawTile)
+		if !seat.isReached {
+			seat.isFuriten = false
+		}
+		if err := s.send(func(int) *simulatorMessage {
+			return &simulatorMessage{
+				Type:      simulatorMessageSelfDraw,
+				Tile:      drawTile / 4,
+				IsRedFive: isSimulatorRedFive(drawTile),
+			}
+		}, who); err != nil {
+			return err
+		}
+
+		// 自摸
+		if tiles34 := seat.tiles34(); util.CalculateShanten(tiles34) == -1 && seat.autoPlayer.config.AutoAgari {
+			if result := s.calcPoint(who, tiles34, drawTile, true); result.Point > 0 {
+				return s.tsumo(who, result)
+			}
+		}
+
+		// 舍牌
+		discardTile34, isReach := s.makeDiscardDecision(who, drawTile)
+		discardTile := seat.removeTile(discardTile34, drawTile)
+		seat.discardTiles = append(seat.discardTiles, discardTile34)
+		if err := s.send(func(_seat int) *simulatorMessage {
+			return &simulatorMessage{
+				Type:        simulatorMessageDiscard,
+				Who:         s.relativeSeat(_seat, who),
+				Tile:        discardTile34,
+				IsRedFive:   isSimulatorRedFive(discardTile),
+				IsTsumogiri: discardTile == drawTile,
+				IsReach:     isReach,
+			}
+		}); err != nil {
+			return err
+		}
+
+		// 荣和（头跳）
+		for i := 1; i < 4; i++ {
+			ronWho := (who + i) % 4
+			ronSeat := s.seats[ronWho]
+			tiles34 := ronSeat.tiles34()
+			tiles34[discardTile34]++
+			isAgari := util.CalculateShanten(tiles34) == -1
+			tiles34[discardTile34]--
+			if !isAgari {
+				continue
+			}
+			if !ronSeat.isFuriten && !ronSeat.isDiscardFuriten(tiles34) && ronSeat.autoPlayer.config.AutoAgari {
+				tiles34[discardTile34]++
+				result := s.calcPoint(ronWho, tiles34, discardTile, false)
+				if result.Point > 0 {
+					return s.ron(ronWho, who, result)
+				}
+			}
+			// 见逃
+			ronSeat.isFuriten = true
+		}
+
+		// 立直成立
+		if isReach {
+			seat.isReached = true
+			seat.point -= 1000
+			s.riichiSticks++
+			s.stats.find(seat.strategy).riichiCount++
+		}
+	}
+
+	return s.ryuukyoku()
+}
+
+// 通过该座位的 roundData 获取 AutoPlayer 的舍牌决策，并判断是否立直
+func (s *simulator) makeDiscardDecision(who int, drawTile int) (discardTile34 int, isReach bool) {
+	seat := s.seats[who]
+	if seat.isReached {
+		return drawTile / 4, false
+	}
+
+	d := seat.parser.roundData
+	playerInfo := d.newModelPlayerInfo()
+	riskTables := d.analysisTilesRisk()
+	decision := seat.autoPlayer.MakeDecision(playerInfo, riskTables.mixedRiskTable(), riskTables.opponentRisks(), -1, false, false, false)
+
+	tiles34 := seat.tiles34()
+	discardTile34 = drawTile / 4
+	if decision.Action == "discard" && decision.Tile >= 0 && tiles34[decision.Tile] > 0 {
+		discardTile34 = decision.Tile
+	}
+
+	// 剩余不到 4 张或不足 1000 点时无法立直
+	if seat.autoPlayer.config.AutoRiichi && seat.point >= 1000 && len(s.wall)-s.wallIndex >= 4 {
+		tiles34[discardTile34]--
+		isReach = util.CalculateShanten(tiles34) == 0
+	}
+	return
+}
+
+// tiles34 为和牌时的 14 张牌
+func (s *simulator) calcPoint(who int, tiles34 []int, winTile int, isTsumo bool) *util.PointResult {
+	seat := s.seats[who]
+	numRedFives := seat.numRedFives()
+	if !isTsumo && isSimulatorRedFive(winTile) {
+		numRedFives[winTile/36]++
+	}
+	playerInfo := &model.PlayerInfo{
+		HandTiles34:   tiles34,
+		DoraTiles:     model.DoraList(s.doraIndicators, false),
+		NumRedFives:   numRedFives,
+		IsTsumo:       isTsumo,
+		WinTile:       winTile / 4,
+		RoundWindTile: 27 + s.roundNumber/4,
+		SelfWindTile:  27 + s.relativeSeat(s.dealer, who),
+		IsParent:      who == s.dealer,
+		IsRiichi:      seat.isReached,
+		DiscardTiles:  seat.discardTiles,
+	}
+	seat.parser.roundData.fillWinSituation(playerInfo)
+	return util.CalcPoint(playerInfo)
+}
+
+func (s *simulator) tsumo(who int, result *util.PointResult) error {
+	childPoint, parentPoint := result.TsumoPoints()
+	winPoint := 1000 * s.riichiSticks
+	for i, seat := range s.seats {
+		if i == who {
+			continue
+		}
+		pay := childPoint + 100*s.benNumber
+		if i == s.dealer {
+			pay = parentPoint + 100*s.benNumber
+		}
+		seat.point -= pay
+		winPoint += pay
+	}
+	s.seats[who].point += winPoint
+
+	stats := s.stats.find(s.seats[who].strategy)
+	stats.winCount++
+	stats.tsumoCount++
+	stats.winPointSum += result.Point
+	return s.endRoundWithWin(who, winPoint)
+}
+
+func (s *simulator) ron(who int, fromWho int, result *util.PointResult) error {
+	pay := result.Point + 300*s.benNumber
+	winPoint := pay + 1000*s.riichiSticks
+	s.seats[fromWho].point -= pay
+	s.seats[who].point += winPoint
+
+	stats := s.stats.find(s.seats[who].strategy)
+	stats.winCount++
+	stats.winPointSum += result.Point
+	fromStats := s.stats.find(s.seats[fromWho].strategy)
+	fromStats.dealInCount++
+	fromStats.dealInPointSum += result.Point
+	return s.endRoundWithWin(who, winPoint)
+}
+
+func (s *simulator) endRoundWithWin(who int, winPoint int) error {
+	s.riichiSticks = 0
+	if err := s.send(func(seat int) *simulatorMessage {
+		return &simulatorMessage{
+			Type:   simulatorMessageRoundWin,
+			Whos:   []int{s.relativeSeat(seat, who)},
+			Points: []int{winPoint},
+		}
+	}); err != nil {
+		return err
+	}
+	if who == s.dealer {
+		s.benNumber++
+	} else {
+		s.nextDealer()
+	}
+	return nil
+}
+
+// 荒牌流局，罚符 3000 点
+func (s *simulator) ryuukyoku() error {
+	tenpaiWhos := []int{}
+	for who, seat := range s.seats {
+		if util.CalculateShanten(seat.tiles34()) == 0 {
+			tenpaiWhos = append(tenpaiWhos, who)
+		}
+	}
+	if n := len(tenpaiWhos); n > 0 && n < 4 {
+		for who, seat := range s.seats {
+			if util.InInts(who, tenpaiWhos) {
+				seat.point += 3000 / n
+			} else {
+				seat.point -= 3000 / (4 - n)
+			}
+		}
+	}
+
+	if err := s.send(func(seat int) *simulatorMessage {
+		whos := []int{}
+		for _, who := range tenpaiWhos {
+			whos = append(whos, s.relativeSeat(seat, who))
+		}
+		return &simulatorMessage{
+			Type: simulatorMessageRyuukyoku,
+			Whos: whos,
+		}
+	}); err != nil {
+		return err
+	}
+
+	s.benNumber++
+	if !util.InInts(s.dealer, tenpaiWhos) {
+		s.roundNumber++
+		s.dealer = (s.dealer + 1) % 4
+	}
+	return nil
+}
+
+func (s *simulator) nextDealer() {
+	s.roundNumber++
+	s.benNumber = 0
+	s.dealer = (s.dealer + 1) % 4
+}
+
+func (s *simulator) run(games int) error {
+	for i := 0; i < games; i++ {
+		if err := s.playGame(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *simulator) printStats() {
+	for _, stats := range s.stats {
+		fmt.Println(stats)
+	}
+}
+
+func runSimulator(games int, seed int64, humanStrategies string, isTonpuu bool) error {
+	s, err := newSimulator(seed, strings.Split(humanStrategies, ","), isTonpuu)
+	if err != nil {
+		return err
+	}
+	gameName := "半庄"
+	if isTonpuu {
+		gameName = "东风"
+	}
+	fmt.Printf("开始模拟 %d 场%s战，随机种子为 %d\n", games, gameName, seed)
+	if err := s.run(games); err != nil {
+		return err
+	}
+	s.printStats()
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestSimulatorPlayRound(t *testing.T) {
+	s, err := newSimulator(1, []string{"aggressive", "balanced", "defensive", "balanced"}, true)
+	assert.NoError(t, err)
+	s.newGame(0)
+	assert.NoError(t, s.playRound())
+
+	// 点数守恒：四家点数 + 场上立直棒 = 100000
+	sum := 1000 * s.riichiSticks
+	for _, seat := range s.seats {
+		sum += seat.point
+		t.Log(seat.strategy, seat.point)
+	}
+	assert.Equal(t, 4*simulatorInitPoint, sum)
+
+	// 各家的 roundData 与裁判视角下的手牌一致
+	for _, seat := range s.seats {
+		if !seat.parser.players[0].isReached {
+			assert.Equal(t, seat.tiles34(), seat.parser.counts)
+		}
+	}
+}
+
+func TestNewSimulator(t *testing.T) {
+	_, err := newSimulator(1, []string{"aggressive", "balanced"}, true)
+	assert.Error(t, err)
+	_, err = newSimulator(1, []string{"aggressive", "balanced", "defensive", "foo"}, true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,215 @@
+package main
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// 通过 WebSocket（/stream）推送实时分析结果，供副屏或他人观战使用
+
+const (
+	streamEventTypeRoundStart   = "round_start"   // 配牌
+	streamEventTypeAnalysis     = "analysis"      // 自家摸牌后的何切分析
+	streamEventTypeMeldAnalysis = "meld_analysis" // 他家舍牌后的鸣牌分析
+	streamEventTypeRoundWin     = "round_win"     // 和牌
+)
+
+type streamEvent struct {
+	Type string `json:"type"`
+	Time int64  `json:"time"` // 毫秒时间戳
+
+	RoundNumber    int      `json:"round_number"` // 0=东1局，4=南1局
+	BenNumber      int      `json:"ben_number"`
+	Dealer         int      `json:"dealer"` // 0=自家 1=下家 2=对家 3=上家
+	DoraIndicators []string `json:"dora_indicators"`
+
+	// 舍牌者（鸣牌分析）或和牌者（和牌）
+	Whos   []int `json:"whos,omitempty"`
+	Points []int `json:"points,omitempty"`
+
+	Analysis *analysisJSON `json:"analysis,omitempty"`
+}
+
+//
+
+// 每个客户端最多缓存的事件数，超出后丢弃新事件
+const streamClientBufferSize = 32
+
+type streamHub struct {
+	sync.Mutex
+	clients map[chan []byte]struct{}
+}
+
+func newStreamHub() *streamHub {
+	return &streamHub{clients: map[chan []byte]struct{}{}}
+}
+
+var globalStreamHub = newStreamHub()
+
+func (hub *streamHub) subscribe() chan []byte {
+	hub.Lock()
+	defer hub.Unlock()
+	ch := make(chan []byte, streamClientBufferSize)
+	hub.clients[ch] = struct{}{}
+	return ch
+}
+
+func (hub *streamHub) unsubscribe(ch chan []byte) {
+	hub.Lock()
+	defer hub.Unlock()
+	if _, ok := hub.clients[ch]; ok {
+		delete(hub.clients, ch)
+		close(ch)
+	}
+}
+
+func (hub *streamHub) hasClients() bool {
+	hub.Lock()
+	defer hub.Unlock()
+	return len(hub.clients) > 0
+}
+
+func (hub *streamHub) publish(event *streamEvent) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "streamHub.publish:", err)
+		return
+	}
+
+	hub.Lock()
+	defer hub.Unlock()
+	for ch := range hub.clients {
+		select {
+		case ch <- data:
+		default:
+			// 客户端处理不过来，丢弃
+		}
+	}
+}
+
+//
+
+func (d *roundData) newStreamEvent(eventType string) *streamEvent {
+	return &streamEvent{
+		Type:           eventType,
+		Time:           time.Now().UnixNano() / int64(time.Millisecond),
+		RoundNumber:    d.roundNumber,
+		BenNumber:      d.benNumber,
+		Dealer:         d.dealer,
+		DoraIndicators: tilesToJSON(d.doraIndicators),
+	}
+}
+
+// 没有客户端时不做任何计算
+func (d *roundData) publishAnalysis(eventType string, playerInfo *model.PlayerInfo, mixedRiskTable riskTable, riskTables riskInfoList) {
+	if !globalStreamHub.hasClients() {
+		return
+	}
+	result, err := newAnalysisJSON(playerInfo, mixedRiskTable, riskTables)
+	if err != nil {
+		return
+	}
+	event := d.newStreamEvent(eventType)
+	event.Analysis = result
+	globalStreamHub.publish(event)
+}
+
+func (d *roundData) publishMeldAnalysis(who int, playerInfo *model.PlayerInfo, targetTile34 int, isRedFive bool, allowChi bool, mixedRiskTable riskTable, riskTables riskInfoList) {
+	if !globalStreamHub.hasClients() {
+		return
+	}
+	result, err := newMeldAnalysisJSON(playerInfo, targetTile34, isRedFive, allowChi, mixedRiskTable, riskTables)
+	if err != nil || result == nil {
+		return
+	}
+	event := d.newStreamEvent(streamEventTypeMeldAnalysis)
+	event.Whos = []int{who}
+	event.Analysis = result
+	globalStreamHub.publish(event)
+}
+
+func (d *roundData) publishRoundWin(whos []int, points []int) {
+	if !globalStreamHub.hasClients() {
+		return
+	}
+	event := d.newStreamEvent(streamEventTypeRoundWin)
+	event.Whos = whos
+	event.Points = points
+	globalStreamHub.publish(event)
+}
+
+//
+
+var streamUpgrader = websocket.Upgrader{
+	// 与 CORS 中间件一致，允许任意来源
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// 推送实时分析结果
+func (h *mjHandler) stream(c echo.Context) error {
+	ws, err := streamUpgrader.Upgrade(c.Response(), c.Request(), nil)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	ch := globalStreamHub.subscribe()
+	defer globalStreamHub.unsubscribe(ch)
+
+	// 客户端断开时 ReadMessage 会返回错误
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			if _, _, err := ws.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case data := <-ch:
+			if err := ws.WriteMessage(websocket.TextMessage, data); err != nil {
+				return nil
+			}
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// 连接 /stream 并逐行打印收到的事件，用于本地调试
+func runStreamClient(url string) error {
+	dialer := *websocket.DefaultDialer
+	// 雀魂模式下使用的是自签名证书
+	dialer.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	ws, _, err := dialer.Dial(url, nil)
+	if err != nil {
+		return fmt.Errorf("连接 %s 失败: %v", url, err)
+	}
+	defer ws.Close()
+
+	fmt.Fprintf(os.Stderr, "已连接 %s\n", url)
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return err
+		}
+		event := &streamEvent{}
+		if err := json.Unmarshal(data, event); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "[%s] %s%d局%d本场\n", event.Type, util.MahjongZH[27+event.RoundNumber/4], event.RoundNumber%4+1, event.BenNumber)
+		fmt.Println(string(data))
+	}
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStream(t *testing.T) {
+	e := echo.New()
+	e.GET("/stream", (&mjHandler{}).stream)
+	server := httptest.NewServer(e)
+	defer server.Close()
+
+	ws, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	// 等待服务端完成订阅
+	for i := 0; i < 100 && !globalStreamHub.hasClients(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.True(t, globalStreamHub.hasClients())
+
+	d := &tenhouRoundData{isRoundEnd: true}
+	d.roundData = newGame(d)
+	d.reset(4, 1, 2)
+	d.doraIndicators = []int{0}
+
+	tiles34, _, err := util.StrToTiles34("123m 456p 789s 11223z")
+	assert.NoError(t, err)
+	d.publishAnalysis(streamEventTypeAnalysis, model.NewSimplePlayerInfo(tiles34, nil), nil, nil)
+	d.publishRoundWin([]int{1}, []int{8000})
+
+	readEvent := func() *streamEvent {
+		ws.SetReadDeadline(time.Now().Add(5 * time.Second))
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := &streamEvent{}
+		assert.NoError(t, json.Unmarshal(data, event))
+		return event
+	}
+
+	event := readEvent()
+	assert.Equal(t, streamEventTypeAnalysis, event.Type)
+	assert.Equal(t, 4, event.RoundNumber)
+	assert.Equal(t, 1, event.BenNumber)
+	assert.Equal(t, []string{"1m"}, event.DoraIndicators)
+	if assert.NotNil(t, event.Analysis) && assert.NotEmpty(t, event.Analysis.Results14) {
+		assert.Equal(t, "3z", event.Analysis.Results14[0].DiscardTile)
+	}
+
+	event = readEvent()
+	assert.Equal(t, streamEventTypeRoundWin, event.Type)
+	assert.Equal(t, []int{1}, event.Whos)
+	assert.Equal(t, []int{8000}, event.Points)
+
+	// 断开后取消订阅
+	ws.Close()
+	for i := 0; i < 100 && globalStreamHub.hasClients(); i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.False(t, globalStreamHub.hasClients())
+}
@@ -142,6 +142,34 @@ const (
 	redFiveSou = 88
 )
 
+// 房间类型的二进制位
+const (
+	tenhouLobbyTypeNoRedFive    = 0x02 // 无赤
+	tenhouLobbyTypeNoOpenTanyao = 0x04 // 无食断
+	tenhouLobbyTypeHanchan      = 0x08 // 半庄战
+)
+
+// 根据房间类型选择规则，如 169 为鳳南喰赤
+func tenhouLobbyRuleset(lobbyType string) util.Ruleset {
+	r := util.RulesetTenhou
+	t, err := strconv.Atoi(lobbyType)
+	if err != nil {
+		return r
+	}
+	if t&tenhouLobbyTypeNoRedFive != 0 {
+		r.NumRedFives = 0
+	}
+	if t&tenhouLobbyTypeNoOpenTanyao != 0 {
+		r.OpenTanyao = false
+	}
+	return r
+}
+
+func isTenhouLobbyTonpuu(lobbyType string) bool {
+	t, err := strconv.Atoi(lobbyType)
+	return err == nil && t&tenhouLobbyTypeHanchan == 0
+}
+
 type tenhouMessage struct {
 	Tag string `json:"tag" xml:"-"`
 
@@ -205,7 +233,7 @@ type tenhouMessage struct {
 
 	// 重连 tag=GO
 	// type, lobby, gpid
-	//Type  string `json:"type"`
+	Type string `json:"type" xml:"type,attr"` // 房间类型 169
 	//Lobby string `json:"lobby"`
 	//GPID  string `json:"gpid"`
 
@@ -214,13 +242,14 @@ type tenhouMessage struct {
 	// `json:"ten"`
 	// `json:"oya"`
 	// `json:"hai"`
-	//Meld1    string `json:"m1"` // 各家副露编号 17450
-	//Meld2    string `json:"m2"`
-	//Meld3    string `json:"m3"`
-	//Kawa0 string `json:"kawa0"` // 各家牌河 112,73,3,131,43,98,78,116
-	//Kawa1 string `json:"kawa1"`
-	//Kawa2 string `json:"kawa2"`
-	//Kawa3 string `json:"kawa3"`
+	Meld0 string `json:"m0" xml:"m0,attr"` // 各家副露编号 17450,35914
+	Meld1 string `json:"m1" xml:"m1,attr"`
+	Meld2 string `json:"m2" xml:"m2,attr"`
+	Meld3 string `json:"m3" xml:"m3,attr"`
+	Kawa0 string `json:"kawa0" xml:"kawa0,attr"` // 各家牌河 112,73,3,255,131,43，255 表示下一张牌为立直宣言牌
+	Kawa1 string `json:"kawa1" xml:"kawa1,attr"`
+	Kawa2 string `json:"kawa2" xml:"kawa2,attr"`
+	Kawa3 string `json:"kawa3" xml:"kawa3,attr"`
 }
 
 //
@@ -232,6 +261,8 @@ type tenhouRoundData struct {
 	msg        *tenhouMessage
 
 	isRoundEnd bool // 某人和牌或流局。初始值为 true
+
+	isTonpuu bool // 是否为东风战，由房间类型决定
 }
 
 func (*tenhouRoundData) _tenhouTileToTile34(tenhouTile int) int {
@@ -414,6 +445,10 @@ func (d *tenhouRoundData) GetMessage() string {
 }
 
 func (d *tenhouRoundData) SkipMessage() bool {
+	if d.msg.Tag == "GO" {
+		autoSetRuleset(tenhouLobbyRuleset(d.msg.Type))
+		d.isTonpuu = isTenhouLobbyTonpuu(d.msg.Type)
+	}
 	// 注意：即使没有获取到用户名也能正常进行游戏
 	return false
 }
@@ -438,6 +473,80 @@ func (d *tenhouRoundData) IsInit() bool {
 	return d.msg.Tag == "INIT" || d.msg.Tag == "REINIT"
 }
 
+func (d *tenhouRoundData) IsReinit() bool {
+	return d.msg.Tag == "REINIT"
+}
+
+// 天凤牌河中表示立直的标记
+const tenhouKawaReachMark = 255
+
+func (d *tenhouRoundData) ParseReinit() *reinitState {
+	s := &reinitState{}
+	// 牌河中出现过的牌，用来判断被鸣的牌是否还在牌河中
+	inKawa := map[int]bool{}
+	for who, kawa := range []string{d.msg.Kawa0, d.msg.Kawa1, d.msg.Kawa2, d.msg.Kawa3} {
+		s.reachTileAt[who] = -1
+		if kawa == "" {
+			continue
+		}
+		isReach := false
+		for _, raw := range strings.Split(kawa, ",") {
+			tenhouTile, err := strconv.Atoi(raw)
+			if err != nil {
+				panic(err)
+			}
+			if tenhouTile == tenhouKawaReachMark {
+				isReach = true
+				continue
+			}
+			if tenhouTile >= 136 {
+				continue
+			}
+			if isReach {
+				s.reachTileAt[who] = len(s.discardTiles[who])
+				isReach = false
+			}
+			inKawa[tenhouTile] = true
+			s.discardTiles[who] = append(s.discardTiles[who], d._tenhouTileToTile34(tenhouTile))
+		}
+	}
+
+	playerNumber := d.playerNumber
+	if playerNumber == 0 {
+		playerNumber = 4
+	}
+	for who, melds := range []string{d.msg.Meld0, d.msg.Meld1, d.msg.Meld2, d.msg.Meld3} {
+		if melds == "" {
+			continue
+		}
+		for _, data := range strings.Split(melds, ",") {
+			if d.isNukiOperator(data) {
+				s.nukiDoraNums[who]++
+				continue
+			}
+			meld, tenhouMeldTiles := d._newMeld(data)
+			s.melds[who] = append(s.melds[who], meld)
+			if meld.MeldType == meldTypeAnkan {
+				continue
+			}
+			// 被鸣的牌不在牌河中时，补到被鸣的玩家的牌河中
+			found := false
+			for _, tenhouTile := range tenhouMeldTiles {
+				if inKawa[tenhouTile] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				bits, _ := strconv.Atoi(data)
+				fromWho := (who + bits&0x3) % playerNumber
+				s.discardTiles[fromWho] = append(s.discardTiles[fromWho], meld.CalledTile)
+			}
+		}
+	}
+	return s
+}
+
 func (d *tenhouRoundData) ParseInit() (roundNumber int, benNumber int, dealer int, doraIndicators []int, handTiles []int, numRedFives []int) {
 	d.isRoundEnd = false
 
@@ -472,6 +581,37 @@ func (d *tenhouRoundData) ParseInit() (roundNumber int, benNumber int, dealer in
 	return
 }
 
+func (d *tenhouRoundData) ParseStanding() *model.Standing {
+	tenSplits := strings.Split(d.msg.Ten, ",")
+	seedSplits := strings.Split(d.msg.Seed, ",")
+	if len(tenSplits) != 4 || len(seedSplits) != 6 {
+		return nil
+	}
+
+	standing := &model.Standing{
+		Scores:       make([]int, 4),
+		AbsentPlayer: -1,
+		IsTonpuu:     d.isTonpuu,
+	}
+	for i, ten := range tenSplits {
+		score, _ := strconv.Atoi(ten)
+		// 天凤的点数以百点为单位
+		standing.Scores[i] = 100 * score
+	}
+	standing.RiichiSticks, _ = strconv.Atoi(seedSplits[2])
+
+	playerNumber := 4
+	if d.playerNumber == 3 {
+		// 三麻时上家不存在
+		playerNumber = 3
+		standing.AbsentPlayer = 3
+	}
+	roundNumber, _ := strconv.Atoi(seedSplits[0])
+	dealer, _ := strconv.Atoi(d.msg.Dealer)
+	standing.FirstDealer = ((dealer-roundNumber%4)%playerNumber + playerNumber) % playerNumber
+	return standing
+}
+
 var _selfDrawReg = regexp.MustCompile("^T[0-9]{1,3}$")
 
 func isTenhouSelfDraw(tag string) bool {
@@ -527,7 +667,13 @@ func (d *tenhouRoundData) IsOpen() bool {
 
 func (d *tenhouRoundData) ParseOpen() (who int, meld *model.Meld, kanDoraIndicator int) {
 	who, _ = strconv.Atoi(d.msg.Who)
-	meldType, tenhouMeldTiles, tenhouCalledTile := d._parseTenhouMeld(d.msg.Meld)
+	meld, _ = d._newMeld(d.msg.Meld)
+	kanDoraIndicator = -1
+	return
+}
+
+func (d *tenhouRoundData) _newMeld(data string) (meld *model.Meld, tenhouMeldTiles []int) {
+	meldType, tenhouMeldTiles, tenhouCalledTile := d._parseTenhouMeld(data)
 	meldTiles := make([]int, len(tenhouMeldTiles))
 	for i, tenhouTile := range tenhouMeldTiles {
 		meldTiles[i] = d._tenhouTileToTile34(tenhouTile)
@@ -542,7 +688,6 @@ func (d *tenhouRoundData) ParseOpen() (who int, meld *model.Meld, kanDoraIndicat
 		ContainRedFive:    d.containRedFive(tenhouMeldTiles),
 		RedFiveFromOthers: isCalledTileRedFive && (meldType == model.MeldTypeChi || meldType == model.MeldTypePon || meldType == model.MeldTypeMinkan),
 	}
-	kanDoraIndicator = -1
 	return
 }
 
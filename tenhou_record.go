@@ -0,0 +1,318 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/platform/tenhou"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// 天凤牌谱（mjlog XML）回放
+// 牌谱中的座位均为绝对座位（0 为起家），回放时需要转换成 seat 视角下的消息再交给 tenhouRoundData 解析
+
+type tenhouRecordConverter struct {
+	seat int
+
+	// 三麻时为 3，座位 3 不存在，相对座位按三家轮转，上家为 2
+	playerNumber int
+
+	// 各家最近一次摸的牌，用来推断摸切
+	latestDrawTiles []string
+}
+
+func newTenhouRecordConverter(seat int) *tenhouRecordConverter {
+	return &tenhouRecordConverter{
+		seat:            seat,
+		playerNumber:    4,
+		latestDrawTiles: make([]string, 4),
+	}
+}
+
+func (c *tenhouRecordConverter) relativeSeat(who int) int {
+	return (who - c.seat + c.playerNumber) % c.playerNumber
+}
+
+func (c *tenhouRecordConverter) relativeWho(who string) string {
+	w, err := strconv.Atoi(who)
+	if err != nil {
+		return who
+	}
+	return strconv.Itoa(c.relativeSeat(w))
+}
+
+// 返回 nil 表示跳过该操作
+func (c *tenhouRecordConverter) convert(action *tenhou.RecordAction) *tenhouMessage {
+	tag := action.Tag
+	if tag == "" {
+		return nil
+	}
+
+	switch {
+	case tag == "INIT":
+		c.latestDrawTiles = make([]string, 4)
+		c.playerNumber = tenhouRecordPlayerNumber(action)
+		hai := []string{action.Hai0, action.Hai1, action.Hai2, action.Hai3}[c.seat]
+		ten := strings.Split(action.Ten, ",")
+		if len(ten) == 4 {
+			// 三麻时不存在的一家总是排在最后
+			n := c.playerNumber
+			ten = append(append(ten[c.seat:n:n], ten[:c.seat]...), ten[n:]...)
+		}
+		return &tenhouMessage{
+			Tag:    tag,
+			Seed:   action.Seed,
+			Ten:    strings.Join(ten, ","),
+			Dealer: c.relativeWho(action.Dealer),
+			Hai:    hai,
+		}
+	case isTenhouRecordDraw(tag):
+		who := int(tag[0] - 'T')
+		c.latestDrawTiles[who] = tag[1:]
+		// 他家摸牌对应 U V W
+		return &tenhouMessage{Tag: string('T'+byte(c.relativeSeat(who))) + tag[1:]}
+	case isTenhouRecordDiscard(tag):
+		who := int(tag[0] - 'D')
+		rawTile := tag[1:]
+		prefix := 'D' + byte(c.relativeSeat(who))
+		if prefix != 'D' && rawTile == c.latestDrawTiles[who] {
+			// 摸切用小写表示
+			prefix = prefix - 'A' + 'a'
+		}
+		c.latestDrawTiles[who] = ""
+		return &tenhouMessage{Tag: string(prefix) + rawTile}
+	case tag == "N", tag == "REACH", tag == "AGARI":
+		return &tenhouMessage{
+			Tag:  tag,
+			Who:  c.relativeWho(action.Who),
+			Meld: action.Meld,
+			Step: action.Step,
+			Ten:  action.Ten,
+		}
+	case tag == "DORA":
+		return &tenhouMessage{Tag: tag, Hai: action.Hai}
+	case tag == "RYUUKYOKU":
+		return &tenhouMessage{Tag: tag}
+	default:
+		// SHUFFLE GO UN TAIKYOKU BYE 等
+		return nil
+	}
+}
+
+// 三麻的牌谱中北家没有配牌
+func tenhouRecordPlayerNumber(init *tenhou.RecordAction) int {
+	if init.Hai3 == "" {
+		return 3
+	}
+	return 4
+}
+
+func isTenhouRecordDraw(tag string) bool {
+	return len(tag) > 1 && tag[0] >= 'T' && tag[0] <= 'W' && isTenhouRecordTile(tag[1:])
+}
+
+func isTenhouRecordDiscard(tag string) bool {
+	return len(tag) > 1 && tag[0] >= 'D' && tag[0] <= 'G' && isTenhouRecordTile(tag[1:])
+}
+
+// 转换后的他家舍牌，e/E=下家, f/F=对家, g/G=上家
+func isTenhouRecordOthersDiscard(tag string) bool {
+	return len(tag) > 1 && strings.ContainsRune("EFGefg", rune(tag[0])) && isTenhouRecordTile(tag[1:])
+}
+
+func isTenhouRecordTile(rawTile string) bool {
+	tile, err := strconv.Atoi(rawTile)
+	return err == nil && tile >= 0 && tile < 136
+}
+
+//
+
+// 回放天凤牌谱，返回 seat 的每次舍牌决策，以及他家舍牌时可以鸣牌的情况
+// seat: 0-起家 1-南家 2-西家 3-北家（三麻没有座位 3）
+func replayTenhouRecord(record *tenhou.Record, seat int) (reviews discardReviewList, meldReviews meldReviewList, err error) {
+	if seat < 0 || seat > 3 {
+		return nil, nil, fmt.Errorf("参数错误: 座位 %d", seat)
+	}
+
+	d := &tenhouRoundData{isRoundEnd: true}
+	d.roundData = newGame(d)
+	d.skipOutput = true
+
+	c := newTenhouRecordConverter(seat)
+	var (
+		turn         int
+		isReachStep1 bool // 自家立直宣言，下一张舍牌为立直宣言牌
+		isReached    bool // 自家立直后不再有舍牌决策
+
+		pendingMeld *meldReview // 他家舍牌后可以鸣牌，等待自家跳过或鸣牌
+	)
+	resolveMeld := func(isCalled bool) {
+		if pendingMeld != nil {
+			pendingMeld.isCalled = isCalled
+			meldReviews = append(meldReviews, pendingMeld)
+			pendingMeld = nil
+		}
+	}
+	for _, action := range record.Actions {
+		msg := c.convert(action)
+		if msg == nil {
+			continue
+		}
+
+		var review *discardReview
+		switch {
+		case msg.Tag == "INIT":
+			if seat >= c.playerNumber {
+				return nil, nil, fmt.Errorf("参数错误: 三麻没有座位 %d", seat)
+			}
+			turn, isReachStep1, isReached = 0, false, false
+			pendingMeld = nil
+		case msg.Tag == "AGARI", msg.Tag == "RYUUKYOKU":
+			pendingMeld = nil
+		case msg.Tag == "N" && msg.Who != "0":
+			// 他家鸣牌了，自家无法再鸣这张牌
+			pendingMeld = nil
+		case isTenhouRecordDraw(msg.Tag):
+			// 有人摸牌，说明自家跳过了鸣牌
+			resolveMeld(false)
+		case msg.Tag == "REACH" && msg.Who == "0" && msg.Step == "1":
+			isReachStep1 = true
+		case msg.Tag[0] == 'D' && isTenhouRecordTile(msg.Tag[1:]) && !isReached:
+			turn++
+			review = newDiscardReview(d.roundData, turn)
+		}
+
+		d.msg = msg
+		if err := d.analysis(); err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case msg.Tag == "N" && msg.Who == "0" && pendingMeld != nil:
+			// 吃碰记为鸣牌，明杠不在鸣牌判断的范围内
+			melds := d.players[0].melds
+			if meldType := melds[len(melds)-1].MeldType; meldType == meldTypeChi || meldType == meldTypePon {
+				resolveMeld(true)
+			} else {
+				pendingMeld = nil
+			}
+		case isTenhouRecordOthersDiscard(msg.Tag) && !isReached:
+			who := int(util.Lower(msg.Tag[0]) - 'd')
+			tile, isRedFive := d._parseTenhouTile(msg.Tag[1:])
+			pendingMeld = newMeldReview(d.roundData, turn, who, tile, isRedFive)
+		}
+
+		if review != nil {
+			tile, _ := d._parseTenhouTile(msg.Tag[1:])
+			review.setSelfDiscardTile(tile, isReachStep1)
+			reviews = append(reviews, review)
+			if isReachStep1 {
+				isReached = true
+			}
+		}
+	}
+	return
+}
+
+// 读取牌谱文件，支持 gzip 压缩过的 mjlog
+func loadTenhouRecord(filePath string) (*tenhou.Record, error) {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > 2 && data[0] == 0x1f && data[1] == 0x8b {
+		reader, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		if data, err = ioutil.ReadAll(reader); err != nil {
+			return nil, err
+		}
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		// tenhou.net/6 JSON 格式
+		record, err := tenhou.ParseTenhou6JSON(trimmed)
+		if err != nil {
+			return nil, fmt.Errorf("解析牌谱 %s 失败: %v", filePath, err)
+		}
+		return record, nil
+	}
+
+	record := &tenhou.Record{}
+	if err := xml.Unmarshal(data, record); err != nil {
+		return nil, fmt.Errorf("解析牌谱 %s 失败: %v", filePath, err)
+	}
+	return record, nil
+}
+
+// path 为文件或目录，目录下只读取 .xml .mjlog 和 .json 文件
+func tenhouRecordFilePaths(path string) ([]string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !fi.IsDir() {
+		return []string{path}, nil
+	}
+
+	fileInfos, err := ioutil.ReadDir(path)
+	if err != nil {
+		return nil, err
+	}
+	filePaths := []string{}
+	for _, fi := range fileInfos {
+		if fi.IsDir() {
+			continue
+		}
+		if ext := strings.ToLower(filepath.Ext(fi.Name())); ext == ".xml" || ext == ".mjlog" || ext == ".json" {
+			filePaths = append(filePaths, filepath.Join(path, fi.Name()))
+		}
+	}
+	return filePaths, nil
+}
+
+func runTenhouRecordReplay(path string, seat int) error {
+	filePaths, err := tenhouRecordFilePaths(path)
+	if err != nil {
+		return err
+	}
+	if len(filePaths) == 0 {
+		return fmt.Errorf("%s 中没有找到天凤牌谱", path)
+	}
+
+	for _, filePath := range filePaths {
+		record, err := loadTenhouRecord(filePath)
+		if err != nil {
+			return err
+		}
+		reviews, meldReviews, err := replayTenhouRecord(record, seat)
+		if err != nil {
+			return fmt.Errorf("回放牌谱 %s 失败: %v", filePath, err)
+		}
+
+		disagreements := reviews.disagreements()
+		fmt.Printf("%s 座位 %d：共 %d 次舍牌，其中 %d 次与 AI 推荐不一致\n", filePath, seat, len(reviews), len(disagreements))
+		fmt.Println()
+		for _, review := range disagreements {
+			fmt.Println(review)
+			fmt.Println()
+		}
+
+		meldDisagreements := meldReviews.disagreements()
+		fmt.Printf("共 %d 次可以鸣牌，其中 %d 次与 AI 判断不一致\n", len(meldReviews), len(meldDisagreements))
+		fmt.Println()
+		for _, review := range meldDisagreements {
+			fmt.Println(review)
+			fmt.Println()
+		}
+	}
+	return nil
+}
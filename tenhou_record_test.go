@@ -0,0 +1,211 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/platform/tenhou"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/stretchr/testify/assert"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// 生成一局所有人都摸切 turns 巡后流局的牌谱
+func newTestTenhouRecord(t *testing.T, seed int64, turns int) (*tenhou.Record, []int) {
+	tiles := rand.New(rand.NewSource(seed)).Perm(136)
+	hai := func(who int) string {
+		s := []string{}
+		for _, tile := range tiles[13*who : 13*who+13] {
+			s = append(s, fmt.Sprint(tile))
+		}
+		return strings.Join(s, ",")
+	}
+
+	data := fmt.Sprintf(`<mjloggm ver="2.3"><GO type="169" lobby="0"/><TAIKYOKU oya="0"/><INIT seed="0,0,0,2,1,%d" ten="250,250,250,250" oya="0" hai0="%s" hai1="%s" hai2="%s" hai3="%s"/>`,
+		tiles[135], hai(0), hai(1), hai(2), hai(3))
+	drawTiles := []int{}
+	for i := 0; i < turns; i++ {
+		for who := 0; who < 4; who++ {
+			tile := tiles[52+4*i+who]
+			drawTiles = append(drawTiles, tile)
+			data += fmt.Sprintf("<%c%d/><%c%d/>", 'T'+who, tile, 'D'+who, tile)
+		}
+	}
+	data += `<RYUUKYOKU ba="0,0" sc="250,0,250,0,250,0,250,0"/></mjloggm>`
+
+	record := &tenhou.Record{}
+	if err := xml.Unmarshal([]byte(data), record); err != nil {
+		t.Fatal(err)
+	}
+	return record, drawTiles
+}
+
+// 生成一局三麻牌谱：所有人摸切至牌山摸完后流局，摸到北时拔北并从岭上摸牌
+// 返回各家每次舍牌时牌山的剩余牌数，以及各家的拔北数
+func newTestSanmaTenhouRecord(t *testing.T, seed int64) (record *tenhou.Record, leftDrawCounts [][]int, nukiCounts []int) {
+	tiles := []int{}
+	for _, tile := range rand.New(rand.NewSource(seed)).Perm(136) {
+		if !util.IsSanmaAbsentTile(tile / 4) {
+			tiles = append(tiles, tile)
+		}
+	}
+	hai := func(who int) string {
+		s := []string{}
+		for _, tile := range tiles[13*who : 13*who+13] {
+			s = append(s, fmt.Sprint(tile))
+		}
+		return strings.Join(s, ",")
+	}
+
+	// 王牌 14 张，最后一张为宝牌指示牌，岭上牌从王牌的开头依次摸
+	const deadWallStart = 108 - 14
+	data := fmt.Sprintf(`<mjloggm ver="2.3"><GO type="185" lobby="0"/><TAIKYOKU oya="0"/><INIT seed="0,0,0,2,1,%d" ten="350,350,350,0" oya="0" hai0="%s" hai1="%s" hai2="%s" hai3=""/>`,
+		tiles[107], hai(0), hai(1), hai(2))
+	leftDrawCounts = make([][]int, 3)
+	nukiCounts = make([]int, 3)
+	liveWallIndex, deadWallIndex := 39, deadWallStart
+	// 每次拔北后从牌山末尾补充一张王牌
+	leftDrawCount := func() int { return deadWallStart - liveWallIndex - (deadWallIndex - deadWallStart) }
+	for who := 0; leftDrawCount() > 0; who = (who + 1) % 3 {
+		tile := tiles[liveWallIndex]
+		liveWallIndex++
+		data += fmt.Sprintf("<%c%d/>", 'T'+who, tile)
+		for tile/4 == 30 {
+			data += fmt.Sprintf(`<N who="%d" m="%d"/>`, who, tile<<8|0x20)
+			nukiCounts[who]++
+			tile = tiles[deadWallIndex]
+			deadWallIndex++
+			data += fmt.Sprintf("<%c%d/>", 'T'+who, tile)
+		}
+		data += fmt.Sprintf("<%c%d/>", 'D'+who, tile)
+		leftDrawCounts[who] = append(leftDrawCounts[who], leftDrawCount())
+	}
+	data += `<RYUUKYOKU ba="0,0" sc="350,0,350,0,350,0,0,0"/></mjloggm>`
+
+	record = &tenhou.Record{}
+	if err := xml.Unmarshal([]byte(data), record); err != nil {
+		t.Fatal(err)
+	}
+	return
+}
+
+func TestTenhouRecordConverter(t *testing.T) {
+	record, _ := newTestTenhouRecord(t, 1, 1)
+	c := newTenhouRecordConverter(1)
+	tags := []string{}
+	for _, action := range record.Actions {
+		if msg := c.convert(action); msg != nil {
+			tags = append(tags, msg.Tag)
+			if msg.Tag == "INIT" {
+				assert.Equal(t, "3", msg.Dealer)
+				assert.Equal(t, record.Actions[2].Hai1, msg.Hai)
+			}
+		}
+	}
+	// 起家、南家（自家）、西家、北家依次摸切
+	assert.Len(t, tags, 10)
+	assert.Equal(t, "W", tags[1][:1])
+	assert.Equal(t, "g", tags[2][:1])
+	assert.Equal(t, "T", tags[3][:1])
+	assert.Equal(t, "D", tags[4][:1])
+	assert.Equal(t, "e", tags[6][:1])
+	assert.Equal(t, "f", tags[8][:1])
+	assert.Equal(t, "RYUUKYOKU", tags[9])
+}
+
+func TestReplayTenhouRecord(t *testing.T) {
+	const turns = 3
+	record, drawTiles := newTestTenhouRecord(t, 1, turns)
+	reviews, _, err := replayTenhouRecord(record, 2)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, turns)
+	for i, review := range reviews {
+		assert.Equal(t, i+1, review.turn)
+		assert.Equal(t, drawTiles[4*i+2]/4, review.selfDiscardTile)
+		assert.Equal(t, 14, util.CountOfTiles34(review.playerInfo.HandTiles34))
+		assert.NotEqual(t, -1, review.aiAttackDiscardTile)
+	}
+
+	_, _, err = replayTenhouRecord(record, 4)
+	assert.Error(t, err)
+}
+
+// 三麻：起家拔北，南家打出的白被西家碰，之后流局
+const testSanmaTenhou6JSON = `{"title": ["", ""], "name": ["A", "B", "C"], "rule": {"disp": "三般南喰赤", "aka": 1}, "log": [[
+  [0, 0, 0], [34000, 35000, 36000], [47], [],
+  [11, 19, 21, 22, 23, 24, 25, 26, 31, 32, 33, 41, 42], [44, 29, 34, 37], ["f44", 41, 42, 60],
+  [11, 19, 27, 28, 29, 34, 35, 36, 37, 38, 39, 43, 45], [27, 35], [45, 60],
+  [11, 19, 21, 22, 23, 24, 25, 26, 31, 32, 33, 45, 45], ["p454545", 36], [11, 60],
+  ["流局", [0, 0, 0]]
+]]}`
+
+func TestReplayTenhouRecord_sanma(t *testing.T) {
+	assert := assert.New(t)
+
+	record, err := tenhou.ParseTenhou6JSON([]byte(testSanmaTenhou6JSON))
+	if !assert.NoError(err) {
+		return
+	}
+
+	// 三麻按三家轮转相对座位，不存在的一家排在最后
+	c := newTenhouRecordConverter(1)
+	msg := c.convert(record.Actions[0])
+	assert.Equal("350,360,340,0", msg.Ten)
+	assert.Equal("2", msg.Dealer)
+
+	reviews, _, err := replayTenhouRecord(record, 0)
+	assert.NoError(err)
+	if assert.Len(reviews, 3) {
+		playerInfo := reviews[0].playerInfo
+		assert.True(playerInfo.IsSanma)
+		assert.Equal(1, playerInfo.NukiDoraNum)
+		assert.Equal(0, playerInfo.HandTiles34[30])
+		assert.Equal(14, util.CountOfTiles34(playerInfo.HandTiles34))
+		assert.Equal(27, reviews[0].selfDiscardTile)
+		for _, review := range reviews {
+			assert.False(util.IsSanmaAbsentTile(review.aiAttackDiscardTile))
+		}
+	}
+
+	// 西家碰了南家的白
+	reviews, meldReviews, err := replayTenhouRecord(record, 2)
+	assert.NoError(err)
+	assert.Len(reviews, 2)
+	if assert.Len(meldReviews, 1) {
+		assert.Equal(31, meldReviews[0].calledTile)
+		assert.True(meldReviews[0].isCalled)
+	}
+
+	_, _, err = replayTenhouRecord(record, 3)
+	assert.Error(err)
+}
+
+func TestReplayTenhouRecord_sanmaFullRound(t *testing.T) {
+	assert := assert.New(t)
+
+	// 种子 2 的牌谱中，西家在最后一巡拔北，牌山随之减少
+	for seed := int64(1); seed <= 2; seed++ {
+		record, leftDrawCounts, nukiCounts := newTestSanmaTenhouRecord(t, seed)
+		for seat := 0; seat < 3; seat++ {
+			reviews, _, err := replayTenhouRecord(record, seat)
+			if !assert.NoError(err) || !assert.Len(reviews, len(leftDrawCounts[seat])) {
+				continue
+			}
+			for i, review := range reviews {
+				playerInfo := review.playerInfo
+				assert.True(playerInfo.IsSanma)
+				assert.Equal(leftDrawCounts[seat][i], playerInfo.LeftDrawTilesCount)
+				assert.Equal(14, util.CountOfTiles34(playerInfo.HandTiles34))
+				assert.False(util.IsSanmaAbsentTile(review.aiAttackDiscardTile))
+				for tile, left := range playerInfo.LeftTiles34 {
+					assert.True(left >= 0 && left <= 4)
+					if util.IsSanmaAbsentTile(tile) {
+						assert.Zero(left)
+					}
+				}
+			}
+			assert.Equal(nukiCounts[seat], reviews[len(reviews)-1].playerInfo.NukiDoraNum)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 	"github.com/EndlessCheng/mahjong-helper/util"
 	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
 )
 
 func Test_parseTenhouMeld(t *testing.T) {
@@ -88,3 +89,49 @@ func TestReg(t *testing.T) {
 	d.msg.Tag = "E123123"
 	t.Log(d.IsDiscard() == false)
 }
+
+func Test_tenhouLobbyRuleset(t *testing.T) {
+	assert.Equal(t, util.RulesetTenhou, tenhouLobbyRuleset("169")) // 鳳南喰赤
+
+	r := tenhouLobbyRuleset("7") // 般東無赤無喰
+	assert.Equal(t, 0, r.NumRedFives)
+	assert.False(t, r.OpenTanyao)
+}
+
+func Test_tenhouRoundData_ParseStanding(t *testing.T) {
+	assert := assert.New(t)
+
+	d := &tenhouRoundData{isRoundEnd: true}
+	d.roundData = newGame(d)
+	d.skipOutput = true
+
+	// 东风战
+	d.msg = &tenhouMessage{Tag: "GO", Type: "65"}
+	assert.False(d.SkipMessage())
+	assert.True(d.isTonpuu)
+
+	// 东4局1本场，供托2本，上家为庄家
+	d.msg = &tenhouMessage{
+		Tag:    "INIT",
+		Seed:   "3,1,2,3,4,5",
+		Ten:    "220,300,250,230",
+		Dealer: "3",
+		Hai:    "0,4,8,12,16,20,24,28,32,36,40,44,48",
+	}
+	assert.NoError(d.analysis())
+	if !assert.NotNil(d.standing) {
+		return
+	}
+	assert.Equal([]int{22000, 30000, 25000, 23000}, d.standing.Scores)
+	assert.Equal(2, d.standing.RiichiSticks)
+	assert.Equal(1, d.standing.BenNumber)
+	assert.Equal(0, d.standing.FirstDealer)
+	assert.True(d.standing.IsAllLast())
+
+	// 下家立直
+	d.msg = &tenhouMessage{Tag: "REACH", Who: "1", Step: "1"}
+	assert.NoError(d.analysis())
+	assert.Equal(29000, d.standing.Scores[1])
+	assert.Equal(3, d.standing.RiichiSticks)
+	assert.Equal(d.standing, d.newModelPlayerInfo().Standing)
+}
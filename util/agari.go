@@ -62,11 +62,26 @@ func _calcKey(tiles34 []int) (key int) {
 	return
 }
 
-// 3k+2 张牌，是否和牌（不检测国士无双）
+// 3k+2 张牌，是否和牌
 func IsAgari(tiles34 []int) bool {
 	key := _calcKey(tiles34)
-	_, isAgari := winTable[key]
-	return isAgari
+	if _, isAgari := winTable[key]; isAgari {
+		return true
+	}
+	return isKokushiAgari(tiles34)
+}
+
+// 14 张牌，是否为国士无双和牌：13 种幺九牌各一张，其中一种两张
+func isKokushiAgari(tiles34 []int) bool {
+	count := 0
+	for _, tile := range YaochuTiles {
+		c := tiles34[tile]
+		if c == 0 || c > 2 {
+			return false
+		}
+		count += c
+	}
+	return count == 14 && CountOfTiles34(tiles34) == 14
 }
 
 //
@@ -81,6 +96,7 @@ type DivideResult struct {
 	// 所以只能判断如七对子、九莲宝灯、一气通贯、两杯口、一杯口等和「形状」有关的役，
 	// 像国士无双、断幺、全带、三色、绿一色等，和具体的牌/位置有关的役是判断不出的，需要另加逻辑判断
 	IsChiitoi       bool // 七对子
+	IsKokushi       bool // 国士无双（PairTile 为两张的那种幺九牌）
 	IsChuurenPoutou bool // 九莲宝灯
 	IsIttsuu        bool // 一气通贯（注意：未考虑副露！）
 	IsRyanpeikou    bool // 两杯口（IsRyanpeikou == true 时 IsIipeikou == false）
@@ -92,6 +108,9 @@ func (d *DivideResult) String() string {
 	if d.IsChiitoi {
 		return "[七对子]"
 	}
+	if d.IsKokushi {
+		return "[国士无双]"
+	}
 
 	output := ""
 
@@ -120,10 +139,19 @@ func (d *DivideResult) String() string {
 	return output
 }
 
-// 3k+2 张牌，返回所有可能的拆解，没有拆解表示未和牌（不检测国士无双）
+// 3k+2 张牌，返回所有可能的拆解，没有拆解表示未和牌
+// 国士无双单独处理，其拆解结果只有 IsKokushi 和 PairTile
 // http://hp.vector.co.jp/authors/VA046927/mjscore/mjalgorism.html
 // http://hp.vector.co.jp/authors/VA046927/mjscore/AgariIndex.java
 func DivideTiles34(tiles34 []int) (divideResults []*DivideResult) {
+	if isKokushiAgari(tiles34) {
+		for _, tile := range YaochuTiles {
+			if tiles34[tile] == 2 {
+				return []*DivideResult{{PairTile: tile, IsKokushi: true}}
+			}
+		}
+	}
+
 	tiles14 := make([]int, 14)
 	tiles14TailIndex := 0
 
@@ -5,13 +5,32 @@ import (
 	"sort"
 )
 
+// 字牌的和率
+// 国士无双听字牌时剩余枚数可能超出表格范围，此时视作单骑
+// 三麻时使用三麻的和率表
+func honorTileAgariRate(left int, isDanki bool, isSanma bool) float64 {
+	nonDankiTable, dankiTable := honorTileNonDankiAgariTable, honorTileDankiAgariTable
+	if isSanma {
+		nonDankiTable, dankiTable = sanmaHonorTileNonDankiAgariTable, sanmaHonorTileDankiAgariTable
+	}
+	if !isDanki && left < len(nonDankiTable) {
+		return nonDankiTable[left]
+	}
+	return dankiTable[MinInt(left, len(dankiTable)-1)]
+}
+
 // 计算各张待牌的和率
 // 剩余为 0 则和率为 0
 func CalculateAgariRateOfEachTile(waits Waits, playerInfo *model.PlayerInfo) map[int]float64 {
 	if playerInfo == nil {
 		playerInfo = &model.PlayerInfo{}
 	}
+	// 根据自家舍牌，确定各个牌的类型（无筋、半筋、筋、两筋），从而得出不同的和率
+	return calculateAgariRateOfEachTile(waits, playerInfo, calcTileType27(playerInfo.DiscardTiles))
+}
 
+// tileType27: 各个数牌的筋牌类型
+func calculateAgariRateOfEachTile(waits Waits, playerInfo *model.PlayerInfo, tileType27 []tileType) map[int]float64 {
 	tileAgariRate := map[int]float64{}
 
 	// 振听的话和率简化成和枚数相关
@@ -29,8 +48,8 @@ func CalculateAgariRateOfEachTile(waits Waits, playerInfo *model.PlayerInfo) map
 	// 特殊处理字牌单骑的情况
 	if len(waits) == 1 {
 		for tile, left := range waits {
-			if tile >= 27 {
-				rate := honorTileDankiAgariTable[left]
+			if isHonorLikeTile(tile, playerInfo.IsSanma) {
+				rate := honorTileAgariRate(left, true, playerInfo.IsSanma)
 				if InInts(tile, playerInfo.DoraTiles) {
 					// 调整听宝牌时的和率
 					// 忽略 dora 复合的影响
@@ -42,19 +61,22 @@ func CalculateAgariRateOfEachTile(waits Waits, playerInfo *model.PlayerInfo) map
 		}
 	}
 
-	// 根据自家舍牌，确定各个牌的类型（无筋、半筋、筋、两筋），从而得出不同的和率
-	tileType27 := calcTileType27(playerInfo.DiscardTiles)
+	numberAgariMap := agariMap
+	if playerInfo.IsSanma {
+		numberAgariMap = sanmaAgariMap
+	}
 	for tile, left := range waits {
 		var rate float64
-		if tile < 27 { // 数牌
-			rate = agariMap[tileType27[tile]][left]
-		} else { // 字牌，非单骑
-			rate = honorTileNonDankiAgariTable[left]
+		isHonorLike := isHonorLikeTile(tile, playerInfo.IsSanma)
+		if !isHonorLike { // 数牌
+			rate = numberAgariMap[tileType27[tile]][left]
+		} else { // 字牌（或三麻的 1m 9m），非单骑
+			rate = honorTileAgariRate(left, false, playerInfo.IsSanma)
 		}
 		if InInts(tile, playerInfo.DoraTiles) {
 			// 调整听宝牌时的和率
 			// 忽略 dora 复合的影响
-			if tile >= 27 {
+			if isHonorLike {
 				rate *= honorDoraAgariMulti
 			} else {
 				rate *= numberDoraAgariMulti
@@ -71,7 +93,10 @@ func CalculateAvgAgariRate(waits Waits, playerInfo *model.PlayerInfo) float64 {
 	if playerInfo == nil {
 		playerInfo = &model.PlayerInfo{}
 	}
+	return calculateAvgAgariRate(waits, playerInfo, calcTileType27(playerInfo.DiscardTiles))
+}
 
+func calculateAvgAgariRate(waits Waits, playerInfo *model.PlayerInfo, tileType27 []tileType) float64 {
 	// 振听的话和率简化成和枚数相关
 	if playerInfo.IsFuriten(waits) {
 		rate := 0.0
@@ -81,7 +106,7 @@ func CalculateAvgAgariRate(waits Waits, playerInfo *model.PlayerInfo) float64 {
 		return rate
 	}
 
-	tileAgariRate := CalculateAgariRateOfEachTile(waits, playerInfo)
+	tileAgariRate := calculateAgariRateOfEachTile(waits, playerInfo, tileType27)
 	agariRate := 0.0
 	for _, rate := range tileAgariRate {
 		agariRate = agariRate + rate - agariRate*rate/100
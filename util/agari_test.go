@@ -22,12 +22,14 @@ func TestIsAgari(t *testing.T) {
 		"11m 111p 111s",
 		"111m 11p 111s",
 		"111m 111p 11s",
+		"119m 19p 19s 1234567z", // 国士无双
+		"19m 19p 19s 12345677z", // 国士无双
 	} {
 		assert.True(t, IsAgari(MustStrToTiles34(humanTiles)), humanTiles)
 	}
 
 	for _, humanTiles := range []string{
-		"119m 19p 19s 1234567z", // 国士无双自行判断
+		"1199m 19p 19s 123456z",
 		"1133555599m 1122s",
 		"1122m",
 		"8888p",
@@ -41,7 +43,7 @@ func TestIsAgari(t *testing.T) {
 func TestDivideTiles34(t *testing.T) {
 	assert := assert.New(t)
 
-	const otherDivideResult = "国士 or 未和牌"
+	const otherDivideResult = "未和牌"
 	divideTiles := func(humanTiles string) string {
 		drs := DivideTiles34(MustStrToTiles34(humanTiles))
 		if len(drs) == 0 {
@@ -76,7 +78,8 @@ func TestDivideTiles34(t *testing.T) {
 	assert.Equal("[11p 111m 111s]", divideTiles("111m 11p 111s"))
 	assert.Equal("[11s 111m 111p]", divideTiles("111m 111p 11s"))
 
-	assert.Equal(otherDivideResult, divideTiles("119m 19p 19s 1234567z"))
+	assert.Equal("[国士无双]", divideTiles("119m 19p 19s 1234567z"))
+	assert.Equal(27, DivideTiles34(MustStrToTiles34("19m 19p 19s 11234567z"))[0].PairTile)
 
 	assert.Equal(otherDivideResult, divideTiles("4888m 499p 134557s 4z"))
 	assert.Equal(otherDivideResult, divideTiles("1122m"))
@@ -0,0 +1,89 @@
+package util
+
+import "sort"
+
+// 他家的放铳信息
+type OpponentRisk struct {
+	// 1=下家, 2=对家, 3=上家
+	Who int
+
+	// 听牌率 (0-100)
+	TenpaiRate float64
+
+	// 听牌时各种牌的铳率 (0-100)，未根据打点修正
+	RiskTiles34 RiskTiles34
+
+	// 放铳时的失点
+	RonPoint float64
+}
+
+type OpponentRiskList []*OpponentRisk
+
+// 切这张牌的综合放铳率 (0-100)
+func (l OpponentRiskList) DealInRate(tile int) float64 {
+	rate := 0.0
+	for _, o := range l {
+		_rate := o.RiskTiles34[tile] * o.TenpaiRate / 100
+		rate = rate + _rate - rate*_rate/100
+	}
+	return rate
+}
+
+// 切这张牌的放铳失点期望
+func (l OpponentRiskList) DealInLoss(tile int) float64 {
+	loss := 0.0
+	for _, o := range l {
+		loss += o.TenpaiRate / 100 * o.RiskTiles34[tile] / 100 * o.RonPoint
+	}
+	return loss
+}
+
+// 未听牌时各向听数的和率（经验值），下标为向听数
+var shantenAgariRates = []float64{0, 0.3, 0.15, 0.05}
+
+// 用于计算期望收支的局收支
+// 两向听及以上时没有计算局收支，用向听数对应的和率和宝牌数对应的打点粗略估计
+func (r *Hand13AnalysisResult) evRoundPoint() float64 {
+	if r.Shanten < 2 {
+		return r.MixedRoundPoint
+	}
+	agariRate := 0.0
+	if r.Shanten < len(shantenAgariRates) {
+		agariRate = shantenAgariRates[r.Shanten]
+	}
+	winPoint := RonPointOtherNakiWithDora(r.DoraCount)
+	if !r.IsNaki {
+		// 门清按立直计算
+		winPoint *= RonPointRiichiHiIppatsu / RonPointOtherNaki
+	}
+	const weight = -1500
+	return agariRate*(winPoint+1500) + weight
+}
+
+// 切牌的期望收支 = 不放铳的概率 * 局收支 - 放铳失点期望
+func (l OpponentRiskList) DiscardEV(result14 *Hand14AnalysisResult) float64 {
+	tile := result14.DiscardTile
+	return (1-l.DealInRate(tile)/100)*result14.Result13.evRoundPoint() - l.DealInLoss(tile)
+}
+
+// 计算各个切牌的期望收支，并按照期望收支从高到低排序
+// 没有威胁时不改变原有顺序
+func (l Hand14AnalysisResultList) SortByEV(opponents OpponentRiskList) {
+	for _, r := range l {
+		r.EV = opponents.DiscardEV(r)
+	}
+	if len(opponents) == 0 {
+		return
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].EV > l[j].EV
+	})
+}
+
+// 将向听不变和向听倒退的切牌合在一起，按照期望收支从高到低排序
+// 没有威胁时向听不变的切牌在前，各自保持原有顺序
+func (l Hand14AnalysisResultList) MergeByEV(incShantenResults14 Hand14AnalysisResultList, opponents OpponentRiskList) Hand14AnalysisResultList {
+	merged := append(append(Hand14AnalysisResultList{}, l...), incShantenResults14...)
+	merged.SortByEV(opponents)
+	return merged
+}
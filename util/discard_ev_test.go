@@ -0,0 +1,74 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+// 测试用的切牌结果，只有向听数和局收支
+func newTestResult14(discardTile int, shanten int, mixedRoundPoint float64) *Hand14AnalysisResult {
+	return &Hand14AnalysisResult{
+		DiscardTile: discardTile,
+		Result13:    &Hand13AnalysisResult{Shanten: shanten, MixedRoundPoint: mixedRoundPoint},
+	}
+}
+
+// 测试用的他家，risks[i] 为 i 的铳率，其余牌为现物
+func newTestOpponent(who int, tenpaiRate float64, ronPoint float64, risks ...float64) *OpponentRisk {
+	risk34 := make(RiskTiles34, 34)
+	copy(risk34, risks)
+	return &OpponentRisk{Who: who, TenpaiRate: tenpaiRate, RiskTiles34: risk34, RonPoint: ronPoint}
+}
+
+func TestOpponentRiskList_DealInRate(t *testing.T) {
+	opponents := OpponentRiskList{
+		newTestOpponent(1, 100, 8000, 10, 20),
+		newTestOpponent(3, 50, 4000, 10, 20),
+	}
+
+	// 1 - 0.9*0.95
+	assert.InDelta(t, 14.5, opponents.DealInRate(0), 1e-9)
+	assert.InDelta(t, 0.1*8000+0.05*4000, opponents.DealInLoss(0), 1e-9)
+	assert.Equal(t, 0.0, opponents.DealInRate(2))
+	assert.Equal(t, 0.0, opponents.DealInLoss(2))
+}
+
+func TestHand14AnalysisResultList_SortByEV(t *testing.T) {
+	// 切 1m 局收支最高但是危险，切 3m 为现物
+	newList := func() Hand14AnalysisResultList {
+		return Hand14AnalysisResultList{newTestResult14(0, 0, 3000), newTestResult14(1, 0, 1000), newTestResult14(2, 0, 500)}
+	}
+
+	// 没有威胁时不改变顺序
+	l := newList()
+	l.SortByEV(nil)
+	assert.Equal(t, []int{0, 1, 2}, []int{l[0].DiscardTile, l[1].DiscardTile, l[2].DiscardTile})
+	assert.Equal(t, 3000.0, l[0].EV)
+
+	l = newList()
+	l.SortByEV(OpponentRiskList{newTestOpponent(3, 100, RonPointRiichiIppatsu, 40, 20)})
+	assert.Equal(t, []int{2, 1, 0}, []int{l[0].DiscardTile, l[1].DiscardTile, l[2].DiscardTile})
+	assert.InDelta(t, 0.6*3000-0.4*RonPointRiichiIppatsu, l[2].EV, 1e-9)
+	assert.Equal(t, 500.0, l[0].EV)
+}
+
+func TestHand14AnalysisResultList_MergeByEV(t *testing.T) {
+	// 两向听，切 1m 危险，切 2m 和向听倒退的 3m 为现物
+	results14 := Hand14AnalysisResultList{newTestResult14(0, 2, 0), newTestResult14(1, 2, 0)}
+	incShantenResults14 := Hand14AnalysisResultList{newTestResult14(2, 3, 0)}
+
+	// 没有威胁时不改变顺序
+	l := results14.MergeByEV(incShantenResults14, nil)
+	assert.Equal(t, []int{0, 1, 2}, []int{l[0].DiscardTile, l[1].DiscardTile, l[2].DiscardTile})
+
+	// 两向听时根据和率和打点估计局收支，不会为了安全而向听倒退
+	l = results14.MergeByEV(incShantenResults14, OpponentRiskList{newTestOpponent(3, 100, RonPointRiichiIppatsu, 40)})
+	assert.Equal(t, []int{1, 2, 0}, []int{l[0].DiscardTile, l[1].DiscardTile, l[2].DiscardTile})
+	assert.InDelta(t, 0.15*(RonPointRiichiHiIppatsu+1500)-1500, l[0].EV, 1e-9)
+	assert.True(t, l[0].EV > l[1].EV)
+
+	// 宝牌多时打点更高
+	doraResult := newTestResult14(3, 2, 0)
+	doraResult.Result13.DoraCount = 2
+	assert.True(t, doraResult.Result13.evRoundPoint() > results14[0].Result13.evRoundPoint())
+}
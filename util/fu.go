@@ -8,119 +8,137 @@ func roundUpFu(fu int) int {
 	return ((fu-1)/10 + 1) * 10
 }
 
+// 符数明细中的一项
+type FuItem struct {
+	Name string
+	Fu   int
+}
+
 // 根据手牌拆解结果，结合场况计算符数
 func (hi *_handInfo) calcFu(isNaki bool) int {
+	return hi.calcFuWithItems(isNaki, nil)
+}
+
+// 计算符数，items 不为 nil 时记录符数明细（进位前）
+func (hi *_handInfo) calcFuWithItems(isNaki bool, items *[]FuItem) int {
 	divideResult := hi.divideResult
 
+	fu := 0
+	addFu := func(name string, _fu int) {
+		fu += _fu
+		if items != nil {
+			*items = append(*items, FuItem{name, _fu})
+		}
+	}
+
 	// 特殊：七对子计 25 符
 	if divideResult.IsChiitoi {
-		return 25
+		addFu("七对子", 25)
+		return fu
 	}
 
 	const baseFu = 20
 
 	// 符底 20 符
-	fu := baseFu
+	addFu("符底", baseFu)
 
 	// 暗刻加符
 	_, ronKotsu := hi.numAnkou()
 	for _, tile := range divideResult.KotsuTiles {
 		var _fu int
+		var name string
 		// 荣和刻子算明刻
 		if ronKotsu && tile == hi.WinTile {
 			_fu = 2
+			name = "明刻（荣和）"
 		} else {
 			_fu = 4
+			name = "暗刻"
 		}
 		if isYaochupai(tile) {
 			_fu *= 2
 		}
-		fu += _fu
+		addFu(name+" "+TilesToStr([]int{tile, tile, tile}), _fu)
 	}
 
 	// 明刻、明杠、暗杠加符
 	for _, meld := range hi.Melds {
 		_fu := 0
+		var name string
 		switch meld.MeldType {
 		case model.MeldTypePon:
 			_fu = 2
+			name = "明刻"
 		case model.MeldTypeMinkan, model.MeldTypeKakan:
 			_fu = 8
+			name = "明杠"
 		case model.MeldTypeAnkan:
 			_fu = 16
+			name = "暗杠"
 		}
 		if _fu > 0 {
 			if isYaochupai(meld.Tiles[0]) {
 				_fu *= 2
 			}
-			fu += _fu
+			addFu(name+" "+TilesToStr(meld.Tiles), _fu)
 		}
 	}
 
 	// 雀头加符（连风雀头计 4 符）
 	if hi.isYakuTile(divideResult.PairTile) {
-		fu += 2
+		_fu := 2
 		if hi.isDoubleWindTile(divideResult.PairTile) {
-			fu += 2
+			_fu += 2
 		}
+		addFu("雀头 "+TilesToStr([]int{divideResult.PairTile, divideResult.PairTile}), _fu)
 	}
 
+	// 手牌全是顺子，且雀头不是役牌
+	isPinfuShape := false
 	if fu == baseFu {
-		// 手牌全是顺子，且雀头不是役牌
 		if isNaki {
 			// 无论怎样都不可能超过 30 符，直接返回
-			return 30
+			addFu("副露平和型", 10)
+			return fu
 		}
 		// 门清状态下需要检测能否平和
 		// 若没有平和则一定是坎张、边张、单骑和牌
-		isPinfu := false
 		for _, tile := range divideResult.ShuntsuFirstTiles {
 			t9 := tile % 9
 			if t9 < 6 && tile == hi.WinTile || t9 > 0 && tile+2 == hi.WinTile {
-				isPinfu = true
+				isPinfuShape = true
 				break
 			}
 		}
-		if hi.IsTsumo {
-			if isPinfu {
-				// 门清自摸平和 20 符
-				return 20
-			}
-			// 坎张、边张、单骑自摸，30 符
-			return 30
-		} else {
-			// 荣和
-			if isPinfu {
-				// 门清平和荣和 30 符
-				return 30
-			}
-			// 坎张、边张、单骑荣和，40 符
-			return 40
-		}
 	}
 
 	// 门清荣和加符
 	if !isNaki && !hi.IsTsumo {
-		fu += 10
+		addFu("门清荣和", 10)
+	}
+
+	// 门清自摸平和 20 符，门清平和荣和 30 符
+	if isPinfuShape {
+		return fu
 	}
 
 	// 自摸加符
 	if hi.IsTsumo {
-		fu += 2
+		addFu("自摸", 2)
 	}
 
 	// 边张、坎张、单骑和牌加符
 	// 考虑能否不为两面和牌
 	if divideResult.PairTile == hi.WinTile {
-		fu += 2 // 单骑和牌加符
+		addFu("单骑", 2)
 	} else {
 		for _, tile := range divideResult.ShuntsuFirstTiles {
 			if tile+1 == hi.WinTile {
-				fu += 2 // 坎张和牌加符
+				addFu("坎张", 2)
 				break
 			}
 			if tile%9 == 0 && tile+2 == hi.WinTile || tile%9 == 6 && tile == hi.WinTile {
-				fu += 2 // 边张和牌加符
+				addFu("边张", 2)
 				break
 			}
 		}
@@ -0,0 +1,365 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+	"strings"
+)
+
+// 读牌：根据他家的舍牌（手切/摸切）、副露和立直时机，推测其听牌形和可能的役
+// 推测结果用来修正该玩家的危险度
+
+// 听牌形
+const (
+	WaitTypeRyanmen = iota // 两面
+	WaitTypeKanchan        // 坎张、边张
+	WaitTypeShanpon        // 对碰
+	WaitTypeTanki          // 单骑
+	waitTypeCount
+)
+
+var WaitTypeNames = []string{"两面", "坎边张", "对碰", "单骑"}
+
+type waitTypeRates [waitTypeCount]float64
+
+// 听牌形的基础分布（参考：科学する麻雀）
+var baseWaitTypeRates = waitTypeRates{0.6, 0.2, 0.12, 0.08}
+
+// 对对和只能对碰或单骑
+var toitoiWaitTypeRates = waitTypeRates{0, 0, 0.6, 0.4}
+
+// 各种牌放铳时的听牌形占比，[牌的位置][听牌形]
+// 19 - 两面 对碰单骑
+// 28 - 两面 坎张 对碰单骑
+// 37 - 两面 坎张 边张 对碰单骑
+// 456- 两面x2 坎张 对碰单骑
+// 字牌 - 对碰单骑
+var tileWaitTypeWeights = [...]waitTypeRates{
+	{0.55, 0, 0.25, 0.2},     // 19
+	{0.55, 0.15, 0.17, 0.13}, // 28
+	{0.5, 0.25, 0.14, 0.11},  // 37
+	{0.65, 0.15, 0.11, 0.09}, // 456
+	{0, 0, 0.55, 0.45},       // 字牌
+}
+
+func tileWaitTypeWeightsIndex(tile int) int {
+	if tile >= 27 {
+		return 4
+	}
+	switch tile%9 + 1 {
+	case 1, 9:
+		return 0
+	case 2, 8:
+		return 1
+	case 3, 7:
+		return 2
+	default:
+		return 3
+	}
+}
+
+const (
+	// 宣言牌为手切的中张时，宣言牌的筋牌和同色牌的危险度倍率
+	reachTileSujiRiskMulti     = 1.5
+	reachTileSameSuitRiskMulti = 1.2
+
+	// 早巡以后手切的牌视作有意义的舍牌
+	handReadingEarlyTurns = 6
+)
+
+// 读牌所需的他家信息
+type HandReadingInput struct {
+	// 舍牌，摸切为负数（^tile）
+	DiscardTiles []int
+
+	Melds []*model.Meld
+
+	// 鸣牌时切的牌在 DiscardTiles 中的下标
+	MeldDiscardsAt []int
+
+	// 立直宣言牌在 DiscardTiles 中的下标，未立直时为 -1
+	ReachTileAt int
+
+	RoundWindTile int
+	SelfWindTile  int
+}
+
+// 读牌的结果
+type HandReading struct {
+	// 听牌形的概率分布 (0-1)，总和为 1
+	WaitTypeRates waitTypeRates
+
+	// 染手的花色 (0=m 1=p 2=s)，没有染手倾向时为 -1
+	HonitsuSuit int
+	// 染手的概率 (0-1)
+	HonitsuRate float64
+
+	// 对对和的概率 (0-1)
+	ToitoiRate float64
+
+	// 是否已碰出役牌
+	HasYakuhai bool
+	// 没有碰出役牌时，手中有役牌对子的概率 (0-1)
+	// 已经切过的役牌（见 discardedYakuhai）不会是对子
+	YakuhaiPairRate float64
+
+	// 手切的立直宣言牌，没有时为 -1
+	ReachTile int
+
+	roundWindTile int
+	selfWindTile  int
+
+	// 切过的役牌，下标为牌
+	discardedYakuhai [34]bool
+}
+
+func (r *HandReading) isYakuhai(tile int) bool {
+	return tile == r.roundWindTile || tile == r.selfWindTile || tile >= 31
+}
+
+// 推测染手：副露都是同一花色（或字牌），且很少切该花色的牌
+// 晚巡或副露后手切该花色的牌，说明不是染该花色
+func (r *HandReading) readHonitsu(in HandReadingInput) {
+	meldCount := [3]int{}
+	for _, meld := range in.Melds {
+		if tile := meld.Tiles[0]; tile < 27 {
+			meldCount[tile/9]++
+		}
+	}
+	firstMeldDiscardAt := len(in.DiscardTiles)
+	if len(in.MeldDiscardsAt) > 0 {
+		firstMeldDiscardAt = in.MeldDiscardsAt[0]
+	}
+	discardCount := [3]int{}
+	lateTedashi := [3]bool{}
+	numberDiscards := 0
+	for i, tile := range in.DiscardTiles {
+		isTedashi := tile >= 0
+		if !isTedashi {
+			tile = ^tile
+		}
+		if tile >= 27 {
+			continue
+		}
+		numberDiscards++
+		discardCount[tile/9]++
+		if isTedashi && (i >= handReadingEarlyTurns || i >= firstMeldDiscardAt) {
+			lateTedashi[tile/9] = true
+		}
+	}
+
+	r.HonitsuSuit = -1
+	for suit := 0; suit < 3; suit++ {
+		if meldCount[suit] < len(in.Melds)-countHonorMelds(in.Melds) {
+			// 有其他花色的副露
+			continue
+		}
+		rate := 0.0
+		if meldCount[suit] > 0 {
+			rate = math.Min(0.8, 0.4+0.2*float64(meldCount[suit]-1))
+		} else if numberDiscards >= handReadingEarlyTurns && discardCount[suit] == 0 {
+			// 没有该花色的副露，但一直没有切该花色的牌
+			rate = 0.3
+		}
+		if numberDiscards > 0 {
+			rate *= math.Max(0, 1-3*float64(discardCount[suit])/float64(numberDiscards))
+		}
+		if lateTedashi[suit] {
+			rate *= 0.3
+		}
+		if rate > r.HonitsuRate {
+			r.HonitsuSuit = suit
+			r.HonitsuRate = rate
+		}
+	}
+}
+
+func countHonorMelds(melds []*model.Meld) (cnt int) {
+	for _, meld := range melds {
+		if meld.Tiles[0] >= 27 {
+			cnt++
+		}
+	}
+	return
+}
+
+// 推测对对和：没有吃，且有两组以上的刻子（杠子）
+func (r *HandReading) readToitoi(in HandReadingInput) {
+	ponCount := 0
+	for _, meld := range in.Melds {
+		if meld.MeldType == model.MeldTypeChi {
+			return
+		}
+		ponCount++
+	}
+	if ponCount >= 2 {
+		r.ToitoiRate = math.Min(1, 0.35+0.25*float64(ponCount-2))
+	}
+}
+
+// 推测役牌：碰出了役牌，或者副露后没有其他役时手中可能有役牌对子
+func (r *HandReading) readYakuhai(in HandReadingInput) {
+	// 手切或摸切役牌，说明手中没有该役牌的对子
+	discardedYakuhaiCount := 0
+	for _, tile := range in.DiscardTiles {
+		if tile < 0 {
+			tile = ^tile
+		}
+		if tile >= 27 && r.isYakuhai(tile) && !r.discardedYakuhai[tile] {
+			r.discardedYakuhai[tile] = true
+			discardedYakuhaiCount++
+		}
+	}
+
+	isNaki := false
+	isTanyao := true
+	for _, meld := range in.Melds {
+		if meld.MeldType != model.MeldTypeAnkan {
+			isNaki = true
+		}
+		if r.isYakuhai(meld.Tiles[0]) {
+			r.HasYakuhai = true
+		}
+		for _, tile := range meld.Tiles {
+			if InInts(tile, YaochuTiles[:]) {
+				isTanyao = false
+			}
+		}
+	}
+	if r.HasYakuhai || !isNaki {
+		return
+	}
+	// 副露后的役主要是役牌、断幺、染手和对对
+	otherYakuRate := math.Max(r.HonitsuRate, r.ToitoiRate)
+	if isTanyao {
+		otherYakuRate = math.Max(otherYakuRate, 0.6)
+	}
+	// 每切过一种役牌，有役牌对子的可能性就降低一些，但仍有可能有其他役牌
+	r.YakuhaiPairRate = (1 - otherYakuRate) * math.Pow(0.8, float64(discardedYakuhaiCount))
+}
+
+// 推测听牌形
+func (r *HandReading) readWaitType(in HandReadingInput) {
+	r.WaitTypeRates = baseWaitTypeRates
+	if r.ToitoiRate > 0 {
+		for i := range r.WaitTypeRates {
+			r.WaitTypeRates[i] = (1-r.ToitoiRate)*r.WaitTypeRates[i] + r.ToitoiRate*toitoiWaitTypeRates[i]
+		}
+	}
+	if r.YakuhaiPairRate > 0 {
+		// 有役牌对子时多了对碰的可能
+		r.WaitTypeRates[WaitTypeShanpon] *= 1 + r.YakuhaiPairRate/2
+	}
+
+	if in.ReachTileAt >= 0 && in.ReachTileAt < len(in.DiscardTiles) {
+		if tile := in.DiscardTiles[in.ReachTileAt]; tile >= 0 {
+			r.ReachTile = tile
+			if tile >= 27 {
+				// 手切字牌立直，多为字牌与搭子的取舍，愚形的可能性稍高
+				r.WaitTypeRates[WaitTypeKanchan] *= 1.2
+				r.WaitTypeRates[WaitTypeTanki] *= 1.2
+			}
+		} else if in.ReachTileAt < handReadingEarlyTurns {
+			// 早巡摸切立直，多为好形
+			r.WaitTypeRates[WaitTypeRyanmen] *= 1.2
+		}
+	}
+
+	sum := 0.0
+	for _, rate := range r.WaitTypeRates {
+		sum += rate
+	}
+	for i := range r.WaitTypeRates {
+		r.WaitTypeRates[i] /= sum
+	}
+}
+
+// 根据他家的舍牌、副露和立直宣言牌进行读牌
+func ReadHand(in HandReadingInput) *HandReading {
+	r := &HandReading{
+		HonitsuSuit:   -1,
+		ReachTile:     -1,
+		roundWindTile: in.RoundWindTile,
+		selfWindTile:  in.SelfWindTile,
+	}
+	r.readHonitsu(in)
+	r.readToitoi(in)
+	r.readYakuhai(in)
+	r.readWaitType(in)
+	return r
+}
+
+// 牌在听牌形分布下的危险度倍率，与基础分布相同时为 1
+func (r *HandReading) waitTypeRiskMulti(tile int) float64 {
+	multi := 0.0
+	for i, weight := range tileWaitTypeWeights[tileWaitTypeWeightsIndex(tile)] {
+		multi += weight * r.WaitTypeRates[i] / baseWaitTypeRates[i]
+	}
+	return multi
+}
+
+// 根据读牌结果对危险度进行修正
+func (l RiskTiles34) FixWithHandReading(r *HandReading) RiskTiles34 {
+	if r == nil {
+		return l
+	}
+	for tile := range l {
+		if l[tile] == 0 {
+			continue
+		}
+
+		l[tile] *= r.waitTypeRiskMulti(tile)
+
+		// 染手时，该花色和字牌更危险，其余花色更安全
+		if r.HonitsuSuit != -1 {
+			if tile >= 27 || tile/9 == r.HonitsuSuit {
+				l[tile] *= 1 + r.HonitsuRate
+			} else {
+				l[tile] *= 1 - r.HonitsuRate
+			}
+		}
+
+		// 可能有役牌对子，切过的役牌除外
+		if tile >= 27 && r.isYakuhai(tile) && !r.discardedYakuhai[tile] {
+			l[tile] *= 1 + r.YakuhaiPairRate
+		}
+	}
+
+	// 宣言牌为手切的中张时，其筋牌和同色牌更危险
+	if r.ReachTile != -1 && r.ReachTile < 27 {
+		if t9 := r.ReachTile % 9; t9 > 0 && t9 < 8 {
+			suit := r.ReachTile / 9
+			for tile := 9 * suit; tile < 9*suit+9; tile++ {
+				if d := tile - r.ReachTile; d == 3 || d == -3 {
+					l[tile] *= reachTileSujiRiskMulti
+				} else if d != 0 {
+					l[tile] *= reachTileSameSuitRiskMulti
+				}
+			}
+		}
+	}
+
+	return l
+}
+
+var suitNames = []string{"万子", "饼子", "索子"}
+
+// 只显示较为明显的读牌结果，没有时返回空串
+func (r *HandReading) String() string {
+	const minShownRate = 0.3
+	var infos []string
+	if r.HonitsuSuit != -1 && r.HonitsuRate >= minShownRate {
+		infos = append(infos, fmt.Sprintf("染手(%s)%.0f%%", suitNames[r.HonitsuSuit], r.HonitsuRate*100))
+	}
+	if r.ToitoiRate >= minShownRate {
+		infos = append(infos, fmt.Sprintf("对对%.0f%%", r.ToitoiRate*100))
+	}
+	if r.YakuhaiPairRate >= minShownRate {
+		infos = append(infos, fmt.Sprintf("役牌对子%.0f%%", r.YakuhaiPairRate*100))
+	}
+	if diff := r.WaitTypeRates[WaitTypeRyanmen] - baseWaitTypeRates[WaitTypeRyanmen]; diff >= 0.1 || diff <= -0.1 {
+		infos = append(infos, fmt.Sprintf("两面%.0f%%", r.WaitTypeRates[WaitTypeRyanmen]*100))
+	}
+	return strings.Join(infos, " ")
+}
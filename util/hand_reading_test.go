@@ -0,0 +1,102 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestReadHand(t *testing.T) {
+	assert := assert.New(t)
+
+	newRisk34 := func() RiskTiles34 {
+		risk34 := make(RiskTiles34, 34)
+		for i := range risk34 {
+			risk34[i] = 10
+		}
+		return risk34
+	}
+
+	// 没有信息时，听牌形为基础分布，危险度不变
+	r := ReadHand(HandReadingInput{DiscardTiles: []int{27, 33, 0}, ReachTileAt: -1, RoundWindTile: 27, SelfWindTile: 28})
+	assert.Equal(-1, r.HonitsuSuit)
+	assert.Equal(-1, r.ReachTile)
+	for i, rate := range r.WaitTypeRates {
+		assert.InDelta(baseWaitTypeRates[i], rate, 1e-9)
+	}
+	risk34 := newRisk34().FixWithHandReading(r)
+	for _, risk := range risk34 {
+		assert.InDelta(10, risk, 1e-9)
+	}
+	assert.Empty(r.String())
+
+	// 索子染手：副露都是索子，且没有切索子
+	chi := &model.Meld{MeldType: model.MeldTypeChi, Tiles: []int{19, 20, 21}}
+	pon := &model.Meld{MeldType: model.MeldTypePon, Tiles: []int{24, 24, 24}}
+	discards := []int{0, 9, ^10, 4, 13, ^5, 14}
+	r = ReadHand(HandReadingInput{DiscardTiles: discards, Melds: []*model.Meld{chi, pon}, ReachTileAt: -1, RoundWindTile: 27, SelfWindTile: 28})
+	assert.Equal(2, r.HonitsuSuit)
+	assert.InDelta(0.6, r.HonitsuRate, 1e-9)
+	assert.Zero(r.ToitoiRate)
+	risk34 = newRisk34().FixWithHandReading(r)
+	assert.True(risk34[22] > 10)
+	assert.True(risk34[4] < 10)
+	assert.Contains(r.String(), "染手(索子)60%")
+
+	// 晚巡手切索子，染手的可能性降低
+	r = ReadHand(HandReadingInput{DiscardTiles: append(discards, 18), Melds: []*model.Meld{chi, pon}, ReachTileAt: -1})
+	assert.True(r.HonitsuRate < 0.2)
+
+	// 早巡手切索子，但是在副露之后，染手的可能性同样降低
+	earlyDiscards := []int{0, 9, 18, 4, 13, ^5}
+	r = ReadHand(HandReadingInput{DiscardTiles: earlyDiscards, Melds: []*model.Meld{chi, pon}, ReachTileAt: -1})
+	assert.InDelta(0.3, r.HonitsuRate, 1e-9)
+	r = ReadHand(HandReadingInput{DiscardTiles: earlyDiscards, Melds: []*model.Meld{chi, pon}, MeldDiscardsAt: []int{2, 4}, ReachTileAt: -1})
+	assert.InDelta(0.09, r.HonitsuRate, 1e-9)
+
+	// 对对和：两组碰，对碰单骑的概率上升
+	pon2 := &model.Meld{MeldType: model.MeldTypePon, Tiles: []int{2, 2, 2}}
+	r = ReadHand(HandReadingInput{DiscardTiles: discards, Melds: []*model.Meld{pon, pon2}, ReachTileAt: -1, RoundWindTile: 27, SelfWindTile: 28})
+	assert.InDelta(0.35, r.ToitoiRate, 1e-9)
+	assert.True(r.WaitTypeRates[WaitTypeRyanmen] < baseWaitTypeRates[WaitTypeRyanmen])
+	assert.True(r.WaitTypeRates[WaitTypeShanpon] > baseWaitTypeRates[WaitTypeShanpon])
+	risk34 = newRisk34().FixWithHandReading(r)
+	assert.True(risk34[29] > 10)
+	assert.True(risk34[13] < 10)
+
+	// 役牌：碰出中
+	yakuhaiPon := &model.Meld{MeldType: model.MeldTypePon, Tiles: []int{33, 33, 33}}
+	r = ReadHand(HandReadingInput{DiscardTiles: discards, Melds: []*model.Meld{yakuhaiPon}, ReachTileAt: -1, RoundWindTile: 27, SelfWindTile: 28})
+	assert.True(r.HasYakuhai)
+	assert.Zero(r.YakuhaiPairRate)
+
+	// 吃了幺九牌，没有碰出役牌时可能有役牌对子
+	chi123 := &model.Meld{MeldType: model.MeldTypeChi, Tiles: []int{9, 10, 11}}
+	r = ReadHand(HandReadingInput{DiscardTiles: []int{0, 18, 8}, Melds: []*model.Meld{chi123}, ReachTileAt: -1, RoundWindTile: 27, SelfWindTile: 28})
+	assert.False(r.HasYakuhai)
+	assert.True(r.YakuhaiPairRate > 0.5)
+	risk34 = newRisk34().FixWithHandReading(r)
+	assert.True(risk34[31] > risk34[29])
+	yakuhaiPairRate := r.YakuhaiPairRate
+
+	// 切过白，白不会是对子；同一种役牌切多次只降低一次
+	for _, discards := range [][]int{{0, 18, 8, 31}, {0, 18, 8, 31, ^31}} {
+		r = ReadHand(HandReadingInput{DiscardTiles: discards, Melds: []*model.Meld{chi123}, ReachTileAt: -1, RoundWindTile: 27, SelfWindTile: 28})
+		assert.InDelta(yakuhaiPairRate*0.8, r.YakuhaiPairRate, 1e-9)
+		risk34 = newRisk34().FixWithHandReading(r)
+		assert.InDelta(risk34[29], risk34[31], 1e-9)
+		assert.True(risk34[32] > risk34[29])
+	}
+
+	// 手切 5m 立直，宣言牌的筋牌和同色牌更危险
+	r = ReadHand(HandReadingInput{DiscardTiles: []int{27, 33, ^18, 4}, ReachTileAt: 3, RoundWindTile: 27, SelfWindTile: 28})
+	assert.Equal(4, r.ReachTile)
+	risk34 = newRisk34().FixWithHandReading(r)
+	assert.InDelta(risk34[10]*reachTileSujiRiskMulti, risk34[1], 1e-9)
+	assert.InDelta(risk34[12]*reachTileSameSuitRiskMulti, risk34[3], 1e-9)
+
+	// 摸切立直没有宣言牌
+	r = ReadHand(HandReadingInput{DiscardTiles: []int{27, 33, ^4}, ReachTileAt: 2})
+	assert.Equal(-1, r.ReachTile)
+	assert.True(r.WaitTypeRates[WaitTypeRyanmen] > baseWaitTypeRates[WaitTypeRyanmen])
+}
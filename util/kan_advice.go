@@ -0,0 +1,322 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+)
+
+// 杠的判断
+const (
+	KanVerdictKan  = iota // 开杠
+	KanVerdictSkip        // 不杠
+)
+
+var KanVerdictNames = []string{"开杠", "不杠"}
+
+const (
+	// 一枚宝牌带来的打点增加（经验值）
+	kanDoraPointValue = 1000.0
+
+	// 他家没有听牌迹象时的和率（经验值）
+	kanOpponentBaseWinRate = 0.2
+
+	// 他家和牌时，自家平均支付的比例（经验值）
+	kanOpponentPayShare = 0.4
+
+	// 他家听牌率为 100% 时，杠后手牌减少带来的防守损失
+	kanDefenceLossPoint = 1000.0
+
+	// 杠后向听倒退的额外损失
+	kanShantenBackLossPoint = 2000.0
+
+	// 期望收支之差达到该值时置信度为 100%
+	kanFullConfidencePoint = 2000.0
+)
+
+type KanAdvice struct {
+	// 杠的副露，MeldType 为暗杠、加杠或大明杠
+	Meld model.Meld
+
+	Verdict int
+
+	// 置信度 (0.5-1)
+	Confidence float64
+
+	// 不杠和杠后的手牌分析结果（均为 3k+1 张，不杠时为最佳何切）
+	SkipResult13 *Hand13AnalysisResult
+	KanResult13  *Hand13AnalysisResult
+
+	// 新宝牌指示牌带来的期望宝牌数：自家、他家每人
+	SelfKanDora     float64
+	OpponentKanDora float64
+
+	// 岭上开花的概率 (0-100)，杠后听牌时才有
+	RinshanRate float64
+
+	// 加杠被抢杠的概率 (0-100)
+	ChankanRate float64
+
+	// 杠与不杠的期望收支之差
+	EVDiff float64
+
+	// 判断依据
+	Reasons []string
+}
+
+func (a *KanAdvice) String() string {
+	return fmt.Sprintf("%s（置信度%.0f%%）", KanVerdictNames[a.Verdict], a.Confidence*100)
+}
+
+// 可以进行的杠
+// calledTile 为 -1 时表示自家摸牌后（3k+2 张），返回暗杠和加杠；否则为他家舍牌（3k+1 张），返回大明杠
+// 立直后不能加杠和大明杠
+func KanCandidates(playerInfo *model.PlayerInfo, calledTile int, isRedFive bool) (melds []model.Meld) {
+	tiles34 := playerInfo.HandTiles34
+	if calledTile != -1 {
+		if !playerInfo.IsRiichi && tiles34[calledTile] == 3 {
+			melds = append(melds, model.Meld{
+				MeldType:          model.MeldTypeMinkan,
+				Tiles:             []int{calledTile, calledTile, calledTile, calledTile},
+				SelfTiles:         []int{calledTile, calledTile, calledTile},
+				CalledTile:        calledTile,
+				RedFiveFromOthers: isRedFive,
+			})
+		}
+		return
+	}
+
+	for tile, c := range tiles34 {
+		if c == 4 {
+			melds = append(melds, model.Meld{
+				MeldType:   model.MeldTypeAnkan,
+				Tiles:      []int{tile, tile, tile, tile},
+				SelfTiles:  []int{tile, tile, tile, tile},
+				CalledTile: tile,
+			})
+		}
+	}
+	if !playerInfo.IsRiichi {
+		for _, meld := range playerInfo.Melds {
+			if tile := meld.Tiles[0]; meld.MeldType == model.MeldTypePon && tiles34[tile] > 0 {
+				melds = append(melds, model.Meld{
+					MeldType:   model.MeldTypeKakan,
+					Tiles:      []int{tile, tile, tile, tile},
+					SelfTiles:  []int{tile},
+					CalledTile: meld.CalledTile,
+				})
+			}
+		}
+	}
+	return
+}
+
+// 杠，返回复原的函数
+// 加杠时将原有的碰替换成加杠
+func applyKan(playerInfo *model.PlayerInfo, kan model.Meld) (undo func()) {
+	if kan.MeldType != model.MeldTypeKakan {
+		playerInfo.AddMeld(kan)
+		return playerInfo.UndoAddMeld
+	}
+	tile := kan.Tiles[0]
+	for i, meld := range playerInfo.Melds {
+		if meld.MeldType == model.MeldTypePon && meld.Tiles[0] == tile {
+			playerInfo.Melds[i] = kan
+			playerInfo.HandTiles34[tile]--
+			return func() {
+				playerInfo.HandTiles34[tile]++
+				playerInfo.Melds[i] = meld
+			}
+		}
+	}
+	panic(fmt.Sprint("没有可以加杠的碰：", Mahjong[tile]))
+}
+
+// 手牌和副露中这种牌的枚数
+func countTileWithMelds(playerInfo *model.PlayerInfo, tile int) (count int) {
+	count = playerInfo.HandTiles34[tile]
+	for _, meld := range playerInfo.Melds {
+		for _, t := range meld.Tiles {
+			if t == tile {
+				count++
+			}
+		}
+	}
+	return
+}
+
+// 翻开一张新宝牌指示牌后，自家（手牌+副露）和他家每人（按 13 张计）的期望宝牌数增加
+// 宝牌指示牌从剩余牌中等概率翻开
+func expectedKanDora(playerInfo *model.PlayerInfo) (self float64, opponent float64) {
+	isSannin := playerInfo.IsSanma
+	leftTiles34 := playerInfo.LeftTiles34
+	sumLeft := 0
+	for _, left := range leftTiles34 {
+		sumLeft += left
+	}
+	if sumLeft == 0 {
+		return
+	}
+	for indicator, left := range leftTiles34 {
+		if left == 0 {
+			continue
+		}
+		p := float64(left) / float64(sumLeft)
+		dora := model.DoraTile(indicator, isSannin)
+		self += p * float64(countTileWithMelds(playerInfo, dora))
+		doraLeft := leftTiles34[dora]
+		if dora == indicator {
+			doraLeft--
+		}
+		opponent += p * 13 * float64(doraLeft) / float64(sumLeft)
+	}
+	return
+}
+
+// 和率，听牌时为手牌和率，未听牌时为经验值
+func kanWinRate(r13 *Hand13AnalysisResult) float64 {
+	if r13.Shanten == shantenStateTenpai {
+		return r13.AvgAgariRate / 100
+	}
+	if r13.Shanten < len(shantenAgariRates) {
+		return shantenAgariRates[r13.Shanten]
+	}
+	return 0
+}
+
+// 和牌时的打点，能立直时取立直打点
+func kanWinPoint(playerInfo *model.PlayerInfo, r13 *Hand13AnalysisResult) float64 {
+	if r13.RiichiPoint > 0 && (playerInfo.IsRiichi || r13.DamaPoint == 0) {
+		return r13.RiichiPoint
+	}
+	return r13.DamaPoint
+}
+
+// 杠前后的进张是否相同，用于判断立直后能否暗杠
+func isSameWaitTiles(w0, w1 Waits) bool {
+	tiles0, tiles1 := w0.indexes(), w1.indexes()
+	if len(tiles0) != len(tiles1) {
+		return false
+	}
+	for i := range tiles0 {
+		if tiles0[i] != tiles1[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// 比较杠与不杠：进张的变化（杠掉的牌不再留在手中）、符数与岭上开花、新宝牌的期望、他家获得新宝牌的风险，以及加杠时被抢杠的风险
+// skipResult13 为不杠时的最佳何切结果，立直时忽略该参数，视作摸切杠的牌
+// 立直后暗杠改变听牌时不能杠，返回 nil
+func CalcKanAdvice(playerInfo *model.PlayerInfo, kan model.Meld, skipResult13 *Hand13AnalysisResult, opponents OpponentRiskList) *KanAdvice {
+	tile := kan.Tiles[0]
+	if len(playerInfo.LeftTiles34) == 0 {
+		playerInfo.FillLeftTiles34()
+	}
+
+	if playerInfo.IsRiichi {
+		playerInfo.DiscardTile(tile, false)
+		skipResult13 = CalculateShantenWithImproves13(playerInfo)
+		playerInfo.UndoDiscardTile(tile, false)
+	}
+	if skipResult13 == nil {
+		return nil
+	}
+
+	undo := applyKan(playerInfo, kan)
+	kanResult13 := CalculateShantenWithImproves13(playerInfo)
+	selfKanDora, opponentKanDora := expectedKanDora(playerInfo)
+	undo()
+
+	if playerInfo.IsRiichi && (kanResult13.Shanten != shantenStateTenpai || !isSameWaitTiles(skipResult13.Waits, kanResult13.Waits)) {
+		return nil
+	}
+
+	a := &KanAdvice{
+		Meld:            kan,
+		SkipResult13:    skipResult13,
+		KanResult13:     kanResult13,
+		SelfKanDora:     selfKanDora,
+		OpponentKanDora: opponentKanDora,
+	}
+
+	// 进张
+	if kanResult13.Shanten > skipResult13.Shanten {
+		a.EVDiff -= kanShantenBackLossPoint
+		a.Reasons = append(a.Reasons, "杠后向听倒退")
+	} else if skipWaits, kanWaits := skipResult13.Waits.AllCount(), kanResult13.Waits.AllCount(); kanWaits != skipWaits {
+		a.Reasons = append(a.Reasons, fmt.Sprintf("杠后进张 %d → %d", skipWaits, kanWaits))
+	}
+
+	// 符数与打点
+	roundPointDiff := kanResult13.MixedRoundPoint - skipResult13.MixedRoundPoint
+	a.EVDiff += roundPointDiff
+	a.Reasons = append(a.Reasons, fmt.Sprintf("局收支 杠%d 不杠%d", int(math.Round(kanResult13.MixedRoundPoint)), int(math.Round(skipResult13.MixedRoundPoint))))
+
+	// 岭上开花
+	if kanResult13.Shanten == shantenStateTenpai {
+		sumLeft := 0
+		for _, left := range playerInfo.LeftTiles34 {
+			sumLeft += left
+		}
+		if sumLeft > 0 {
+			a.RinshanRate = 100 * float64(kanResult13.Waits.AllCount()) / float64(sumLeft)
+			a.EVDiff += a.RinshanRate / 100 * kanWinPoint(playerInfo, kanResult13)
+			a.Reasons = append(a.Reasons, fmt.Sprintf("岭上开花率%.1f%%", a.RinshanRate))
+		}
+	}
+
+	// 新宝牌
+	a.EVDiff += selfKanDora * kanDoraPointValue * kanWinRate(kanResult13)
+	opponentLoss := 0.0
+	notTenpaiRate := 1.0
+	for _, o := range opponents {
+		opponentLoss += opponentKanDora * kanDoraPointValue * math.Max(o.TenpaiRate/100, kanOpponentBaseWinRate) * kanOpponentPayShare
+		notTenpaiRate *= 1 - o.TenpaiRate/100
+	}
+	if len(opponents) == 0 {
+		opponentLoss = 3 * opponentKanDora * kanDoraPointValue * kanOpponentBaseWinRate * kanOpponentPayShare
+	}
+	a.EVDiff -= opponentLoss
+	a.Reasons = append(a.Reasons, fmt.Sprintf("期望宝牌 自家+%.2f 他家每人+%.2f", selfKanDora, opponentKanDora))
+
+	// 抢杠
+	if kan.MeldType == model.MeldTypeKakan {
+		chankanLoss := 0.0
+		for _, o := range opponents {
+			p := o.TenpaiRate / 100 * o.RiskTiles34[tile] / 100
+			a.ChankanRate += 100 * p
+			chankanLoss += p * o.RonPoint
+		}
+		if a.ChankanRate > 0 {
+			a.EVDiff -= chankanLoss
+			a.Reasons = append(a.Reasons, fmt.Sprintf("被抢杠的概率%.1f%%", a.ChankanRate))
+		}
+	}
+
+	// 防守，立直后无法弃和
+	if threat := 1 - notTenpaiRate; threat > 0 && !playerInfo.IsRiichi {
+		a.EVDiff -= threat * kanDefenceLossPoint
+		a.Reasons = append(a.Reasons, fmt.Sprintf("他家听牌率%.0f%%，杠后手牌减少，防守能力下降", threat*100))
+	}
+
+	if a.EVDiff > 0 {
+		a.Verdict = KanVerdictKan
+	} else {
+		a.Verdict = KanVerdictSkip
+	}
+	a.Confidence = 0.5 + 0.5*math.Min(1, math.Abs(a.EVDiff)/kanFullConfidencePoint)
+	return a
+}
+
+// 计算所有可以进行的杠的判断，无法杠时返回空
+// calledTile 及 skipResult13 的含义同 KanCandidates 和 CalcKanAdvice
+func CalcKanAdvices(playerInfo *model.PlayerInfo, calledTile int, isRedFive bool, skipResult13 *Hand13AnalysisResult, opponents OpponentRiskList) (advices []*KanAdvice) {
+	for _, kan := range KanCandidates(playerInfo, calledTile, isRedFive) {
+		if a := CalcKanAdvice(playerInfo, kan, skipResult13, opponents); a != nil {
+			advices = append(advices, a)
+		}
+	}
+	return
+}
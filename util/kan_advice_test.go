@@ -0,0 +1,90 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestKanCandidates(t *testing.T) {
+	assert := assert.New(t)
+
+	pon := model.Meld{MeldType: model.MeldTypePon, Tiles: []int{31, 31, 31}, SelfTiles: []int{31, 31}, CalledTile: 31}
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("1111m 234p 567s 5z 89s"), []model.Meld{pon})
+	melds := KanCandidates(playerInfo, -1, false)
+	if assert.Len(melds, 2) {
+		assert.Equal(model.MeldTypeAnkan, melds[0].MeldType)
+		assert.Equal([]int{0, 0, 0, 0}, melds[0].SelfTiles)
+		assert.Equal(model.MeldTypeKakan, melds[1].MeldType)
+		assert.Equal([]int{31}, melds[1].SelfTiles)
+	}
+
+	// 立直后只能暗杠
+	playerInfo.IsRiichi = true
+	assert.Len(KanCandidates(playerInfo, -1, false), 1)
+
+	// 大明杠
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("111m 234p 567s 89s 55z"), nil)
+	melds = KanCandidates(playerInfo, 0, false)
+	if assert.Len(melds, 1) {
+		assert.Equal(model.MeldTypeMinkan, melds[0].MeldType)
+	}
+	assert.Empty(KanCandidates(playerInfo, 1, false))
+}
+
+func TestCalcKanAdvice(t *testing.T) {
+	assert := assert.New(t)
+
+	advices := func(playerInfo *model.PlayerInfo, calledTile int, opponents OpponentRiskList) []*KanAdvice {
+		var skipResult13 *Hand13AnalysisResult
+		if calledTile == -1 {
+			if _, results14, _ := CalculateShantenWithImproves14(playerInfo); len(results14) > 0 {
+				skipResult13 = results14[0].Result13
+			}
+		} else {
+			skipResult13 = CalculateShantenWithImproves13(playerInfo)
+		}
+		return CalcKanAdvices(playerInfo, calledTile, false, skipResult13, opponents)
+	}
+
+	// 听牌后暗杠东，听牌不变，符数和宝牌增加
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("1111z 234m 567p 78s 99s"), nil)
+	l := advices(playerInfo, -1, nil)
+	if assert.Len(l, 1) {
+		a := l[0]
+		assert.Equal(KanVerdictKan, a.Verdict)
+		assert.Equal(0, a.KanResult13.Shanten)
+		assert.True(a.SelfKanDora > 0)
+		assert.True(a.RinshanRate > 0)
+	}
+	// 杠后复原手牌
+	assert.Equal(MustStrToTiles34("1111z 234m 567p 78s 99s"), playerInfo.HandTiles34)
+	assert.Empty(playerInfo.Melds)
+
+	// 立直后暗杠改变听牌，不能杠
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("11112m 567p 789s 456s"), nil)
+	playerInfo.IsRiichi = true
+	assert.Empty(advices(playerInfo, -1, nil))
+
+	// 加杠有被抢杠的风险
+	pon := model.Meld{MeldType: model.MeldTypePon, Tiles: []int{4, 4, 4}, SelfTiles: []int{4, 4}, CalledTile: 4}
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("5m 234p 567s 78s 99s"), []model.Meld{pon})
+	risk34 := make(RiskTiles34, 34)
+	risk34[4] = 10
+	opponents := OpponentRiskList{{Who: 1, TenpaiRate: 100, RiskTiles34: risk34, RonPoint: 8000}}
+	l = advices(playerInfo, -1, opponents)
+	if assert.Len(l, 1) {
+		a := l[0]
+		assert.Equal(model.MeldTypeKakan, a.Meld.MeldType)
+		assert.InDelta(10, a.ChankanRate, 1e-9)
+		assert.Contains(a.Reasons, "被抢杠的概率10.0%")
+	}
+	assert.Equal(model.MeldTypePon, playerInfo.Melds[0].MeldType)
+
+	// 大明杠破坏门清，无役时不杠
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("111m 234p 567s 89s 55p"), nil)
+	l = advices(playerInfo, 0, nil)
+	if assert.Len(l, 1) {
+		assert.Equal(KanVerdictSkip, l[0].Verdict)
+	}
+}
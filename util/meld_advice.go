@@ -0,0 +1,155 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+)
+
+// 他家舍牌时的鸣牌判断
+const (
+	MeldVerdictCall = iota // 鸣牌
+	MeldVerdictSkip        // 跳过
+)
+
+var MeldVerdictNames = []string{"鸣牌", "跳过"}
+
+const (
+	// 局收支之差每 1000 点计 1 分，最多计 2 分
+	meldRoundPointScoreUnit = 1000.0
+	meldRoundPointScoreMax  = 2.0
+
+	// 鸣牌后无役时扣的分，可能无役时按概率扣分
+	meldNoYakuScore = 3.0
+
+	// 无役的概率达到该值时显示在判断依据中
+	meldShownNoYakuRate = 0.3
+
+	// 他家听牌率为 100% 时，鸣牌后手牌减少带来的防守损失（分）
+	meldDefenceLossScore = 2.0
+
+	// 得分绝对值达到该值时置信度为 100%
+	meldFullConfidenceScore = 4.0
+)
+
+type MeldAdvice struct {
+	Verdict int
+
+	// 置信度 (0.5-1)
+	Confidence float64
+
+	// 鸣牌后的最佳何切
+	Best *Hand14AnalysisResult
+
+	// 鸣牌后和不鸣时的向听数
+	CallShanten int
+	SkipShanten int
+
+	// 鸣牌后和不鸣时的局收支
+	CallRoundPoint float64
+	SkipRoundPoint float64
+
+	// 判断依据
+	Reasons []string
+}
+
+func (a *MeldAdvice) String() string {
+	return fmt.Sprintf("%s（置信度%.0f%%）", MeldVerdictNames[a.Verdict], a.Confidence*100)
+}
+
+// 鸣牌类型（吃或碰）
+func (a *MeldAdvice) MeldType() int {
+	if a.Best.OpenTiles[0] == a.Best.OpenTiles[1] {
+		return model.MeldTypePon
+	}
+	return model.MeldTypeChi
+}
+
+// 鸣牌后是否无役
+// 听牌时根据打点判断，一向听时根据役种和役的路线判断，更高向听时役种信息不全，视作有役（由役的路线按概率扣分）
+func isNoYakuAfterMeld(r13 *Hand13AnalysisResult) bool {
+	switch r13.Shanten {
+	case shantenStateTenpai:
+		return r13.DamaPoint == 0
+	case 1:
+		return len(r13.YakuTypes) == 0 && (r13.YakuPaths == nil || r13.isNoYakuRoute())
+	default:
+		return false
+	}
+}
+
+// 比较鸣牌与跳过：速度（向听数）、役、局收支以及鸣牌后手牌减少带来的防守损失，给出鸣牌或跳过的判断
+// skipResult13 为不鸣时的手牌分析结果，results14 和 incShantenResults14 为 CalculateMeld 的结果
+// 无法鸣牌或已立直时返回 nil
+func CalcMeldAdvice(playerInfo *model.PlayerInfo, skipResult13 *Hand13AnalysisResult, results14 Hand14AnalysisResultList, incShantenResults14 Hand14AnalysisResultList, opponents OpponentRiskList) *MeldAdvice {
+	if playerInfo.IsRiichi || skipResult13 == nil {
+		return nil
+	}
+	var best *Hand14AnalysisResult
+	if len(results14) > 0 {
+		best = results14[0]
+	} else if len(incShantenResults14) > 0 {
+		best = incShantenResults14[0]
+	} else {
+		return nil
+	}
+
+	a := &MeldAdvice{
+		Best:           best,
+		CallShanten:    best.Result13.Shanten,
+		SkipShanten:    skipResult13.Shanten,
+		CallRoundPoint: best.Result13.MixedRoundPoint,
+		SkipRoundPoint: skipResult13.MixedRoundPoint,
+	}
+	score := 0.0
+
+	// 役
+	noYaku := isNoYakuAfterMeld(best.Result13)
+	if noYaku {
+		score -= meldNoYakuScore
+		a.Reasons = append(a.Reasons, "鸣牌后无役")
+	} else if paths := best.Result13.YakuPaths; paths != nil && paths.NoYakuRate > 0 {
+		// 未听牌时根据役的路线估计无役的概率
+		score -= paths.NoYakuRate * meldNoYakuScore
+		if paths.NoYakuRate >= meldShownNoYakuRate {
+			a.Reasons = append(a.Reasons, fmt.Sprintf("鸣牌后可能无役（%.0f%%）", paths.NoYakuRate*100))
+		}
+	}
+
+	// 速度
+	if a.CallShanten < a.SkipShanten {
+		score += 2
+		if a.CallShanten == shantenStateTenpai && !noYaku {
+			score++
+			a.Reasons = append(a.Reasons, "鸣牌后听牌")
+		} else {
+			a.Reasons = append(a.Reasons, "鸣牌后向听数前进")
+		}
+	} else {
+		score -= 2
+		a.Reasons = append(a.Reasons, "鸣牌后向听数不变")
+	}
+
+	// 打点
+	diff := (a.CallRoundPoint - a.SkipRoundPoint) / meldRoundPointScoreUnit
+	score += math.Max(-meldRoundPointScoreMax, math.Min(meldRoundPointScoreMax, diff))
+	a.Reasons = append(a.Reasons, fmt.Sprintf("局收支 鸣牌%d 不鸣%d", int(math.Round(a.CallRoundPoint)), int(math.Round(a.SkipRoundPoint))))
+
+	// 防守
+	notTenpaiRate := 1.0
+	for _, o := range opponents {
+		notTenpaiRate *= 1 - o.TenpaiRate/100
+	}
+	if threat := 1 - notTenpaiRate; threat > 0 {
+		score -= threat * meldDefenceLossScore
+		a.Reasons = append(a.Reasons, fmt.Sprintf("他家听牌率%.0f%%，鸣牌后手牌减少，防守能力下降", threat*100))
+	}
+
+	if score > 0 {
+		a.Verdict = MeldVerdictCall
+	} else {
+		a.Verdict = MeldVerdictSkip
+	}
+	a.Confidence = 0.5 + 0.5*math.Min(1, math.Abs(score)/meldFullConfidenceScore)
+	return a
+}
@@ -0,0 +1,100 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalcMeldAdvice(t *testing.T) {
+	assert := assert.New(t)
+
+	advice := func(playerInfo *model.PlayerInfo, calledTile int, allowChi bool, opponents OpponentRiskList) *MeldAdvice {
+		_, results14, incShantenResults14 := CalculateMeld(playerInfo, calledTile, false, allowChi)
+		return CalcMeldAdvice(playerInfo, CalculateShantenWithImproves13(playerInfo), results14, incShantenResults14, opponents)
+	}
+
+	// 碰中后役牌听牌
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("234m 567p 678s 77z 19p"), nil)
+	a := advice(playerInfo, 33, false, nil)
+	if assert.NotNil(a) {
+		assert.Equal(MeldVerdictCall, a.Verdict)
+		assert.Equal(0, a.CallShanten)
+		assert.Equal(1, a.SkipShanten)
+		assert.Equal([]int{33, 33}, a.Best.OpenTiles)
+		assert.Equal(model.MeldTypePon, a.MeldType())
+		assert.Equal("鸣牌后听牌", a.Reasons[0])
+	}
+
+	// 他家听牌时，鸣牌的置信度降低
+	risk34 := make(RiskTiles34, 34)
+	opponents := OpponentRiskList{{Who: 1, TenpaiRate: 100, RiskTiles34: risk34, RonPoint: RonPointRiichiHiIppatsu}}
+	threatened := advice(playerInfo, 33, false, opponents)
+	if assert.NotNil(a) && assert.NotNil(threatened) {
+		assert.True(threatened.Confidence < a.Confidence)
+		assert.Contains(threatened.Reasons[len(threatened.Reasons)-1], "防守能力下降")
+	}
+
+	// 吃 4s 后听牌但无役
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("123m 789p 35s 99m 11p 2z"), nil)
+	a = advice(playerInfo, 21, true, nil)
+	if assert.NotNil(a) {
+		assert.Equal(MeldVerdictSkip, a.Verdict)
+		assert.Equal("鸣牌后无役", a.Reasons[0])
+	}
+
+	// 不能吃上家以外的牌
+	assert.Nil(advice(playerInfo, 21, false, nil))
+
+	// 吃 2m 后一向听，虽然暂时没有役，但可以走断幺
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("34m 5567p 345s 68s 9p"), nil)
+	a = advice(playerInfo, 1, true, nil)
+	if assert.NotNil(a) {
+		assert.Equal(MeldVerdictCall, a.Verdict)
+		assert.NotContains(a.Reasons, "鸣牌后无役")
+		assert.Equal(model.MeldTypeChi, a.MeldType())
+		if assert.NotNil(a.Best.Result13.YakuPaths) {
+			assert.Equal(YakuTanyao, a.Best.Result13.YakuPaths.Paths[0].YakuType)
+		}
+	}
+
+	// 立直后不能鸣牌
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("234m 567p 678s 77z 19p"), nil)
+	playerInfo.IsRiichi = true
+	assert.Nil(advice(playerInfo, 33, false, nil))
+}
+
+func TestCalcMeldAdvice_ruleset(t *testing.T) {
+	assert := assert.New(t)
+	defer SetRuleset(CurrentRuleset())
+
+	// 吃 2m 后断幺听牌
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("34m 7m 5567p 234s 678s"), nil)
+	advice := func() *MeldAdvice {
+		_, results14, incShantenResults14 := CalculateMeld(playerInfo, 1, false, true)
+		if !assert.NotEmpty(results14) {
+			return nil
+		}
+		if CurrentRuleset().OpenTanyao {
+			assert.Contains(results14[0].Result13.YakuTypes, YakuTanyao)
+		} else {
+			assert.Empty(results14[0].Result13.YakuTypes)
+			assert.Zero(results14[0].Result13.DamaPoint)
+		}
+		return CalcMeldAdvice(playerInfo, CalculateShantenWithImproves13(playerInfo), results14, incShantenResults14, nil)
+	}
+
+	SetRuleset(RulesetTenhou)
+	if a := advice(); assert.NotNil(a) {
+		assert.NotContains(a.Reasons, "鸣牌后无役")
+	}
+
+	// 无食断
+	r := RulesetTenhou
+	r.OpenTanyao = false
+	SetRuleset(r)
+	if a := advice(); assert.NotNil(a) {
+		assert.Equal(MeldVerdictSkip, a.Verdict)
+		assert.Contains(a.Reasons, "鸣牌后无役")
+	}
+}
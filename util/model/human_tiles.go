@@ -12,6 +12,13 @@ type HumanTilesInfo struct {
 	HumanDoraTiles string // 13m6p 不能有空格
 	IsTsumo        bool
 
+	// 以下为可选项，为空时使用默认值（东场东家）
+	HumanRoundWind    string // 场风，如 1z
+	HumanSelfWind     string // 自风，如 2z
+	HumanDiscardTiles string // 自家舍牌，用于判断振听等
+	Turn              int    // 巡目，用于估算剩余可以摸的牌数
+	IsSanma           bool   // 是否为三麻
+
 	HumanMelds      []string // 从 HumanTiles 解析出来的副露
 	HumanTargetTile string   // 从 HumanTiles 解析出来的被鸣的牌
 }
@@ -3,10 +3,11 @@ package model
 import "fmt"
 
 type PlayerInfo struct {
-	HandTiles34 []int  // 手牌，不含副露
-	Melds       []Meld // 副露
-	DoraTiles   []int  // 宝牌指示牌产生的宝牌，可以重复
-	NumRedFives []int  // 按照 mps 的顺序，各个赤5的个数（手牌和副露中的）
+	HandTiles34  []int  // 手牌，不含副露
+	Melds        []Meld // 副露
+	DoraTiles    []int  // 宝牌指示牌产生的宝牌，可以重复
+	UraDoraTiles []int  // 里宝牌指示牌产生的里宝牌，仅在计算实际和牌时设置
+	NumRedFives  []int  // 按照 mps 的顺序，各个赤5的个数（手牌和副露中的）
 
 	IsTsumo       bool // 是否自摸
 	WinTile       int  // 自摸/荣和的牌
@@ -16,17 +17,31 @@ type PlayerInfo struct {
 	IsDaburii     bool // 是否双立直
 	IsRiichi      bool // 是否立直
 
+	// 状况役，仅在计算实际和牌时设置
+	IsIppatsu   bool // 是否一发
+	IsLastTile  bool // 是否为最后一张牌（自摸时为海底，荣和时为河底）
+	IsRinshan   bool // 是否为开杠后摸的岭上牌
+	IsChankan   bool // 是否荣和他家加杠的牌
+	IsFirstDraw bool // 是否为第一巡且此前无人鸣牌（自摸时为天和/地和，荣和时为人和）
+
 	DiscardTiles []int // 自家舍牌，用于判断和率，是否振听等  *注意创建 PlayerInfo 的时候把负数调整成正的！
 	LeftTiles34  []int // 剩余牌
 
-	LeftDrawTilesCount int // 剩余可以摸的牌数
+	LeftDrawTilesCount int // 剩余可以摸的牌数，为 LeftDrawTilesCountUnknown 时表示未知，为 0 时表示最后一张牌已被摸走
 
 	//LeftRedFives []int // 剩余赤5个数，用于估算打点
 	//AvgUraDora float64 // 平均里宝牌个数，用于计算立直时的打点
 
-	NukiDoraNum int // 拔北宝牌数
+	NukiDoraNum int  // 拔北宝牌数
+	IsSanma     bool // 是否为三麻，影响自摸点数、拔北和危险度等的计算
+
+	// 场况，用于终局时的顺位判断，为 nil 时不考虑顺位
+	Standing *Standing
 }
 
+// 不知道剩余可以摸的牌数，如手动输入手牌时
+const LeftDrawTilesCountUnknown = -1
+
 func NewSimplePlayerInfo(tiles34 []int, melds []Meld) *PlayerInfo {
 	leftTiles34 := InitLeftTiles34WithTiles34(tiles34)
 	for _, meld := range melds {
@@ -44,6 +59,8 @@ func NewSimplePlayerInfo(tiles34 []int, melds []Meld) *PlayerInfo {
 		RoundWindTile: 27,
 		SelfWindTile:  27,
 		LeftTiles34:   leftTiles34,
+
+		LeftDrawTilesCount: LeftDrawTilesCountUnknown,
 	}
 }
 
@@ -76,6 +93,24 @@ func (pi *PlayerInfo) CountDora() (count int) {
 	return
 }
 
+// 立直和牌时，手牌和副露中的里宝牌个数
+func (pi *PlayerInfo) CountUraDora() (count int) {
+	if !pi.IsRiichi && !pi.IsDaburii {
+		return 0
+	}
+	for _, uraDoraTile := range pi.UraDoraTiles {
+		count += pi.HandTiles34[uraDoraTile]
+		for _, m := range pi.Melds {
+			for _, tile := range m.Tiles {
+				if tile == uraDoraTile {
+					count++
+				}
+			}
+		}
+	}
+	return
+}
+
 // 立直时，根据牌山计算和了时的里宝牌个数
 // TODO: 考虑 WinTile
 //func (pi *PlayerInfo) CountUraDora() (count float64) {
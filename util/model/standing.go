@@ -0,0 +1,92 @@
+package model
+
+// 场况：各家点数、供托等，用于终局（All Last）时根据顺位调整打法
+type Standing struct {
+	// 各家点数，0=自家, 1=下家, 2=对家, 3=上家
+	// 本局立直的玩家已扣除 1000 点
+	Scores []int
+
+	// 三麻时不存在的一家，四麻时为 -1
+	AbsentPlayer int
+
+	// 起家，同分时按起家顺序决定顺位
+	FirstDealer int
+
+	// 场上的立直棒数（含本局的立直）
+	RiichiSticks int
+
+	// 是否为东风战
+	IsTonpuu bool
+
+	RoundNumber int // 场数（如东1为0，东2为1，...，南1为4，...）
+	BenNumber   int // 本场数
+	Dealer      int // 庄家 0=自家, 1=下家, 2=对家, 3=上家
+}
+
+// 该玩家是否在场（三麻时有一家不存在）
+func (s *Standing) IsPresent(who int) bool {
+	return who >= 0 && who < len(s.Scores) && who != s.AbsentPlayer
+}
+
+func (s *Standing) PlayerNumber() int {
+	if s.AbsentPlayer != -1 {
+		return len(s.Scores) - 1
+	}
+	return len(s.Scores)
+}
+
+// 每个场风的局数，三麻为 3 局
+func (s *Standing) roundsPerWind() int {
+	if s.PlayerNumber() == 3 {
+		return 3
+	}
+	return 4
+}
+
+// 从东1局开始的第几局（从 0 开始）
+// 注意无论是三麻还是四麻，南1的场数都是 4
+func (s *Standing) roundIndex(roundNumber int) int {
+	return roundNumber/4*s.roundsPerWind() + roundNumber%4
+}
+
+// 终局是第几局，东风战为东4局（三麻东3局），半庄战为南4局（三麻南3局）
+func (s *Standing) lastRoundIndex() int {
+	if s.IsTonpuu {
+		return s.roundsPerWind() - 1
+	}
+	return 2*s.roundsPerWind() - 1
+}
+
+// 是否为终局，即东风战东4局、半庄战南4局（三麻为东3局、南3局），以及之后的延长战
+func (s *Standing) IsAllLast() bool {
+	return s.roundIndex(s.RoundNumber) >= s.lastRoundIndex()
+}
+
+// 本局之后还剩多少局（不考虑连庄），终局时为 0
+func (s *Standing) RemainingRounds() int {
+	if s.IsAllLast() {
+		return 0
+	}
+	return s.lastRoundIndex() - s.roundIndex(s.RoundNumber)
+}
+
+// 是否为终盘，即半庄战南场、东风战东3局之后（三麻东2局之后），此时顺位比局收支更重要
+func (s *Standing) IsFinalRounds() bool {
+	return s.RemainingRounds() < (s.lastRoundIndex()+2)/2
+}
+
+// 从起家开始，各家的座位顺序（0 为起家），不在场的一家为 -1
+func (s *Standing) SeatOrders() []int {
+	orders := make([]int, len(s.Scores))
+	order := 0
+	for i := range s.Scores {
+		who := (s.FirstDealer + i) % len(s.Scores)
+		if !s.IsPresent(who) {
+			orders[who] = -1
+			continue
+		}
+		orders[who] = order
+		order++
+	}
+	return orders
+}
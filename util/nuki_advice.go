@@ -0,0 +1,164 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+)
+
+// 三麻摸到北时的拔北判断
+const (
+	NukiVerdictNuki = iota // 拔北
+	NukiVerdictKeep        // 留北
+)
+
+var NukiVerdictNames = []string{"拔北", "留北"}
+
+const (
+	// 他家听牌率为 100% 时，拔北后失去一张安全的北带来的防守损失
+	nukiSafeTileLossPoint = 1000.0
+
+	// 北的铳率 (%) 低于该值时视作安全牌
+	nukiSafeTileMaxRisk = 2.0
+)
+
+type NukiAdvice struct {
+	Verdict int
+
+	// 置信度 (0.5-1)
+	Confidence float64
+
+	// 留北时的最佳何切（不切北）和拔北后的手牌分析结果（均为 3k+1 张）
+	// 立直时留北视作摸切北
+	KeepResult13 *Hand13AnalysisResult
+	NukiResult13 *Hand13AnalysisResult
+
+	// 北是否为自家的役牌
+	IsYakuhai bool
+
+	// 拔北后补摸的牌岭上开花的概率 (0-100)，拔北后听牌时才有
+	RinshanRate float64
+
+	// 拔北与留北的期望收支之差
+	EVDiff float64
+
+	// 判断依据
+	Reasons []string
+}
+
+func (a *NukiAdvice) String() string {
+	return fmt.Sprintf("%s（置信度%.0f%%）", NukiVerdictNames[a.Verdict], a.Confidence*100)
+}
+
+// 拔北，返回复原的函数
+func applyNuki(playerInfo *model.PlayerInfo) (undo func()) {
+	playerInfo.HandTiles34[30]--
+	playerInfo.NukiDoraNum++
+	return func() {
+		playerInfo.NukiDoraNum--
+		playerInfo.HandTiles34[30]++
+	}
+}
+
+// 比较拔北与留北：拔北多一枚宝牌并补摸一张牌，留北则保留北的牌型价值（雀头、刻子、役牌）和一张安全牌
+// results14 和 incShantenResults14 为 3k+2 张手牌的何切结果（需已排序），留北时取其中第一个不切北的结果
+// 非三麻、手中没有北或立直后拔北改变听牌时返回 nil
+func CalcNukiAdvice(playerInfo *model.PlayerInfo, results14 Hand14AnalysisResultList, incShantenResults14 Hand14AnalysisResultList, opponents OpponentRiskList) *NukiAdvice {
+	if !playerInfo.IsSanma || playerInfo.HandTiles34[30] == 0 {
+		return nil
+	}
+	if len(playerInfo.LeftTiles34) == 0 {
+		playerInfo.FillLeftTiles34()
+	}
+
+	var keepResult13 *Hand13AnalysisResult
+	if playerInfo.IsRiichi {
+		playerInfo.DiscardTile(30, false)
+		keepResult13 = CalculateShantenWithImproves13(playerInfo)
+		playerInfo.UndoDiscardTile(30, false)
+	} else {
+		for _, results := range []Hand14AnalysisResultList{results14, incShantenResults14} {
+			for _, r := range results {
+				if r.DiscardTile != 30 {
+					keepResult13 = r.Result13
+					break
+				}
+			}
+			if keepResult13 != nil {
+				break
+			}
+		}
+	}
+	if keepResult13 == nil {
+		return nil
+	}
+
+	undo := applyNuki(playerInfo)
+	nukiResult13 := CalculateShantenWithImproves13(playerInfo)
+	undo()
+
+	if playerInfo.IsRiichi && (nukiResult13.Shanten != shantenStateTenpai || !isSameWaitTiles(keepResult13.Waits, nukiResult13.Waits)) {
+		return nil
+	}
+
+	a := &NukiAdvice{
+		KeepResult13: keepResult13,
+		NukiResult13: nukiResult13,
+		IsYakuhai:    playerInfo.RoundWindTile == 30 || playerInfo.SelfWindTile == 30,
+	}
+
+	// 牌型
+	if nukiResult13.Shanten > keepResult13.Shanten {
+		a.EVDiff -= kanShantenBackLossPoint
+		if a.IsYakuhai {
+			a.Reasons = append(a.Reasons, "北是役牌，拔北后向听倒退")
+		} else {
+			a.Reasons = append(a.Reasons, "拔北后向听倒退")
+		}
+	} else if nukiResult13.Shanten < keepResult13.Shanten {
+		a.Reasons = append(a.Reasons, "拔北后向听数前进")
+	}
+
+	// 宝牌与打点
+	a.EVDiff += nukiResult13.MixedRoundPoint - keepResult13.MixedRoundPoint
+	if nukiResult13.MixedRoundPoint == keepResult13.MixedRoundPoint {
+		// 远离听牌时局收支没有计入宝牌，按经验值估计
+		a.EVDiff += kanDoraPointValue * kanWinRate(nukiResult13)
+	}
+	a.Reasons = append(a.Reasons, fmt.Sprintf("局收支 拔北%d 留北%d", int(math.Round(nukiResult13.MixedRoundPoint)), int(math.Round(keepResult13.MixedRoundPoint))))
+
+	// 补摸的牌
+	if nukiResult13.Shanten == shantenStateTenpai {
+		sumLeft := 0
+		for _, left := range playerInfo.LeftTiles34 {
+			sumLeft += left
+		}
+		if sumLeft > 0 {
+			a.RinshanRate = 100 * float64(nukiResult13.Waits.AllCount()) / float64(sumLeft)
+			a.EVDiff += a.RinshanRate / 100 * kanWinPoint(playerInfo, nukiResult13)
+			a.Reasons = append(a.Reasons, fmt.Sprintf("岭上开花率%.1f%%", a.RinshanRate))
+		}
+	}
+
+	// 防守，北对他家而言只能单骑或对碰
+	if !playerInfo.IsRiichi {
+		threat := 0.0
+		for _, o := range opponents {
+			if o.RiskTiles34[30] < nukiSafeTileMaxRisk {
+				threat = 1 - (1-threat)*(1-o.TenpaiRate/100)
+			}
+		}
+		if threat > 0 {
+			a.EVDiff -= threat * nukiSafeTileLossPoint
+			a.Reasons = append(a.Reasons, fmt.Sprintf("他家听牌率%.0f%%，拔北后失去一张安全牌", threat*100))
+		}
+	}
+
+	if a.EVDiff > 0 {
+		a.Verdict = NukiVerdictNuki
+	} else {
+		a.Verdict = NukiVerdictKeep
+	}
+	a.Confidence = 0.5 + 0.5*math.Min(1, math.Abs(a.EVDiff)/kanFullConfidencePoint)
+	return a
+}
@@ -0,0 +1,61 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalcNukiAdvice(t *testing.T) {
+	assert := assert.New(t)
+
+	newSanmaPlayerInfo := func(humanTiles string) *model.PlayerInfo {
+		playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34(humanTiles), nil)
+		playerInfo.IsSanma = true
+		for tile := range playerInfo.LeftTiles34 {
+			if IsSanmaAbsentTile(tile) {
+				playerInfo.LeftTiles34[tile] = 0
+			}
+		}
+		return playerInfo
+	}
+	advice := func(playerInfo *model.PlayerInfo, opponents OpponentRiskList) *NukiAdvice {
+		_, results14, incShantenResults14 := CalculateShantenWithImproves14(playerInfo)
+		return CalcNukiAdvice(playerInfo, results14, incShantenResults14, opponents)
+	}
+
+	// 孤立的北，拔北后听牌且多一枚宝牌
+	playerInfo := newSanmaPlayerInfo("11m 234p 567s 789s 4z 1p 5p")
+	a := advice(playerInfo, nil)
+	if assert.NotNil(a) {
+		assert.Equal(NukiVerdictNuki, a.Verdict)
+		assert.Equal(0, a.NukiResult13.Shanten)
+		assert.True(a.RinshanRate > 0)
+	}
+	// 拔北后复原手牌
+	assert.Equal(MustStrToTiles34("11m 234p 567s 789s 4z 1p 5p"), playerInfo.HandTiles34)
+	assert.Zero(playerInfo.NukiDoraNum)
+
+	// 北是听牌所需的雀头，留北
+	a = advice(newSanmaPlayerInfo("11m 234p 567s 789s 44z 1p"), nil)
+	if assert.NotNil(a) {
+		assert.Equal(NukiVerdictKeep, a.Verdict)
+		assert.Equal("拔北后向听倒退", a.Reasons[0])
+	}
+
+	// 他家听牌时，拔北会失去一张安全牌
+	noThreat := advice(newSanmaPlayerInfo("1m 234p 567s 78s 99s 4z 1p"), nil)
+	risk34 := make(RiskTiles34, 34)
+	opponents := OpponentRiskList{{Who: 1, TenpaiRate: 100, RiskTiles34: risk34, RonPoint: RonPointRiichiHiIppatsu}}
+	threatened := advice(newSanmaPlayerInfo("1m 234p 567s 78s 99s 4z 1p"), opponents)
+	if assert.NotNil(noThreat) && assert.NotNil(threatened) {
+		assert.InDelta(noThreat.EVDiff-nukiSafeTileLossPoint, threatened.EVDiff, 1e-9)
+		assert.Contains(threatened.Reasons[len(threatened.Reasons)-1], "失去一张安全牌")
+	}
+
+	// 四麻或手中没有北
+	playerInfo = newSanmaPlayerInfo("11m 234p 567s 789s 4z 1p 5p")
+	playerInfo.IsSanma = false
+	assert.Nil(advice(playerInfo, nil))
+	assert.Nil(advice(newSanmaPlayerInfo("11m 234p 567s 789s 5z 1p 5p"), nil))
+}
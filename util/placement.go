@@ -0,0 +1,225 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"sort"
+)
+
+// 根据点数计算各家顺位（0 为一位），同分时起家顺序靠前者优先
+// 三麻时不存在的一家顺位为 -1
+func CalcPlacements(standing *model.Standing, scores []int) []int {
+	seatOrders := standing.SeatOrders()
+	placements := make([]int, len(scores))
+	for i := range scores {
+		if !standing.IsPresent(i) {
+			placements[i] = -1
+			continue
+		}
+		for j := range scores {
+			if j == i || !standing.IsPresent(j) {
+				continue
+			}
+			if scores[j] > scores[i] || scores[j] == scores[i] && seatOrders[j] < seatOrders[i] {
+				placements[i]++
+			}
+		}
+	}
+	return placements
+}
+
+// 荣和后各家的点数，含本场和供托
+// ronPoint: 荣和点数，不含本场
+func ScoresAfterRon(standing *model.Standing, winner int, loser int, ronPoint int) []int {
+	scores := append([]int{}, standing.Scores...)
+	pay := ronPoint + 300*standing.BenNumber
+	scores[loser] -= pay
+	scores[winner] += pay + 1000*standing.RiichiSticks
+	return scores
+}
+
+// 自摸后各家的点数，含本场和供托
+// childPoint parentPoint: 子家和亲家支付的点数，不含本场，亲家自摸时 parentPoint 无效
+// 三麻时不存在的一家不支付，childPoint parentPoint 应为三麻的支付点数（见 CalcPointTsumo3）
+func ScoresAfterTsumo(standing *model.Standing, winner int, childPoint int, parentPoint int) []int {
+	scores := append([]int{}, standing.Scores...)
+	for who := range scores {
+		if who == winner || !standing.IsPresent(who) {
+			continue
+		}
+		pay := childPoint
+		if who == standing.Dealer {
+			pay = parentPoint
+		}
+		pay += 100 * standing.BenNumber
+		scores[who] -= pay
+		scores[winner] += pay
+	}
+	scores[winner] += 1000 * standing.RiichiSticks
+	return scores
+}
+
+//
+
+// 某个番符下的和牌点数
+type winPoint struct {
+	ronPoint         int
+	tsumoChildPoint  int
+	tsumoParentPoint int
+	tsumoSum         int
+}
+
+// 所有可能的和牌点数，按荣和点数或自摸点数从小到大排序
+func allWinPoints(isParent bool, isTsumo bool, isSanma bool) (points []winPoint) {
+	add := func(han, fu, yakumanTimes int) {
+		p := winPoint{}
+		p.ronPoint = CalcPointRon(han, fu, yakumanTimes, isParent)
+		if isSanma {
+			p.tsumoChildPoint, p.tsumoParentPoint = CalcPointTsumo3(han, fu, yakumanTimes, isParent)
+			p.tsumoSum = CalcPointTsumoSum3(han, fu, yakumanTimes, isParent)
+		} else {
+			p.tsumoChildPoint, p.tsumoParentPoint = CalcPointTsumo(han, fu, yakumanTimes, isParent)
+			p.tsumoSum = CalcPointTsumoSum(han, fu, yakumanTimes, isParent)
+		}
+		points = append(points, p)
+	}
+	for han := 1; han <= 4; han++ {
+		if han >= 2 {
+			if isTsumo {
+				// 平和自摸
+				add(han, 20, 0)
+			}
+			if !isTsumo || han >= 3 {
+				// 七对子
+				add(han, 25, 0)
+			}
+		}
+		for fu := 30; fu <= 110; fu += 10 {
+			add(han, fu, 0)
+		}
+	}
+	for _, han := range []int{5, 6, 8, 11, 13} {
+		add(han, 30, 0)
+	}
+	add(0, 0, 1)
+	add(0, 0, 2)
+
+	sort.SliceStable(points, func(i, j int) bool {
+		if isTsumo {
+			return points[i].tsumoSum < points[j].tsumoSum
+		}
+		return points[i].ronPoint < points[j].ronPoint
+	})
+	return
+}
+
+// 升至某一顺位所需的最低打点
+type PlacementTarget struct {
+	Placement int // 目标顺位，0 为一位
+
+	// 荣和各家所需的最低点数（不含本场和供托），-1 表示无法达到
+	// 自家和不在场的一家为 0
+	MinRonPoints []int
+
+	// 自摸所需的最低点数（不含本场和供托），-1 表示无法达到
+	// 亲家自摸时为各家支付的点数，此时 MinTsumoParentPoint 为 0
+	MinTsumoChildPoint  int
+	MinTsumoParentPoint int
+}
+
+// 荣和 loser 的 ronPoint 点能否达到目标顺位
+func (t *PlacementTarget) CanRon(loser int, ronPoint int) bool {
+	need := t.MinRonPoints[loser]
+	return need > 0 && ronPoint >= need
+}
+
+// 终局时的顺位分析
+type AllLastAnalysis struct {
+	Standing *model.Standing
+
+	Placement int // 自家当前顺位，0 为一位
+
+	// 升至更高顺位所需的最低打点，下标即目标顺位
+	Targets []*PlacementTarget
+
+	// 放铳给各家时，不掉顺位所能承受的最高荣和点数（不含本场）
+	// 0 表示放铳任意点数都会掉顺位，-1 表示放铳任意点数都不会掉顺位
+	// 自家和不在场的一家为 0
+	MaxDealInPoints []int
+}
+
+// 升一个顺位的目标，当前为一位时返回 nil
+func (a *AllLastAnalysis) NextTarget() *PlacementTarget {
+	if len(a.Targets) == 0 {
+		return nil
+	}
+	return a.Targets[len(a.Targets)-1]
+}
+
+// 分析自家和牌、放铳后的顺位变化
+// 只在终局时有意义，没有场况时返回 nil
+func AnalysisAllLast(standing *model.Standing) *AllLastAnalysis {
+	if standing == nil || len(standing.Scores) == 0 || !standing.IsPresent(0) {
+		return nil
+	}
+
+	const self = 0
+	placement := CalcPlacements(standing, standing.Scores)[self]
+	a := &AllLastAnalysis{
+		Standing:        standing,
+		Placement:       placement,
+		MaxDealInPoints: make([]int, len(standing.Scores)),
+	}
+
+	isParent := standing.Dealer == self
+	isSanma := standing.PlayerNumber() == 3
+	selfRonPoints := allWinPoints(isParent, false, isSanma)
+	selfTsumoPoints := allWinPoints(isParent, true, isSanma)
+	for target := 0; target < placement; target++ {
+		t := &PlacementTarget{
+			Placement:           target,
+			MinRonPoints:        make([]int, len(standing.Scores)),
+			MinTsumoChildPoint:  -1,
+			MinTsumoParentPoint: -1,
+		}
+		for loser := range standing.Scores {
+			if loser == self || !standing.IsPresent(loser) {
+				continue
+			}
+			t.MinRonPoints[loser] = -1
+			for _, p := range selfRonPoints {
+				if CalcPlacements(standing, ScoresAfterRon(standing, self, loser, p.ronPoint))[self] <= target {
+					t.MinRonPoints[loser] = p.ronPoint
+					break
+				}
+			}
+		}
+		for _, p := range selfTsumoPoints {
+			scores := ScoresAfterTsumo(standing, self, p.tsumoChildPoint, p.tsumoParentPoint)
+			if CalcPlacements(standing, scores)[self] <= target {
+				t.MinTsumoChildPoint, t.MinTsumoParentPoint = p.tsumoChildPoint, p.tsumoParentPoint
+				break
+			}
+		}
+		a.Targets = append(a.Targets, t)
+	}
+
+	for winner := range standing.Scores {
+		if winner == self || !standing.IsPresent(winner) {
+			continue
+		}
+		points := allWinPoints(standing.Dealer == winner, false, isSanma)
+		for i, p := range points {
+			if CalcPlacements(standing, ScoresAfterRon(standing, winner, self, p.ronPoint))[self] > placement {
+				break
+			}
+			if i == len(points)-1 {
+				// 放铳两倍役满也不会掉顺位
+				a.MaxDealInPoints[winner] = -1
+			} else {
+				a.MaxDealInPoints[winner] = p.ronPoint
+			}
+		}
+	}
+
+	return a
+}
@@ -0,0 +1,74 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalcPlacements(t *testing.T) {
+	// 下家为起家，同分时下家优先
+	standing := &model.Standing{Scores: make([]int, 4), AbsentPlayer: -1, FirstDealer: 1}
+	assert.Equal(t, []int{1, 0, 3, 2}, CalcPlacements(standing, []int{30000, 30000, 10000, 30000 - 100}))
+
+	// 三麻，上家不存在，对家为起家
+	standing = &model.Standing{Scores: make([]int, 4), AbsentPlayer: 3, FirstDealer: 2}
+	assert.Equal(t, []int{1, 2, 0, -1}, CalcPlacements(standing, []int{35000, 35000, 35000, 0}))
+}
+
+func TestScoresAfterWin(t *testing.T) {
+	standing := &model.Standing{
+		Scores:       []int{25000, 25000, 25000, 24000},
+		AbsentPlayer: -1,
+		Dealer:       2,
+		BenNumber:    1,
+		RiichiSticks: 1,
+	}
+	assert.Equal(t, []int{25000 + 3900 + 300 + 1000, 25000, 25000, 24000 - 3900 - 300}, ScoresAfterRon(standing, 0, 3, 3900))
+	assert.Equal(t, []int{25000 + 4300 + 1000, 25000 - 1100, 25000 - 2100, 24000 - 1100}, ScoresAfterTsumo(standing, 0, 1000, 2000))
+
+	// 三麻自摸损
+	standing.AbsentPlayer = 3
+	assert.Equal(t, []int{25000 + 3200 + 1000, 25000 - 1100, 25000 - 2100, 24000}, ScoresAfterTsumo(standing, 0, 1000, 2000))
+}
+
+func TestAnalysisAllLast(t *testing.T) {
+	assert := assert.New(t)
+
+	// 南4局，上家为庄家，自家为起家，自家四位
+	standing := &model.Standing{
+		Scores:       []int{22000, 30000, 25000, 23000},
+		AbsentPlayer: -1,
+		FirstDealer:  0,
+		RoundNumber:  7,
+		Dealer:       3,
+	}
+	a := AnalysisAllLast(standing)
+	assert.Equal(3, a.Placement)
+	if assert.Len(a.Targets, 3) {
+		// 升至三位：荣和任意一家均可
+		t3 := a.NextTarget()
+		assert.Equal(2, t3.Placement)
+		assert.Equal([]int{0, 1000, 1000, 1000}, t3.MinRonPoints)
+		assert.Equal(300, t3.MinTsumoChildPoint)
+		assert.Equal(500, t3.MinTsumoParentPoint)
+
+		// 升至一位：荣和对家需要满贯（同分时起家优先）
+		t1 := a.Targets[0]
+		assert.Equal(8000, t1.MinRonPoints[2])
+		assert.True(t1.CanRon(2, 8000))
+		assert.False(t1.CanRon(2, 7700))
+	}
+	// 四位放铳不会掉顺位
+	assert.Equal([]int{0, -1, -1, -1}, a.MaxDealInPoints)
+
+	// 自家一位
+	standing.Scores = []int{30000, 22000, 25000, 23000}
+	a = AnalysisAllLast(standing)
+	assert.Equal(0, a.Placement)
+	assert.Empty(a.Targets)
+	assert.Nil(a.NextTarget())
+	assert.Equal([]int{0, 3900, 2300, 3400}, a.MaxDealInPoints)
+
+	assert.Nil(AnalysisAllLast(nil))
+}
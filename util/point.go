@@ -17,7 +17,7 @@ func calcBasicPoint(han int, fu int, yakumanTimes int) (basicPoint int) {
 	switch {
 	case yakumanTimes > 0: // (x倍)役满
 		basicPoint = 8000 * yakumanTimes
-	case han >= 13: // 累计役满
+	case han >= 13 && ruleset.KazoeYakuman: // 累计役满
 		basicPoint = 8000
 	case han >= 11: // 三倍满
 		basicPoint = 6000
@@ -29,6 +29,8 @@ func calcBasicPoint(han int, fu int, yakumanTimes int) (basicPoint int) {
 		basicPoint = fu * (1 << uint(2+han))
 		if basicPoint > 2000 { // 满贯
 			basicPoint = 2000
+		} else if basicPoint == 1920 && ruleset.KiriageMangan { // 切上满贯
+			basicPoint = 2000
 		}
 	}
 	return
@@ -86,14 +88,14 @@ type PointResult struct {
 	agariRate    float64 // 无役时的和率为 0
 }
 
-// 已和牌，计算自摸或荣和时的点数（不考虑里宝、一发等情况）
+// 已和牌，计算自摸或荣和时的点数（立直时计入 UraDoraTiles 中的里宝）
 // 无役时返回的点数为 0（和率也为 0）
-// 调用前请设置 IsTsumo WinTile
+// 调用前请设置 IsTsumo WinTile，一发、海底等状况役需设置 PlayerInfo 中的对应字段
 func CalcPoint(playerInfo *model.PlayerInfo) (result *PointResult) {
 	result = &PointResult{}
 	isNaki := playerInfo.IsNaki()
 	var han, fu int
-	numDora := playerInfo.CountDora()
+	numDora := playerInfo.CountDora() + playerInfo.CountUraDora()
 	for _, divideResult := range DivideTiles34(playerInfo.HandTiles34) {
 		_hi := &_handInfo{
 			PlayerInfo:   playerInfo,
@@ -112,7 +114,11 @@ func CalcPoint(playerInfo *model.PlayerInfo) (result *PointResult) {
 		}
 		var pt int
 		if _hi.IsTsumo {
-			pt = CalcPointTsumoSum(han, fu, yakumanTimes, _hi.IsParent)
+			if _hi.IsSanma {
+				pt = CalcPointTsumoSum3(han, fu, yakumanTimes, _hi.IsParent)
+			} else {
+				pt = CalcPointTsumoSum(han, fu, yakumanTimes, _hi.IsParent)
+			}
 		} else {
 			pt = CalcPointRon(han, fu, yakumanTimes, _hi.IsParent)
 		}
@@ -140,6 +146,11 @@ func CalcPoint(playerInfo *model.PlayerInfo) (result *PointResult) {
 	return
 }
 
+// 自摸时的子家支付点数和亲家支付点数
+func (r *PointResult) TsumoPoints() (childPoint int, parentPoint int) {
+	return CalcPointTsumo(r.han, r.fu, r.yakumanTimes, r.isParent)
+}
+
 // 已听牌，根据 playerInfo 提供的信息计算加权和率后的平均点数
 // 无役时返回 0
 // 有役时返回平均点数（立直时考虑自摸、一发和里宝）和各种侍牌下的对应点数
@@ -186,12 +197,12 @@ func CalcAvgPoint(playerInfo model.PlayerInfo, waits Waits) (avgPoint float64, p
 }
 
 // 计算立直时的平均点数（考虑自摸、一发和里宝）和各种侍牌下的对应点数
-// 已鸣牌时返回 0
-// TODO: 剩余不到 4 张无法立直
-// TODO: 不足 1000 点无法立直
+// 已鸣牌、剩余不到 4 张、不足 1000 点等无法立直时返回 0
 func CalcAvgRiichiPoint(playerInfo model.PlayerInfo, waits Waits) (avgRiichiPoint float64, pointResults []*PointResult) {
-	if playerInfo.IsNaki() {
-		return 0, nil
+	if !playerInfo.IsRiichi {
+		if ok, _ := CanRiichi(&playerInfo); !ok {
+			return 0, nil
+		}
 	}
 	playerInfo.IsRiichi = true
 	return CalcAvgPoint(playerInfo, waits)
@@ -74,6 +74,8 @@ func TestCalcRonPointWithHands(t *testing.T) {
 	assert.Equal(32000, CalcPoint(newPIWithWinTile("11122345678999m", "3m")).Point)
 	assert.Equal(64000, CalcPoint(newPIWithWinTile("11122345678999m", "2m")).Point)
 	assert.Equal(160000, CalcPoint(newPIWithWinTile("11122233344455z", "5z")).Point)
+	assert.Equal(32000, CalcPoint(newPIWithWinTile("19m 19p 19s 12345677z", "1z")).Point) // [国士]
+	assert.Equal(64000, CalcPoint(newPIWithWinTile("19m 19p 19s 12345677z", "7z")).Point) // [国士十三面]
 
 	// 子家立直荣和
 	newPIWithRiichi := func(humanTiles string, winHumanTile string) *model.PlayerInfo {
@@ -130,6 +132,8 @@ func TestCalcRonPointWithHands(t *testing.T) {
 			HandTiles34:   tiles34,
 			RoundWindTile: MustStrToTile34("2z"),
 			SelfWindTile:  MustStrToTile34("2z"),
+
+			LeftDrawTilesCount: model.LeftDrawTilesCountUnknown,
 		}, waits
 	}
 	assert.InDelta(3700, first(CalcAvgRiichiPoint(newPIWithWaits("34m 123567p 12355s"))), eps)   // 立直平和
@@ -145,11 +149,66 @@ func TestCalcRonPointWithHands(t *testing.T) {
 			RoundWindTile: MustStrToTile34("2z"),
 			SelfWindTile:  MustStrToTile34("2z"),
 			DiscardTiles:  MustStrToTiles(humanDiscardTiles),
+
+			LeftDrawTilesCount: model.LeftDrawTilesCountUnknown,
 		}, waits
 	}
 	assert.InDelta(4070, first(CalcAvgRiichiPoint(newFuritenPIWithWaits("45678m 123p 56799s", "9m"))), eps) // 立直平和(自摸)
 }
 
+func TestCalcPointWithSituationalYaku(t *testing.T) {
+	assert := assert.New(t)
+
+	newPI := func(humanTiles string, winHumanTile string, isTsumo bool, melds ...model.Meld) *model.PlayerInfo {
+		return &model.PlayerInfo{
+			HandTiles34:   MustStrToTiles34(humanTiles),
+			Melds:         melds,
+			IsTsumo:       isTsumo,
+			WinTile:       MustStrToTile34(winHumanTile),
+			RoundWindTile: MustStrToTile34("2z"),
+			SelfWindTile:  MustStrToTile34("3z"),
+		}
+	}
+	pon := model.Meld{MeldType: model.MeldTypePon, Tiles: MustStrToTiles("333s")}
+	minkan := model.Meld{MeldType: model.MeldTypeMinkan, Tiles: MustStrToTiles("3333s")}
+
+	// 立直一发 40符2番
+	pi := newPI("345m 222789p 333s 66z", "3m", false)
+	pi.IsRiichi = true
+	pi.IsIppatsu = true
+	assert.Equal(2600, CalcPoint(pi).Point)
+
+	// 海底 30符1番 300-500
+	pi = newPI("345m 222789p 66z", "3m", true, pon)
+	pi.IsLastTile = true
+	assert.Equal(1100, CalcPoint(pi).Point)
+
+	// 河底 30符1番
+	pi = newPI("345m 222789p 66z", "3m", false, pon)
+	pi.IsLastTile = true
+	assert.Equal(1000, CalcPoint(pi).Point)
+
+	// 抢杠 30符1番
+	pi = newPI("345m 222789p 66z", "3m", false, pon)
+	pi.IsChankan = true
+	assert.Equal(1000, CalcPoint(pi).Point)
+
+	// 岭上 40符1番 400-700
+	pi = newPI("345m 222789p 66z", "3m", true, minkan)
+	pi.IsRinshan = true
+	assert.Equal(1500, CalcPoint(pi).Point)
+
+	// 天和
+	pi = newPI("345m 222789p 333s 66z", "3m", true)
+	pi.IsParent = true
+	pi.IsFirstDraw = true
+	assert.Equal(48000, CalcPoint(pi).Point)
+
+	// 地和
+	pi.IsParent = false
+	assert.Equal(32000, CalcPoint(pi).Point)
+}
+
 func BenchmarkCalcAvgRiichiPoint(b *testing.B) {
 	humanTiles := "11123678m 11122z" // 三面
 	tiles34 := MustStrToTiles34(humanTiles)
@@ -167,3 +226,68 @@ func BenchmarkCalcAvgRiichiPoint(b *testing.B) {
 		CalcAvgRiichiPoint(playerInfo, waits)
 	}
 }
+
+func TestCalcPointWithRuleset(t *testing.T) {
+	assert := assert.New(t)
+
+	defer SetRuleset(CurrentRuleset())
+
+	newPI := func(humanTiles string, winHumanTile string, melds ...model.Meld) *model.PlayerInfo {
+		return &model.PlayerInfo{
+			HandTiles34:   MustStrToTiles34(humanTiles),
+			Melds:         melds,
+			WinTile:       MustStrToTile34(winHumanTile),
+			RoundWindTile: MustStrToTile34("2z"),
+			SelfWindTile:  MustStrToTile34("3z"),
+		}
+	}
+
+	// 切上满贯
+	SetRuleset(RulesetMajsoul)
+	assert.Equal(7700, CalcPointRon(4, 30, 0, false))
+	SetRuleset(RulesetMLeague)
+	assert.Equal(8000, CalcPointRon(4, 30, 0, false))
+	assert.Equal(12000, CalcPointRon(3, 60, 0, true))
+
+	// 累计役满
+	SetRuleset(RulesetMajsoul)
+	assert.Equal(32000, CalcPointRon(13, 30, 0, false))
+	SetRuleset(RulesetWRC)
+	assert.Equal(24000, CalcPointRon(13, 30, 0, false))
+
+	// 两倍役满
+	kokushi13 := newPI("19m 19p 19s 12345677z", "7z")
+	SetRuleset(RulesetMajsoul)
+	assert.Equal(64000, CalcPoint(kokushi13).Point)
+	SetRuleset(RulesetTenhou)
+	assert.Equal(32000, CalcPoint(kokushi13).Point)
+
+	// 役满复合
+	daisangenTsuuiisou := newPI("11122z 555666777z", "1z")
+	assert.Equal(64000, CalcPoint(daisangenTsuuiisou).Point)
+	r := RulesetTenhou
+	r.MultipleYakuman = false
+	SetRuleset(r)
+	assert.Equal(32000, CalcPoint(daisangenTsuuiisou).Point)
+
+	// 食断
+	openTanyao := newPI("234m 567p 23488s", "2s", model.Meld{MeldType: model.MeldTypePon, Tiles: MustStrToTiles("666s")})
+	SetRuleset(RulesetTenhou)
+	assert.Equal(1000, CalcPoint(openTanyao).Point)
+	r = RulesetTenhou
+	r.OpenTanyao = false
+	SetRuleset(r)
+	assert.Equal(0, CalcPoint(openTanyao).Point)
+
+	// 人和
+	renhou := newPI("345m 222789p 333s 66z", "3m")
+	renhou.IsFirstDraw = true
+	SetRuleset(RulesetTenhou)
+	assert.Equal(0, CalcPoint(renhou).Point)
+	r.RenhouHan = 5
+	SetRuleset(r)
+	assert.Equal(8000, CalcPoint(renhou).Point)
+	r.RenhouHan = 13
+	SetRuleset(r)
+	assert.Equal(32000, CalcPoint(renhou).Point)
+}
@@ -0,0 +1,323 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// 段位战的顺位点（pt）
+type RankPointTable struct {
+	Name string
+
+	// 各顺位获得的 pt，下标为顺位（0 为一位），半庄战和东风战分别设置
+	// 三麻时取下标 0 1 3
+	Hanchan [4]float64
+	Tonpuu  [4]float64
+
+	// pt 是否还要加上终局点数与基准点数之差（每 1000 点 1pt），如雀魂段位战
+	// 基准点数四麻为 25000，三麻为 35000，与当前规则的马和返点无关
+	IncludeScoreDelta bool
+}
+
+// 雀魂段位战计算点数差时的基准点数
+const (
+	rankPointScoreBase      = 25000
+	rankPointScoreBaseSanma = 35000
+)
+
+// 天凤凤凰卓，四位扣分随段位增加
+func TenhouRankPointTable(dan int) RankPointTable {
+	return RankPointTable{
+		Name:    fmt.Sprintf("天凤凤凰卓%d段", dan),
+		Hanchan: [4]float64{90, 45, 0, -15 * float64(dan)},
+		Tonpuu:  [4]float64{60, 30, 0, -10 * float64(dan)},
+	}
+}
+
+// 雀魂玉之间（雀豪），四位扣分随段位不同，可以用自定义 pt 覆盖
+var RankPointTableMajsoulJade = RankPointTable{
+	Name:              "雀魂玉之间",
+	Hanchan:           [4]float64{110, 55, 0, -150},
+	Tonpuu:            [4]float64{55, 30, 0, -75},
+	IncludeScoreDelta: true,
+}
+
+// 根据名称获取 pt 表
+// tenhou 为天凤凤凰卓七段，tenhouN 为天凤凤凰卓 N 段，majsoul 为雀魂玉之间
+// 也可以直接指定各顺位的 pt，如 90,45,0,-135
+func RankPointTableByName(name string) (RankPointTable, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	switch {
+	case name == "majsoul":
+		return RankPointTableMajsoulJade, nil
+	case name == "tenhou":
+		return TenhouRankPointTable(7), nil
+	case strings.HasPrefix(name, "tenhou"):
+		dan, err := strconv.Atoi(name[len("tenhou"):])
+		if err != nil || dan < 1 || dan > 10 {
+			return RankPointTable{}, fmt.Errorf("天凤段位应为 1-10：%s", name)
+		}
+		return TenhouRankPointTable(dan), nil
+	}
+
+	splits := strings.Split(name, ",")
+	if len(splits) != 4 {
+		return RankPointTable{}, fmt.Errorf("未知 pt 表 %s，可选 tenhou tenhouN majsoul，或以逗号分隔的四个顺位的 pt", name)
+	}
+	t := RankPointTable{Name: name}
+	for i, split := range splits {
+		pt, err := strconv.ParseFloat(strings.TrimSpace(split), 64)
+		if err != nil {
+			return RankPointTable{}, fmt.Errorf("pt 格式错误：%s", split)
+		}
+		t.Hanchan[i] = pt
+		t.Tonpuu[i] = pt
+	}
+	return t, nil
+}
+
+// 某一顺位获得的 pt
+func (t RankPointTable) placementPoint(standing *model.Standing, placement int) float64 {
+	points := t.Hanchan
+	if standing.IsTonpuu {
+		points = t.Tonpuu
+	}
+	if standing.PlayerNumber() == 3 {
+		return [3]float64{points[0], points[1], points[3]}[placement]
+	}
+	return points[placement]
+}
+
+// 当前点数为 scores、之后还剩 rounds 局时，自家终局的期望 pt
+func (t RankPointTable) ExpectedPoint(standing *model.Standing, scores []int, rounds int) float64 {
+	pt := 0.0
+	for placement, p := range PlacementProbabilities(standing, scores, rounds) {
+		pt += p * t.placementPoint(standing, placement)
+	}
+	if t.IncludeScoreDelta {
+		// 点数差关于点数是线性的，其期望只与当前点数有关
+		base := rankPointScoreBase
+		if standing.PlayerNumber() == 3 {
+			base = rankPointScoreBaseSanma
+		}
+		pt += float64(scores[0]-base) / 1000
+	}
+	return pt
+}
+
+// 以下为终局顺位的估计
+
+// 每局各家点数变动的标准差（经验值）
+const scoreStdDevPerRound = 5000.0
+
+// 标准正态分布的分布函数
+func normalCDF(x float64) float64 {
+	return 0.5 * (1 + math.Erf(x/math.Sqrt2))
+}
+
+// 当前点数为 scores、之后还剩 rounds 局时，自家终局各顺位的概率，下标为顺位
+// 假设各家终局点数独立地服从以当前点数为均值、方差与剩余局数成正比的正态分布
+// rounds 为 0 时直接根据当前点数计算顺位
+func PlacementProbabilities(standing *model.Standing, scores []int, rounds int) []float64 {
+	const self = 0
+	probabilities := make([]float64, standing.PlayerNumber())
+	if rounds <= 0 {
+		probabilities[CalcPlacements(standing, scores)[self]] = 1
+		return probabilities
+	}
+
+	sigma := scoreStdDevPerRound * math.Sqrt(float64(rounds))
+
+	// 对自家的点数变动做数值积分，取 [-4σ, 4σ]
+	const steps = 40
+	sumWeight := 0.0
+	for i := -steps; i <= steps; i++ {
+		z := 4 * float64(i) / steps
+		weight := math.Exp(-z * z / 2)
+		selfScore := float64(scores[self]) + z*sigma
+
+		// 比自家点数高的人数的分布
+		higher := []float64{1}
+		for who, score := range scores {
+			if who == self || !standing.IsPresent(who) {
+				continue
+			}
+			p := normalCDF((float64(score) - selfScore) / sigma)
+			next := make([]float64, len(higher)+1)
+			for c, q := range higher {
+				next[c] += q * (1 - p)
+				next[c+1] += q * p
+			}
+			higher = next
+		}
+
+		for placement, q := range higher {
+			probabilities[placement] += weight * q
+		}
+		sumWeight += weight
+	}
+	for i := range probabilities {
+		probabilities[i] /= sumWeight
+	}
+	return probabilities
+}
+
+// 以下为本局结果的估计
+
+// 本局结束时的一种可能结果
+type roundOutcome struct {
+	probability float64
+	scores      []int
+}
+
+// 自家点数变动 delta，其余在场的人平摊
+func scoresAfterTransfer(standing *model.Standing, delta int) []int {
+	scores := append([]int{}, standing.Scores...)
+	scores[0] += delta
+	for who := range scores {
+		if who != 0 && standing.IsPresent(who) {
+			scores[who] -= delta / (standing.PlayerNumber() - 1)
+		}
+	}
+	return scores
+}
+
+const (
+	// 他家听牌时和牌的概率（经验值）
+	opponentAgariRate = 0.5
+
+	// 他家和牌时自摸的比例（经验值）
+	opponentTsumoRate = 0.4
+
+	// 他家和牌的概率之和的上限，剩下的为流局等情况
+	maxOpponentsAgariRate = 0.8
+)
+
+// 他家 o 自摸后各家的点数
+func scoresAfterOpponentTsumo(standing *model.Standing, o *OpponentRisk) []int {
+	point := int(math.Round(o.RonPoint))
+	if o.Who == standing.Dealer {
+		childPoint := roundUpPoint(point / 3)
+		return ScoresAfterTsumo(standing, o.Who, childPoint, childPoint)
+	}
+	return ScoresAfterTsumo(standing, o.Who, roundUpPoint(point/4), roundUpPoint(point/2))
+}
+
+// 自家既未放铳也未和牌时，他家和牌的各种结果
+// 他家和牌的概率为听牌率乘以 opponentAgariRate，按 opponentTsumoRate 区分自摸和荣和其余他家
+func opponentAgariOutcomes(standing *model.Standing, opponents OpponentRiskList) (outcomes []roundOutcome) {
+	sumRate := 0.0
+	for _, o := range opponents {
+		if standing.IsPresent(o.Who) {
+			sumRate += o.TenpaiRate / 100 * opponentAgariRate
+		}
+	}
+	if sumRate == 0 {
+		return
+	}
+	scale := math.Min(1, maxOpponentsAgariRate/sumRate)
+
+	for _, o := range opponents {
+		if !standing.IsPresent(o.Who) {
+			continue
+		}
+		p := o.TenpaiRate / 100 * opponentAgariRate * scale
+		if p == 0 {
+			continue
+		}
+
+		var losers []int
+		for who := 1; who < len(standing.Scores); who++ {
+			if who != o.Who && standing.IsPresent(who) {
+				losers = append(losers, who)
+			}
+		}
+		tsumoRate := opponentTsumoRate
+		if len(losers) == 0 {
+			tsumoRate = 1
+		}
+		outcomes = append(outcomes, roundOutcome{p * tsumoRate, scoresAfterOpponentTsumo(standing, o)})
+		for _, loser := range losers {
+			outcomes = append(outcomes, roundOutcome{p * (1 - tsumoRate) / float64(len(losers)), ScoresAfterRon(standing, o.Who, loser, int(math.Round(o.RonPoint)))})
+		}
+	}
+	return
+}
+
+// 切牌后本局结束时的各种结果
+// 放铳给各家的概率为听牌率乘以铳率；不放铳时，听牌则按和率区分和牌与否
+// 自家未和牌时，再区分他家自摸、他家荣和其余他家（见 opponentAgariOutcomes）和其余情况
+// 其余情况的点数变动使得自家点数变动的期望与局收支一致（未听牌时为 evRoundPoint）
+func discardOutcomes(standing *model.Standing, opponents OpponentRiskList, result14 *Hand14AnalysisResult) (outcomes []roundOutcome) {
+	noDealInRate := 1.0
+	for _, o := range opponents {
+		p := o.TenpaiRate / 100 * o.RiskTiles34[result14.DiscardTile] / 100
+		if p == 0 || !standing.IsPresent(o.Who) {
+			continue
+		}
+		outcomes = append(outcomes, roundOutcome{p, ScoresAfterRon(standing, o.Who, 0, int(math.Round(o.RonPoint)))})
+		noDealInRate -= p
+	}
+	if noDealInRate <= 0 {
+		return
+	}
+
+	r13 := result14.Result13
+	agariRate := r13.AvgAgariRate / 100
+	winPoint := r13.DamaPoint
+	if r13.RiichiPoint > 0 {
+		winPoint = r13.RiichiPoint
+	}
+
+	// 自家未和牌时的点数变动期望
+	restDelta := r13.evRoundPoint()
+	if r13.Shanten == 0 && agariRate > 0 && winPoint > 0 {
+		winScores := scoresAfterTransfer(standing, int(math.Round(winPoint))+300*standing.BenNumber)
+		winScores[0] += 1000 * standing.RiichiSticks
+		outcomes = append(outcomes, roundOutcome{noDealInRate * agariRate, winScores})
+		if agariRate >= 1 {
+			return
+		}
+		restDelta = (r13.MixedRoundPoint - agariRate*winPoint) / (1 - agariRate)
+	} else {
+		agariRate = 0
+	}
+	restRate := noDealInRate * (1 - agariRate)
+
+	otherRate := 1.0
+	for _, o := range opponentAgariOutcomes(standing, opponents) {
+		outcomes = append(outcomes, roundOutcome{restRate * o.probability, o.scores})
+		otherRate -= o.probability
+		restDelta -= o.probability * float64(o.scores[0]-standing.Scores[0])
+	}
+	outcomes = append(outcomes, roundOutcome{restRate * otherRate, scoresAfterTransfer(standing, int(math.Round(restDelta/otherRate)))})
+	return
+}
+
+// 切这张牌的期望 pt
+func (t RankPointTable) DiscardRankPoint(standing *model.Standing, opponents OpponentRiskList, result14 *Hand14AnalysisResult) float64 {
+	rounds := standing.RemainingRounds()
+	pt := 0.0
+	for _, o := range discardOutcomes(standing, opponents, result14) {
+		pt += o.probability * t.ExpectedPoint(standing, o.scores, rounds)
+	}
+	return pt
+}
+
+// 计算各个切牌的期望 pt，并按照期望 pt 从高到低排序
+// 在 SortByEV 之后调用，期望 pt 相同时保持原有顺序
+func (l Hand14AnalysisResultList) SortByRankPoint(opponents OpponentRiskList, standing *model.Standing, table RankPointTable) {
+	if standing == nil || !standing.IsPresent(0) {
+		return
+	}
+	for _, r := range l {
+		r.RankPointEV = table.DiscardRankPoint(standing, opponents, r)
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		return l[i].RankPointEV > l[j].RankPointEV
+	})
+}
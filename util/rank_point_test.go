@@ -0,0 +1,184 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRankPointTableByName(t *testing.T) {
+	assert := assert.New(t)
+
+	table, err := RankPointTableByName("tenhou")
+	assert.NoError(err)
+	assert.Equal([4]float64{90, 45, 0, -105}, table.Hanchan)
+	assert.Equal([4]float64{60, 30, 0, -70}, table.Tonpuu)
+
+	table, err = RankPointTableByName("Tenhou10")
+	assert.NoError(err)
+	assert.Equal(-150.0, table.Hanchan[3])
+
+	table, err = RankPointTableByName("majsoul")
+	assert.NoError(err)
+	assert.True(table.IncludeScoreDelta)
+
+	table, err = RankPointTableByName("50, 10, -10, -30")
+	assert.NoError(err)
+	assert.Equal([4]float64{50, 10, -10, -30}, table.Hanchan)
+	assert.Equal(table.Hanchan, table.Tonpuu)
+
+	for _, name := range []string{"tenhou11", "tenhoux", "mleague", "1,2,3", "1,2,3,x"} {
+		_, err = RankPointTableByName(name)
+		assert.Error(err, name)
+	}
+}
+
+func TestStanding_IsFinalRounds(t *testing.T) {
+	standing := &model.Standing{RoundNumber: 3}
+	assert.False(t, standing.IsFinalRounds())
+	assert.Equal(t, 4, standing.RemainingRounds())
+	standing.RoundNumber = 4
+	assert.True(t, standing.IsFinalRounds())
+	standing.RoundNumber = 8
+	assert.Equal(t, 0, standing.RemainingRounds())
+
+	standing = &model.Standing{IsTonpuu: true, RoundNumber: 1}
+	assert.False(t, standing.IsFinalRounds())
+	standing.RoundNumber = 2
+	assert.True(t, standing.IsFinalRounds())
+	assert.Equal(t, 1, standing.RemainingRounds())
+
+	// 三麻半庄战，南3局为终局
+	standing = &model.Standing{Scores: []int{35000, 35000, 0, 35000}, AbsentPlayer: 2, RoundNumber: 2}
+	assert.False(t, standing.IsFinalRounds())
+	assert.Equal(t, 3, standing.RemainingRounds())
+	standing.RoundNumber = 4
+	assert.True(t, standing.IsFinalRounds())
+	assert.Equal(t, 2, standing.RemainingRounds())
+	standing.RoundNumber = 6
+	assert.True(t, standing.IsAllLast())
+	assert.Equal(t, 0, standing.RemainingRounds())
+	standing.RoundNumber = 8
+	assert.True(t, standing.IsAllLast())
+
+	// 三麻东风战，东3局为终局
+	standing = &model.Standing{Scores: []int{35000, 35000, 0, 35000}, AbsentPlayer: 2, IsTonpuu: true}
+	assert.False(t, standing.IsFinalRounds())
+	standing.RoundNumber = 1
+	assert.True(t, standing.IsFinalRounds())
+	assert.False(t, standing.IsAllLast())
+	standing.RoundNumber = 2
+	assert.True(t, standing.IsAllLast())
+}
+
+func TestPlacementProbabilities(t *testing.T) {
+	assert := assert.New(t)
+
+	standing := &model.Standing{Scores: make([]int, 4), AbsentPlayer: -1}
+
+	// 终局时顺位是确定的
+	assert.Equal([]float64{0, 0, 1, 0}, PlacementProbabilities(standing, []int{25000, 30000, 35000, 10000}, 0))
+
+	// 同分时各顺位的概率相同
+	for _, p := range PlacementProbabilities(standing, []int{25000, 25000, 25000, 25000}, 4) {
+		assert.InDelta(0.25, p, 0.01)
+	}
+
+	// 点数领先越多、剩余局数越少，一位率越高
+	sum := 0.0
+	probabilities := PlacementProbabilities(standing, []int{35000, 25000, 20000, 20000}, 4)
+	for _, p := range probabilities {
+		sum += p
+	}
+	assert.InDelta(1, sum, 1e-9)
+	assert.True(probabilities[0] > 0.5)
+	assert.True(PlacementProbabilities(standing, []int{35000, 25000, 20000, 20000}, 1)[0] > probabilities[0])
+	assert.True(PlacementProbabilities(standing, []int{45000, 25000, 15000, 15000}, 4)[0] > probabilities[0])
+
+	// 三麻
+	standing = &model.Standing{Scores: make([]int, 4), AbsentPlayer: 3}
+	assert.Len(PlacementProbabilities(standing, []int{35000, 35000, 35000, 0}, 2), 3)
+}
+
+func TestRankPointTable_ExpectedPoint(t *testing.T) {
+	assert := assert.New(t)
+
+	// 雀魂只加上点数差，与当前规则的马和返点无关
+	defer SetRuleset(CurrentRuleset())
+	SetRuleset(RulesetTenhou)
+	standing := &model.Standing{Scores: make([]int, 4), AbsentPlayer: -1}
+	assert.InDelta(110+15, RankPointTableMajsoulJade.ExpectedPoint(standing, []int{40000, 30000, 20000, 10000}, 0), 1e-9)
+	assert.InDelta(-150-15, RankPointTableMajsoulJade.ExpectedPoint(standing, []int{10000, 30000, 20000, 40000}, 0), 1e-9)
+	assert.InDelta(90, TenhouRankPointTable(7).ExpectedPoint(standing, []int{40000, 30000, 20000, 10000}, 0), 1e-9)
+
+	// 三麻的基准点数为 35000
+	standing = &model.Standing{Scores: make([]int, 4), AbsentPlayer: 3}
+	assert.InDelta(110+10, RankPointTableMajsoulJade.ExpectedPoint(standing, []int{45000, 35000, 25000, 0}, 0), 1e-9)
+	assert.InDelta(-150-10, RankPointTableMajsoulJade.ExpectedPoint(standing, []int{25000, 35000, 45000, 0}, 0), 1e-9)
+}
+
+func TestDiscardOutcomes(t *testing.T) {
+	assert := assert.New(t)
+
+	standing := &model.Standing{Scores: []int{25000, 25000, 25000, 25000}, AbsentPlayer: -1, Dealer: 1}
+	opponents := OpponentRiskList{newTestOpponent(3, 50, 8000, 20), newTestOpponent(1, 20, 5800, 0, 10)}
+	tenpaiResult := newTestResult14(0, 0, 1800)
+	tenpaiResult.Result13.AvgAgariRate = 40
+	tenpaiResult.Result13.DamaPoint = 3900
+
+	for _, result14 := range []*Hand14AnalysisResult{tenpaiResult, newTestResult14(0, 1, 500), newTestResult14(1, 1, 500)} {
+		// 点数期望与期望收支一致
+		sumP, ev := 0.0, 0.0
+		outcomes := discardOutcomes(standing, opponents, result14)
+		for _, o := range outcomes {
+			sumP += o.probability
+			ev += o.probability * float64(o.scores[0]-standing.Scores[0])
+		}
+		assert.InDelta(1, sumP, 1e-9)
+		assert.InDelta(opponents.DiscardEV(result14), ev, 1)
+
+		// 他家自摸时自家也要支付
+		tsumoP := 0.0
+		for _, o := range outcomes {
+			if o.scores[0] == 25000-2000 && o.scores[3] == 25000+8000 {
+				tsumoP += o.probability
+			}
+		}
+		assert.True(tsumoP > 0)
+	}
+
+	// 三麻时他家只能荣和另一家或自摸
+	standing = &model.Standing{Scores: []int{35000, 35000, 35000, 0}, AbsentPlayer: 3, Dealer: 1}
+	outcomes := opponentAgariOutcomes(standing, OpponentRiskList{newTestOpponent(2, 100, 8000)})
+	if assert.Len(outcomes, 2) {
+		assert.InDelta(opponentAgariRate*opponentTsumoRate, outcomes[0].probability, 1e-9)
+		assert.Equal([]int{35000 - 2000, 35000 - 4000, 35000 + 6000, 0}, outcomes[0].scores)
+		assert.Equal([]int{35000, 35000 - 8000, 35000 + 8000, 0}, outcomes[1].scores)
+	}
+}
+
+func TestHand14AnalysisResultList_SortByRankPoint(t *testing.T) {
+	// 南4局，自家小幅领先，四位立直，四位自摸时会超过自家
+	// 切 1m 局收支高，放铳后会掉到三位；切 3m 为现物
+	standing := &model.Standing{
+		Scores:       []int{32000, 30000, 15000, 22000},
+		AbsentPlayer: -1,
+		RiichiSticks: 1,
+		RoundNumber:  7,
+		Dealer:       1,
+	}
+	opponents := OpponentRiskList{newTestOpponent(3, 100, 8000, 15)}
+	l := Hand14AnalysisResultList{newTestResult14(0, 1, 3000), newTestResult14(2, 1, 0)}
+
+	// 按期望收支切 1m
+	l.SortByEV(opponents)
+	assert.Equal(t, 0, l[0].DiscardTile)
+
+	// 按期望 pt 切 3m
+	// 不放铳时，四位自摸则自家为二位，其余情况自家为一位
+	l.SortByRankPoint(opponents, standing, TenhouRankPointTable(7))
+	assert.Equal(t, 2, l[0].DiscardTile)
+	safePoint := (1-opponentAgariRate*opponentTsumoRate)*90 + opponentAgariRate*opponentTsumoRate*45
+	assert.InDelta(t, safePoint, l[0].RankPointEV, 1e-9)
+	assert.InDelta(t, 0.85*safePoint+0.15*0, l[1].RankPointEV, 1e-9)
+}
@@ -0,0 +1,184 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+)
+
+// 能否立直：门清、剩余不少于 4 张（三麻为 3 张）、点数不少于 1000
+// 剩余牌数未知或没有场况时视作可以立直
+func CanRiichi(playerInfo *model.PlayerInfo) (ok bool, reason string) {
+	if playerInfo.IsNaki() {
+		return false, "已副露，无法立直"
+	}
+	minLeftDrawTilesCount := 4
+	if playerInfo.IsSanma {
+		minLeftDrawTilesCount = 3
+	}
+	if left := playerInfo.LeftDrawTilesCount; left != model.LeftDrawTilesCountUnknown && left < minLeftDrawTilesCount {
+		return false, fmt.Sprintf("剩余不到 %d 张，无法立直", minLeftDrawTilesCount)
+	}
+	if s := playerInfo.Standing; s != nil && len(s.Scores) > 0 && s.Scores[0] < 1000 {
+		return false, "不足 1000 点，无法立直"
+	}
+	return true, ""
+}
+
+// 听牌时的立直判断
+const (
+	RiichiVerdictRiichi    = iota // 立直
+	RiichiVerdictDama             // 默听
+	RiichiVerdictTsumoOnly        // 默听，但只能自摸（无役或振听）
+)
+
+var RiichiVerdictNames = []string{"立直", "默听", "默听自摸"}
+
+const (
+	// 默听无役时，自摸占和了的比例（经验值）
+	damaTsumoRatio = 0.35
+
+	// 立直后无法弃和，有人听牌时，未和牌的局中额外放铳的概率（经验值）
+	riichiLockedDealInRate = 0.2
+
+	// 和率数据对应的剩余巡目（6~10 巡目听牌）
+	agariRateBaseLeftTurns = 10.0
+)
+
+type RiichiAdvice struct {
+	Verdict int
+
+	CanRiichi bool
+
+	// 立直和默听的和率 (0-100)，已根据剩余巡目修正
+	// 默听只能自摸时，DamaAgariRate 为自摸的和率
+	RiichiAgariRate float64
+	DamaAgariRate   float64
+
+	// 立直和默听的平均打点
+	RiichiPoint float64
+	DamaPoint   float64
+
+	// 立直和默听的期望收支
+	RiichiEV float64
+	DamaEV   float64
+
+	// 判断依据
+	Reasons []string
+}
+
+func (a *RiichiAdvice) String() string {
+	return RiichiVerdictNames[a.Verdict]
+}
+
+// 剩余巡目较少时，和率按比例减少
+// 剩余牌数未知时不做修正
+func agariRateLeftTurnsMulti(leftDrawTilesCount int) float64 {
+	if leftDrawTilesCount == model.LeftDrawTilesCountUnknown {
+		return 1
+	}
+	return math.Min(1, float64(leftDrawTilesCount)/4/agariRateBaseLeftTurns)
+}
+
+// 默听时他家不会特意避开自家的筋牌，待牌均按筋牌计算和率
+var damaTileType27 = func() []tileType {
+	allTiles := make([]int, 27)
+	for i := range allTiles {
+		allTiles[i] = i
+	}
+	return calcTileType27(allTiles)
+}()
+
+// 切牌后听牌时，比较立直和默听的期望收支，给出立直、默听或默听自摸的判断
+// 立直的和率根据舍牌（含宣言牌）的筋牌类型计算，立直需支付 1000 点供托，且有人听牌时立直后无法弃和
+// 切牌后未听牌、已副露或已立直时返回 nil
+func CalcRiichiAdvice(playerInfo *model.PlayerInfo, result14 *Hand14AnalysisResult, opponents OpponentRiskList) *RiichiAdvice {
+	r13 := result14.Result13
+	if r13.Shanten != 0 || playerInfo.IsRiichi || playerInfo.IsNaki() || len(result14.OpenTiles) > 0 {
+		return nil
+	}
+
+	isRedFive := playerInfo.IsOnlyRedFive(result14.DiscardTile)
+	playerInfo.DiscardTile(result14.DiscardTile, isRedFive)
+	defer playerInfo.UndoDiscardTile(result14.DiscardTile, isRedFive)
+
+	canRiichi, cannotRiichiReason := CanRiichi(playerInfo)
+	a := &RiichiAdvice{CanRiichi: canRiichi}
+	turnsMulti := agariRateLeftTurnsMulti(playerInfo.LeftDrawTilesCount)
+	isFuriten := playerInfo.IsFuriten(r13.Waits)
+
+	// 默听
+	isTsumoOnly := isFuriten || r13.DamaWaits.AllCount() == 0
+	if isTsumoOnly {
+		a.Verdict = RiichiVerdictTsumoOnly
+		pi := *playerInfo
+		pi.IsTsumo = true
+		a.DamaPoint, _ = CalcAvgPoint(pi, r13.Waits)
+		a.DamaAgariRate = calculateAvgAgariRate(r13.Waits, playerInfo, damaTileType27) * turnsMulti
+		if !isFuriten {
+			// 振听时的和率已经只考虑了自摸
+			a.DamaAgariRate *= damaTsumoRatio
+		}
+	} else {
+		a.Verdict = RiichiVerdictDama
+		a.DamaPoint = r13.DamaPoint
+		a.DamaAgariRate = calculateAvgAgariRate(r13.DamaWaits, playerInfo, damaTileType27) * turnsMulti
+	}
+	a.DamaEV = a.DamaAgariRate / 100 * a.DamaPoint
+
+	if !canRiichi {
+		a.Reasons = append(a.Reasons, cannotRiichiReason)
+		return a
+	}
+
+	// 立直
+	a.RiichiPoint = r13.RiichiPoint
+	a.RiichiAgariRate = CalculateAvgAgariRate(r13.Waits, playerInfo) * turnsMulti
+	pR := a.RiichiAgariRate / 100
+	a.RiichiEV = pR*a.RiichiPoint - (1-pR)*1000
+
+	// 有人听牌时，立直后无法弃和
+	notTenpaiRate := 1.0
+	ronLoss := 0.0
+	for _, o := range opponents {
+		notTenpaiRate *= 1 - o.TenpaiRate/100
+		ronLoss += o.TenpaiRate / 100 * o.RonPoint
+	}
+	threat := 1 - notTenpaiRate
+	if threat > 0 {
+		a.RiichiEV -= (1 - pR) * riichiLockedDealInRate * ronLoss
+	}
+
+	if a.RiichiEV > a.DamaEV {
+		a.Verdict = RiichiVerdictRiichi
+	}
+
+	damaName := RiichiVerdictNames[RiichiVerdictDama]
+	if isTsumoOnly {
+		if isFuriten {
+			a.Reasons = append(a.Reasons, "振听，默听只能自摸")
+		} else {
+			a.Reasons = append(a.Reasons, "无役，默听只能自摸")
+		}
+		damaName = RiichiVerdictNames[RiichiVerdictTsumoOnly]
+	}
+	a.Reasons = append(a.Reasons,
+		fmt.Sprintf("立直和率%.1f%% 打点%d，%s和率%.1f%% 打点%d", a.RiichiAgariRate, int(math.Round(a.RiichiPoint)), damaName, a.DamaAgariRate, int(math.Round(a.DamaPoint))),
+	)
+	if turnsMulti < 1 {
+		a.Reasons = append(a.Reasons, fmt.Sprintf("剩余 %d 张，和率降低", playerInfo.LeftDrawTilesCount))
+	}
+	a.Reasons = append(a.Reasons, "立直需支付 1000 点供托，未和牌时失去")
+	if threat > 0 {
+		a.Reasons = append(a.Reasons, fmt.Sprintf("他家听牌率%.0f%%，立直后无法弃和", threat*100))
+	}
+	a.Reasons = append(a.Reasons, fmt.Sprintf("期望收支：立直%+d %s%+d", int(math.Round(a.RiichiEV)), damaName, int(math.Round(a.DamaEV))))
+	return a
+}
+
+// 计算各个切牌的立直判断
+func (l Hand14AnalysisResultList) CalcRiichiAdvices(playerInfo *model.PlayerInfo, opponents OpponentRiskList) {
+	for _, r := range l {
+		r.RiichiAdvice = CalcRiichiAdvice(playerInfo, r, opponents)
+	}
+}
@@ -0,0 +1,111 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCanRiichi(t *testing.T) {
+	assert := assert.New(t)
+
+	// 剩余牌数未知时可以立直
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("123789m 234p 5568s 1z"), nil)
+	assert.Equal(model.LeftDrawTilesCountUnknown, playerInfo.LeftDrawTilesCount)
+	ok, _ := CanRiichi(playerInfo)
+	assert.True(ok)
+
+	playerInfo.LeftDrawTilesCount = 3
+	ok, reason := CanRiichi(playerInfo)
+	assert.False(ok)
+	assert.Contains(reason, "4 张")
+
+	// 最后一张牌已被摸走
+	playerInfo.LeftDrawTilesCount = 0
+	ok, reason = CanRiichi(playerInfo)
+	assert.False(ok)
+	assert.Contains(reason, "4 张")
+
+	// 三麻剩余 3 张时可以立直
+	playerInfo.IsSanma = true
+	playerInfo.LeftDrawTilesCount = 3
+	ok, _ = CanRiichi(playerInfo)
+	assert.True(ok)
+	playerInfo.LeftDrawTilesCount = 2
+	ok, reason = CanRiichi(playerInfo)
+	assert.False(ok)
+	assert.Contains(reason, "3 张")
+	playerInfo.IsSanma = false
+
+	playerInfo.LeftDrawTilesCount = 4
+	playerInfo.Standing = &model.Standing{Scores: []int{900, 30000, 35000, 34100}, AbsentPlayer: -1}
+	ok, reason = CanRiichi(playerInfo)
+	assert.False(ok)
+	assert.Contains(reason, "1000 点")
+
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("789m 234p 5568s 1z"), []model.Meld{{MeldType: model.MeldTypeChi, Tiles: []int{0, 1, 2}}})
+	ok, _ = CanRiichi(playerInfo)
+	assert.False(ok)
+}
+
+func TestCalcRiichiAdvice(t *testing.T) {
+	assert := assert.New(t)
+
+	advice := func(playerInfo *model.PlayerInfo, discardTile int, opponents OpponentRiskList) *RiichiAdvice {
+		_, results14, _ := CalculateShantenWithImproves14(playerInfo)
+		for _, r := range results14 {
+			if r.DiscardTile == discardTile {
+				return CalcRiichiAdvice(playerInfo, r, opponents)
+			}
+		}
+		return nil
+	}
+
+	// 无役听 7s 坎张，默听只能自摸，立直更好
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("123789m 234p 5568s 1z"), nil)
+	a := advice(playerInfo, 27, nil)
+	if assert.NotNil(a) {
+		assert.Equal(RiichiVerdictRiichi, a.Verdict)
+		assert.True(a.CanRiichi)
+		assert.True(a.RiichiEV > a.DamaEV)
+		assert.Equal("无役，默听只能自摸", a.Reasons[0])
+	}
+	// 切牌后不再动手牌
+	assert.Equal(MustStrToTiles34("123789m 234p 5568s 1z"), playerInfo.HandTiles34)
+	assert.Empty(playerInfo.DiscardTiles)
+
+	// 剩余不到 4 张无法立直
+	playerInfo.LeftDrawTilesCount = 3
+	a = advice(playerInfo, 27, nil)
+	if assert.NotNil(a) {
+		assert.Equal(RiichiVerdictTsumoOnly, a.Verdict)
+		assert.False(a.CanRiichi)
+		assert.Equal(0.0, a.RiichiEV)
+		assert.Len(a.Reasons, 1)
+	}
+
+	// 断幺平和三色，默听打点充足，他家立直时默听
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("234m 234p 234s 66s 45m 9p"), nil)
+	risk34 := make(RiskTiles34, 34)
+	opponents := OpponentRiskList{{Who: 2, TenpaiRate: 100, RiskTiles34: risk34, RonPoint: RonPointRiichiHiIppatsu}}
+	noThreat := advice(playerInfo, 17, nil)
+	a = advice(playerInfo, 17, opponents)
+	if assert.NotNil(a) && assert.NotNil(noThreat) {
+		assert.Equal(RiichiVerdictDama, a.Verdict)
+		assert.True(a.RiichiEV < noThreat.RiichiEV)
+		assert.Equal(noThreat.DamaEV, a.DamaEV)
+		assert.Contains(a.Reasons[len(a.Reasons)-2], "立直后无法弃和")
+	}
+
+	// 振听
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("234m 234p 234s 66s 45m 9p"), nil)
+	playerInfo.DiscardTiles = []int{2}
+	a = advice(playerInfo, 17, nil)
+	if assert.NotNil(a) {
+		assert.Equal("振听，默听只能自摸", a.Reasons[0])
+		assert.Equal(RiichiVerdictRiichi, a.Verdict)
+	}
+
+	// 切牌后未听牌
+	assert.Nil(advice(playerInfo, 1, nil))
+}
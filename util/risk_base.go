@@ -82,7 +82,16 @@ type RiskTiles34 []float64
 // leftTiles34: 各个牌在山中剩余的枚数
 // roundWindTile: 场风
 // playerWindTile: 自风
-func CalculateRiskTiles34(turns int, safeTiles34 []bool, leftTiles34 []int, doraTiles []int, roundWindTile int, playerWindTile int) (risk34 RiskTiles34) {
+func CalculateRiskTiles34(turns int, safeTiles34 []bool, leftTiles34 []int, doraTiles []int, roundWindTile int, playerWindTile int) RiskTiles34 {
+	return calculateRiskTiles34(RiskRate, turns, safeTiles34, leftTiles34, doraTiles, roundWindTile, playerWindTile)
+}
+
+// 同 CalculateRiskTiles34，三麻时使用三麻的危险度表（1m 9m 及 2-8m 的修正见 FixWithSanma）
+func CalculateRiskTiles34Sanma(turns int, safeTiles34 []bool, leftTiles34 []int, doraTiles []int, roundWindTile int, playerWindTile int) RiskTiles34 {
+	return calculateRiskTiles34(SanmaRiskRate, turns, safeTiles34, leftTiles34, doraTiles, roundWindTile, playerWindTile)
+}
+
+func calculateRiskTiles34(riskRate [][]float64, turns int, safeTiles34 []bool, leftTiles34 []int, doraTiles []int, roundWindTile int, playerWindTile int) (risk34 RiskTiles34) {
 	risk34 = make(RiskTiles34, 34)
 
 	// 只对 dora 牌的危险度进行调整（综合了放铳率和失点）
@@ -107,12 +116,12 @@ func CalculateRiskTiles34(turns int, safeTiles34 []bool, leftTiles34 []int, dora
 	// 生成用来计算筋牌的「安牌」
 	lowRiskTiles27 := calcLowRiskTiles27(safeTiles34, leftTiles34)
 	// 利用「安牌」计算无筋、筋、半筋、双筋的铳率
-	// TODO: 特殊处理宣言牌的筋牌、宣言牌的同色牌的铳率
+	// 宣言牌的筋牌、宣言牌的同色牌的铳率见 FixWithHandReading
 	for i := 0; i < 3; i++ {
 		for j := 0; j < 3; j++ {
 			idx := 9*i + j
 			t := TileTypeTable[j][lowRiskTiles27[idx+3]]
-			risk34[idx] = RiskRate[turns][t] * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * doraMulti(idx, t)
 			if j == 0 && safeTiles34[idx+3] && leftTiles34[idx] == 0 {
 				// (1) 两面 对碰单骑 都不可能 -> 安牌
 				risk34[idx] = 0
@@ -122,12 +131,12 @@ func CalculateRiskTiles34(turns int, safeTiles34 []bool, leftTiles34 []int, dora
 			idx := 9*i + j
 			mixSafeTile := lowRiskTiles27[idx-3]<<1 | lowRiskTiles27[idx+3]
 			t := TileTypeTable[j][mixSafeTile]
-			risk34[idx] = RiskRate[turns][t] * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * doraMulti(idx, t)
 		}
 		for j := 6; j < 9; j++ {
 			idx := 9*i + j
 			t := TileTypeTable[j][lowRiskTiles27[idx-3]]
-			risk34[idx] = RiskRate[turns][t] * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * doraMulti(idx, t)
 			if j == 8 && safeTiles34[idx-3] && leftTiles34[idx] == 0 {
 				// (9) 两面 对碰单骑 都不可能 -> 安牌
 				risk34[idx] = 0
@@ -136,8 +145,8 @@ func CalculateRiskTiles34(turns int, safeTiles34 []bool, leftTiles34 []int, dora
 		// 5断，37视作安牌筋
 		if leftTiles34[9*i+4] == 0 {
 			t := tileTypeSuji37
-			risk34[9*i+2] = RiskRate[turns][t] * doraMulti(9*i+2, t)
-			risk34[9*i+6] = RiskRate[turns][t] * doraMulti(9*i+6, t)
+			risk34[9*i+2] = riskRate[turns][t] * doraMulti(9*i+2, t)
+			risk34[9*i+6] = riskRate[turns][t] * doraMulti(9*i+6, t)
 		}
 	}
 	for i := 27; i < 34; i++ {
@@ -145,9 +154,9 @@ func CalculateRiskTiles34(turns int, safeTiles34 []bool, leftTiles34 []int, dora
 			// 该玩家的役牌 = 场风/其自风/白/发/中
 			isYakuHai := i == roundWindTile || i == playerWindTile || i >= 31
 			t := HonorTileType[boolToInt(isYakuHai)][leftTiles34[i]-1]
-			risk34[i] = RiskRate[turns][t] * doraMulti(i, t)
+			risk34[i] = riskRate[turns][t] * doraMulti(i, t)
 		} else {
-			// 剩余数为 0 可以视作安牌（忽略国士）
+			// 剩余数为 0 可以视作安牌（国士见 FixWithKokushi）
 			risk34[i] = 0
 		}
 	}
@@ -166,32 +175,32 @@ func CalculateRiskTiles34(turns int, safeTiles34 []bool, leftTiles34 []int, dora
 		switch idx%9 + 1 {
 		case 1, 9:
 			t := tileTypeSuji19
-			risk34[idx] = RiskRate[turns][t] * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * doraMulti(idx, t)
 		case 2, 8:
 			t := tileTypeSuji19
-			risk34[idx] = RiskRate[turns][t] * 1.1 * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * 1.1 * doraMulti(idx, t)
 		case 3, 7:
 			t := tileTypeSuji28
-			risk34[idx] = RiskRate[turns][t] * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * doraMulti(idx, t)
 		case 4, 6:
 			t := tileTypeDoubleSuji46
-			risk34[idx] = RiskRate[turns][t] * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * doraMulti(idx, t)
 		case 5:
 			t := tileTypeDoubleSuji5
-			risk34[idx] = RiskRate[turns][t] * doraMulti(idx, t)
+			risk34[idx] = riskRate[turns][t] * doraMulti(idx, t)
 		default:
 			panic(fmt.Errorf("[CalculateRiskTiles34] 代码有误: ncSafeTile = %d", ncSafeTile.Tile34))
 		}
 	}
 
-	// 根据现物和 No Chance 计算是否只输对碰单骑，在这种情况下安全度和筋 19 差不多；若剩余枚数为 0 可直接视作现物（忽略国士）
+	// 根据现物和 No Chance 计算是否只输对碰单骑，在这种情况下安全度和筋 19 差不多；若剩余枚数为 0 可直接视作现物（国士见 FixWithKokushi）
 	// 更新铳率表：Double No Chance 的危险度
 	dncSafeTiles := CalcDNCSafeTilesWithDiscards(leftTiles34, safeTiles34)
 	for _, dncSafeTile := range dncSafeTiles {
 		tile := dncSafeTile.Tile34
 		if leftTiles34[tile] > 0 {
 			t := tileTypeSuji19
-			risk34[tile] = RiskRate[turns][t] * doraMulti(tile, t)
+			risk34[tile] = riskRate[turns][t] * doraMulti(tile, t)
 			// 非19仍然有点断幺的危险，危险度 *1.1
 			if t9 := tile % 9; t9 > 0 && t9 < 8 {
 				risk34[tile] *= 1.1
@@ -220,6 +229,37 @@ func (l RiskTiles34) FixWithEarlyOutside(discardTiles []int) RiskTiles34 {
 	return l
 }
 
+// 根据舍牌粗略判断是否有国士无双的可能：门清，且舍牌已有一定数量但几乎没有幺九牌
+func MaybeKokushi(discardTiles []int, isNaki bool) bool {
+	const minDiscardCount = 6
+	if isNaki || len(discardTiles) < minDiscardCount {
+		return false
+	}
+	yaochuCount := 0
+	for _, tile := range discardTiles {
+		if InInts(tile, YaochuTiles[:]) {
+			yaochuCount++
+		}
+	}
+	return yaochuCount <= 1
+}
+
+// 对国士无双的危险度进行修正
+// 国士听的牌可能全在自己手中，所以剩余数为 0 的幺九牌也不是安牌，这里当做剩余一枚的客风来算
+// 三麻时使用三麻的危险度表
+func (l RiskTiles34) FixWithKokushi(turns int, safeTiles34 []bool, leftTiles34 []int, isSanma bool) RiskTiles34 {
+	riskRate := RiskRate
+	if isSanma {
+		riskRate = SanmaRiskRate
+	}
+	for _, tile := range YaochuTiles {
+		if !safeTiles34[tile] && leftTiles34[tile] == 0 && l[tile] == 0 {
+			l[tile] = riskRate[turns][tileTypeOtakazeLeft1]
+		}
+	}
+	return l
+}
+
 func (l RiskTiles34) FixWithGlobalMulti(multi float64) RiskTiles34 {
 	for i := range l {
 		l[i] *= multi
@@ -101,3 +101,27 @@ func TestCalculateLeftNoSujiTiles(t *testing.T) {
 	}
 	assert.Equal("", TilesToStr(CalculateLeftNoSujiTiles(safeTiles34, leftTiles34)))
 }
+
+func TestRiskTiles34_FixWithKokushi(t *testing.T) {
+	assert.True(t, MaybeKokushi(MustStrToTiles("2345m 678p 5s"), false))
+	assert.False(t, MaybeKokushi(MustStrToTiles("2345m 678p 5s"), true))
+	assert.False(t, MaybeKokushi(MustStrToTiles("19m 345p 5s"), false))
+	assert.False(t, MaybeKokushi(MustStrToTiles("234m"), false))
+
+	safeTiles34 := make([]bool, 34)
+	safeTiles34[MustStrToTile34("7z")] = true
+	leftTiles34 := InitLeftTiles34WithTiles34(MustStrToTiles34("1111z 7777z"))
+	risk34 := CalculateRiskTiles34(9, safeTiles34, leftTiles34, nil, 27, 28)
+	assert.Equal(t, 0.0, risk34[MustStrToTile34("1z")])
+
+	// 剩余数为 0 的东不再是安牌，现物中不变
+	risk34.FixWithKokushi(9, safeTiles34, leftTiles34, false)
+	assert.Equal(t, RiskRate[9][tileTypeOtakazeLeft1], risk34[MustStrToTile34("1z")])
+	assert.Equal(t, 0.0, risk34[MustStrToTile34("7z")])
+
+	// 三麻时使用三麻的危险度表
+	risk34 = CalculateRiskTiles34Sanma(9, safeTiles34, leftTiles34, nil, 27, 28)
+	risk34.FixWithKokushi(9, safeTiles34, leftTiles34, true)
+	assert.Equal(t, SanmaRiskRate[9][tileTypeOtakazeLeft1], risk34[MustStrToTile34("1z")])
+	assert.Equal(t, 0.0, risk34[MustStrToTile34("7z")])
+}
@@ -0,0 +1,330 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math/rand"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 蒙特卡罗模拟：从剩余牌中随机生成牌山，按简单的策略摸切至流局，统计听牌率、和率和平均打点
+// 简化：他家只摸切（其舍牌可以荣和），不考虑他家的鸣牌、立直与和牌
+
+// 剩余可以摸的牌数未知时，按配牌后的牌数计算
+const rolloutDefaultLeftDrawTilesCount = 70
+
+type RolloutConfig struct {
+	// 每个切牌的模拟次数
+	Simulations int
+
+	// 并行的 goroutine 数，为 0 时取 CPU 核数
+	Workers int
+
+	// 时间限制，为 0 时不限制。超时后停止模拟，此时结果取决于已完成的模拟
+	TimeBudget time.Duration
+
+	// 随机种子，不超时的情况下相同的种子得到相同的结果
+	Seed int64
+}
+
+type RolloutResult struct {
+	// 切牌，3k+1 张牌时为 -1
+	DiscardTile int
+
+	// 实际完成的模拟次数
+	Simulations int
+
+	// 流局时（或和牌前）听牌的概率 (0-100)
+	TenpaiRate float64
+
+	// 和牌的概率 (0-100)
+	AgariRate float64
+
+	// 和牌时的平均点数（自摸为各家支付之和，不含里宝）
+	AvgPoint float64
+
+	// [i] 为自家第 i+1 次摸牌后已听牌（或已和牌）的概率 (0-100)
+	TenpaiRateByTurn []float64
+
+	// [i] 为自家第 i+1 次摸牌前（含该次摸牌）已和牌的概率 (0-100)
+	AgariRateByTurn []float64
+}
+
+// 模拟的统计数据，均为整数以保证结果与各个模拟的完成顺序无关
+type rolloutStats struct {
+	simulations  int64
+	tenpaiCount  int64
+	agariCount   int64
+	pointSum     int64
+	tenpaiByTurn []int64
+	agariByTurn  []int64
+}
+
+func newRolloutStats(turns int) *rolloutStats {
+	return &rolloutStats{
+		tenpaiByTurn: make([]int64, turns),
+		agariByTurn:  make([]int64, turns),
+	}
+}
+
+func (s *rolloutStats) add(o *rolloutOutcome) {
+	atomic.AddInt64(&s.simulations, 1)
+	if o.tenpaiTurn != -1 {
+		atomic.AddInt64(&s.tenpaiCount, 1)
+		for i := o.tenpaiTurn; i < len(s.tenpaiByTurn); i++ {
+			atomic.AddInt64(&s.tenpaiByTurn[i], 1)
+		}
+	}
+	if o.agariTurn != -1 {
+		atomic.AddInt64(&s.agariCount, 1)
+		atomic.AddInt64(&s.pointSum, int64(o.point))
+		for i := o.agariTurn; i < len(s.agariByTurn); i++ {
+			atomic.AddInt64(&s.agariByTurn[i], 1)
+		}
+	}
+}
+
+func (s *rolloutStats) result(discardTile int) *RolloutResult {
+	r := &RolloutResult{
+		DiscardTile:      discardTile,
+		Simulations:      int(s.simulations),
+		TenpaiRateByTurn: make([]float64, len(s.tenpaiByTurn)),
+		AgariRateByTurn:  make([]float64, len(s.agariByTurn)),
+	}
+	if s.simulations == 0 {
+		return r
+	}
+	n := float64(s.simulations)
+	r.TenpaiRate = 100 * float64(s.tenpaiCount) / n
+	r.AgariRate = 100 * float64(s.agariCount) / n
+	if s.agariCount > 0 {
+		r.AvgPoint = float64(s.pointSum) / float64(s.agariCount)
+	}
+	for i := range s.tenpaiByTurn {
+		r.TenpaiRateByTurn[i] = 100 * float64(s.tenpaiByTurn[i]) / n
+		r.AgariRateByTurn[i] = 100 * float64(s.agariByTurn[i]) / n
+	}
+	return r
+}
+
+type rolloutOutcome struct {
+	tenpaiTurn int // 首次听牌时自家的摸牌次数下标，没有听牌时为 -1
+	agariTurn  int // 和牌时自家的摸牌次数下标（荣和时为下一次摸牌的下标），没有和牌时为 -1
+	point      int
+}
+
+// 玩家数
+func rolloutPlayerNumber(playerInfo *model.PlayerInfo) int {
+	if playerInfo.IsSanma {
+		return 3
+	}
+	return 4
+}
+
+// 自家还能摸的牌数
+func rolloutSelfDrawCount(playerInfo *model.PlayerInfo) int {
+	leftDrawTilesCount := playerInfo.LeftDrawTilesCount
+	if leftDrawTilesCount == model.LeftDrawTilesCountUnknown {
+		leftDrawTilesCount = rolloutDefaultLeftDrawTilesCount
+		if playerInfo.IsSanma {
+			leftDrawTilesCount = SanmaLeftDrawTilesCountAfterDeal
+		}
+	}
+	return leftDrawTilesCount / rolloutPlayerNumber(playerInfo)
+}
+
+// 简单的切牌策略：选择向听数最小的切牌，相同时选择进张最多的切牌
+// visibleLeftTiles34 为自家视角下的剩余牌
+func rolloutChooseDiscard(tiles34 []int, visibleLeftTiles34 []int) (discardTile int, shanten int, waits Waits) {
+	discardTile = -1
+	shanten = 99
+	for tile, c := range tiles34 {
+		if c == 0 {
+			continue
+		}
+		tiles34[tile]--
+		if _shanten := CalculateShanten(tiles34); _shanten <= shanten {
+			_, _waits := CalculateShantenAndWaits13(tiles34, visibleLeftTiles34)
+			if _shanten < shanten || _waits.AllCount() > waits.AllCount() {
+				discardTile, shanten, waits = tile, _shanten, _waits
+			}
+		}
+		tiles34[tile]++
+	}
+	return
+}
+
+// 和牌时的点数，无役时返回 0
+func rolloutPoint(pi *model.PlayerInfo, winTile int, isTsumo bool) int {
+	pi.HandTiles34[winTile]++
+	pi.WinTile = winTile
+	pi.IsTsumo = isTsumo
+	point := CalcPoint(pi).Point
+	pi.HandTiles34[winTile]--
+	return point
+}
+
+// 对 3k+1 张牌进行一次模拟
+func rolloutOnce(playerInfo *model.PlayerInfo, wall []int, selfDrawCount int, rnd *rand.Rand) *rolloutOutcome {
+	o := &rolloutOutcome{tenpaiTurn: -1, agariTurn: -1}
+
+	rnd.Shuffle(len(wall), func(i, j int) { wall[i], wall[j] = wall[j], wall[i] })
+
+	pi := *playerInfo
+	pi.HandTiles34 = append([]int(nil), playerInfo.HandTiles34...)
+	pi.DiscardTiles = append([]int(nil), playerInfo.DiscardTiles...)
+	visibleLeftTiles34 := append([]int(nil), playerInfo.LeftTiles34...)
+	isMenzen := !pi.IsNaki()
+
+	shanten, waits := CalculateShantenAndWaits13(pi.HandTiles34, visibleLeftTiles34)
+	if shanten == 0 {
+		o.tenpaiTurn = 0
+		if isMenzen {
+			pi.IsRiichi = true
+		}
+	}
+
+	playerNumber := rolloutPlayerNumber(playerInfo)
+	pos := 0
+	for turn := 0; turn < selfDrawCount; turn++ {
+		// 他家摸切
+		for i := 0; i < playerNumber-1 && pos < len(wall); i++ {
+			tile := wall[pos]
+			pos++
+			visibleLeftTiles34[tile]--
+			if shanten == 0 && waits[tile] > 0 && !pi.IsFuriten(waits) {
+				if point := rolloutPoint(&pi, tile, false); point > 0 {
+					o.agariTurn, o.point = turn, point
+					return o
+				}
+			}
+		}
+		if pos >= len(wall) {
+			break
+		}
+
+		// 自家摸牌
+		tile := wall[pos]
+		pos++
+		visibleLeftTiles34[tile]--
+		if shanten == 0 && waits[tile] > 0 {
+			if point := rolloutPoint(&pi, tile, true); point > 0 {
+				o.agariTurn, o.point = turn, point
+				return o
+			}
+		}
+
+		if shanten == 0 || pi.IsRiichi {
+			// 听牌后摸切
+			pi.DiscardTiles = append(pi.DiscardTiles, tile)
+			continue
+		}
+
+		pi.HandTiles34[tile]++
+		discardTile, _shanten, _waits := rolloutChooseDiscard(pi.HandTiles34, visibleLeftTiles34)
+		pi.HandTiles34[discardTile]--
+		pi.DiscardTiles = append(pi.DiscardTiles, discardTile)
+		shanten, waits = _shanten, _waits
+		if shanten == 0 {
+			o.tenpaiTurn = turn
+			// 门清听牌即立直
+			if isMenzen && selfDrawCount-turn-1 > 0 {
+				pi.IsRiichi = true
+			}
+		}
+	}
+	return o
+}
+
+type rolloutJob struct {
+	target int // 在 targets 中的下标
+	sim    int // 模拟的序号，用于生成随机种子
+}
+
+// 对多个 3k+1 张牌的手牌进行并行模拟，所有手牌共用时间限制
+func rollout(targets []*model.PlayerInfo, cfg RolloutConfig) []*rolloutStats {
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	var deadline time.Time
+	if cfg.TimeBudget > 0 {
+		deadline = time.Now().Add(cfg.TimeBudget)
+	}
+
+	allStats := make([]*rolloutStats, len(targets))
+	walls := make([][]int, len(targets))
+	selfDrawCounts := make([]int, len(targets))
+	for i, pi := range targets {
+		if len(pi.LeftTiles34) == 0 {
+			pi.FillLeftTiles34()
+		}
+		selfDrawCounts[i] = rolloutSelfDrawCount(pi)
+		allStats[i] = newRolloutStats(selfDrawCounts[i])
+		for tile, left := range pi.LeftTiles34 {
+			for j := 0; j < left; j++ {
+				walls[i] = append(walls[i], tile)
+			}
+		}
+	}
+
+	// 按模拟序号交替分配各个手牌的任务，使得超时后各个手牌的模拟次数相近
+	jobs := make(chan rolloutJob)
+	go func() {
+		defer close(jobs)
+		for sim := 0; sim < cfg.Simulations; sim++ {
+			for target := range targets {
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					return
+				}
+				jobs <- rolloutJob{target, sim}
+			}
+		}
+	}()
+
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for job := range jobs {
+				if !deadline.IsZero() && time.Now().After(deadline) {
+					// 耗尽剩余的任务
+					continue
+				}
+				wall := append([]int(nil), walls[job.target]...)
+				// 各个手牌的同一序号的模拟使用相同的牌山顺序，以减小比较时的方差
+				rnd := rand.New(rand.NewSource(cfg.Seed + int64(job.sim)))
+				o := rolloutOnce(targets[job.target], wall, selfDrawCounts[job.target], rnd)
+				allStats[job.target].add(o)
+			}
+		}()
+	}
+	wg.Wait()
+	return allStats
+}
+
+// 对 3k+1 张牌进行蒙特卡罗模拟
+func Rollout13(playerInfo *model.PlayerInfo, cfg RolloutConfig) *RolloutResult {
+	return rollout([]*model.PlayerInfo{playerInfo}, cfg)[0].result(-1)
+}
+
+// 对 3k+2 张牌的各个切牌进行蒙特卡罗模拟，结果的顺序与 results14 相同
+func RolloutDiscards(playerInfo *model.PlayerInfo, results14 Hand14AnalysisResultList, cfg RolloutConfig) []*RolloutResult {
+	targets := make([]*model.PlayerInfo, len(results14))
+	for i, r := range results14 {
+		pi := *playerInfo
+		pi.HandTiles34 = append([]int(nil), playerInfo.HandTiles34...)
+		pi.DiscardTiles = append([]int(nil), playerInfo.DiscardTiles...)
+		pi.DiscardTile(r.DiscardTile, false)
+		targets[i] = &pi
+	}
+	allStats := rollout(targets, cfg)
+	results := make([]*RolloutResult, len(results14))
+	for i, s := range allStats {
+		results[i] = s.result(results14[i].DiscardTile)
+	}
+	return results
+}
@@ -0,0 +1,69 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRollout13(t *testing.T) {
+	assert := assert.New(t)
+
+	cfg := RolloutConfig{Simulations: 200, Workers: 4, Seed: 1}
+
+	// 两面听牌，立直后摸切
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("123456789m 234p 5s"), nil)
+	playerInfo.LeftDrawTilesCount = 40
+	r := Rollout13(playerInfo, cfg)
+	assert.Equal(200, r.Simulations)
+	assert.Equal(-1, r.DiscardTile)
+	assert.InDelta(100, r.TenpaiRate, 1e-9)
+	assert.Len(r.AgariRateByTurn, 10)
+	assert.True(r.AgariRate > 30)
+	assert.True(r.AvgPoint >= 2000) // 立直一气
+	assert.InDelta(r.AgariRate, r.AgariRateByTurn[len(r.AgariRateByTurn)-1], 1e-9)
+	for i := 1; i < len(r.AgariRateByTurn); i++ {
+		assert.True(r.AgariRateByTurn[i] >= r.AgariRateByTurn[i-1])
+	}
+
+	// 相同的种子得到相同的结果，与并行数无关
+	cfg.Workers = 1
+	assert.Equal(r, Rollout13(playerInfo, cfg))
+	cfg.Seed = 2
+	assert.NotEqual(r, Rollout13(playerInfo, cfg))
+
+	// 超时后停止模拟
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("147m 258p 369s 1234z"), nil)
+	r = Rollout13(playerInfo, RolloutConfig{Simulations: 1e6, TimeBudget: 50 * time.Millisecond})
+	assert.True(r.Simulations > 0)
+	assert.True(r.Simulations < 1e6)
+}
+
+func TestRolloutDiscards(t *testing.T) {
+	assert := assert.New(t)
+
+	// 一向听，切 1z 比切 5m（向听倒退）更容易听牌
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("12356m 234p 45678s 1z"), nil)
+	playerInfo.LeftDrawTilesCount = 40
+	_, results14, incShantenResults14 := CalculateShantenWithImproves14(playerInfo)
+	var candidates Hand14AnalysisResultList
+	for _, r := range append(results14, incShantenResults14...) {
+		if r.DiscardTile == 27 || r.DiscardTile == 4 {
+			candidates = append(candidates, r)
+		}
+	}
+	if !assert.Len(candidates, 2) {
+		return
+	}
+	results := RolloutDiscards(playerInfo, candidates, RolloutConfig{Simulations: 200, Seed: 1})
+	if assert.Len(results, 2) {
+		rates := map[int]float64{}
+		for _, r := range results {
+			rates[r.DiscardTile] = r.TenpaiRate
+		}
+		assert.True(rates[27] > rates[4])
+	}
+	// 手牌不变
+	assert.Equal(MustStrToTiles34("12356m 234p 45678s 1z"), playerInfo.HandTiles34)
+}
@@ -0,0 +1,148 @@
+package util
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// 规则，影响打点计算、役种判断、危险度中的打点估计等
+type Ruleset struct {
+	Name string
+
+	NumRedFives     int  // 赤宝牌数
+	OpenTanyao      bool // 是否有食断
+	KiriageMangan   bool // 是否切上满贯，即 4番30符、3番60符 按满贯计算
+	KazoeYakuman    bool // 13番以上是否为累计役满，否则为三倍满
+	DoubleYakuman   bool // 国士十三面、四暗刻单骑、纯正九莲、大四喜是否为两倍役满
+	MultipleYakuman bool // 多个役满能否复合
+	RenhouHan       int  // 人和的番数，0 表示没有人和，13 及以上表示役满
+
+	// 三麻是否有自摸损：自摸时不存在的一家不支付，否则其应付的点数由另两家平摊
+	SanmaTsumoLoss bool
+
+	// 终局得点，用于顺位判断
+	StartingPoints int    // 四麻的起始点数，三麻多 10000 点
+	Oka            int    // 返点与起始点数之差，由一位获得
+	Uma            [4]int // 顺位马（千点），三麻时取一位和末位的马，二位为 0
+}
+
+var (
+	RulesetTenhou = Ruleset{
+		Name:            "天凤",
+		NumRedFives:     3,
+		OpenTanyao:      true,
+		KazoeYakuman:    true,
+		MultipleYakuman: true,
+		SanmaTsumoLoss:  true,
+		StartingPoints:  25000,
+		Oka:             5000,
+		Uma:             [4]int{20, 10, -10, -20},
+	}
+
+	RulesetMajsoul = Ruleset{
+		Name:            "雀魂段位",
+		NumRedFives:     3,
+		OpenTanyao:      true,
+		KazoeYakuman:    true,
+		DoubleYakuman:   true,
+		MultipleYakuman: true,
+		StartingPoints:  25000,
+		Uma:             [4]int{15, 5, -5, -15},
+	}
+
+	// WRC 和 EMA 的规则在打点上是一致的
+	RulesetWRC = Ruleset{
+		Name:            "WRC/EMA",
+		OpenTanyao:      true,
+		MultipleYakuman: true,
+		StartingPoints:  30000,
+		Uma:             [4]int{15, 5, -5, -15},
+	}
+
+	// 雀魂古役模式：人和为满贯
+	RulesetMajsoulGuyi = Ruleset{
+		Name:            "雀魂古役",
+		NumRedFives:     3,
+		OpenTanyao:      true,
+		KazoeYakuman:    true,
+		DoubleYakuman:   true,
+		MultipleYakuman: true,
+		RenhouHan:       5,
+		StartingPoints:  25000,
+		Uma:             [4]int{15, 5, -5, -15},
+	}
+
+	RulesetMLeague = Ruleset{
+		Name:            "M-League",
+		NumRedFives:     3,
+		OpenTanyao:      true,
+		KiriageMangan:   true,
+		KazoeYakuman:    true,
+		MultipleYakuman: true,
+		StartingPoints:  25000,
+		Oka:             5000,
+		Uma:             [4]int{30, 10, -10, -30},
+	}
+)
+
+var rulesetNameMap = map[string]Ruleset{
+	"tenhou":  RulesetTenhou,
+	"majsoul": RulesetMajsoul,
+	"guyi":    RulesetMajsoulGuyi,
+	"wrc":     RulesetWRC,
+	"ema":     RulesetWRC,
+	"mleague": RulesetMLeague,
+}
+
+// 根据名称获取预设规则，如 tenhou majsoul guyi wrc ema mleague
+func RulesetByName(name string) (Ruleset, error) {
+	r, ok := rulesetNameMap[strings.ToLower(name)]
+	if !ok {
+		names := []string{}
+		for n := range rulesetNameMap {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return Ruleset{}, fmt.Errorf("未知规则 %s，可选规则为 %s", name, strings.Join(names, " "))
+	}
+	return r, nil
+}
+
+// 默认为雀魂段位规则
+var ruleset = RulesetMajsoul
+
+func SetRuleset(r Ruleset) {
+	ruleset = r
+}
+
+func CurrentRuleset() Ruleset {
+	return ruleset
+}
+
+// 人和是否为役满
+func (r Ruleset) isRenhouYakuman() bool {
+	return r.RenhouHan >= 13
+}
+
+// 荣和点数均值相对于天凤规则（赤3）的倍率
+// 每少一枚赤宝牌，平均打点约减少 5%
+func (r Ruleset) RonPointMulti() float64 {
+	return 1 + 0.05*float64(r.NumRedFives-RulesetTenhou.NumRedFives)
+}
+
+// 终局得点（千点），含顺位马和返点
+// placement: 顺位，0 为一位
+func (r Ruleset) FinalPoint(score int, placement int, playerNumber int) float64 {
+	startingPoints := r.StartingPoints
+	uma := r.Uma[placement]
+	if playerNumber == 3 {
+		startingPoints += 10000
+		uma = [3]int{r.Uma[0], 0, r.Uma[3]}[placement]
+	}
+	point := float64(score-startingPoints-r.Oka)/1000 + float64(uma)
+	if placement == 0 {
+		point += float64(r.Oka*playerNumber) / 1000
+	}
+	return point
+}
@@ -0,0 +1,32 @@
+package util
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestRulesetByName(t *testing.T) {
+	r, err := RulesetByName("Tenhou")
+	assert.NoError(t, err)
+	assert.Equal(t, RulesetTenhou, r)
+
+	r, err = RulesetByName("ema")
+	assert.NoError(t, err)
+	assert.Equal(t, RulesetWRC, r)
+
+	_, err = RulesetByName("unknown")
+	assert.Error(t, err)
+}
+
+func TestRuleset_RonPointMulti(t *testing.T) {
+	assert.Equal(t, 1.0, RulesetTenhou.RonPointMulti())
+	assert.InDelta(t, 0.85, RulesetWRC.RonPointMulti(), 1e-9)
+}
+
+func TestRuleset_FinalPoint(t *testing.T) {
+	// 天凤：30000 返，一位 +20+20
+	assert.InDelta(t, 45.0, RulesetTenhou.FinalPoint(35000, 0, 4), 1e-9)
+	assert.InDelta(t, -25.0, RulesetTenhou.FinalPoint(25000, 3, 4), 1e-9)
+	// 雀魂：无返点，三麻二位没有马
+	assert.InDelta(t, -5.0, RulesetMajsoul.FinalPoint(30000, 1, 3), 1e-9)
+}
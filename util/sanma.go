@@ -0,0 +1,64 @@
+package util
+
+// 三麻（三人麻将）
+// 三麻没有 2-8m，1m 和 9m 无法组成顺子，只能作为雀头或刻子，其性质与字牌相同
+// 三麻的听牌率、危险度和和率使用单独的表，见 sanma_data.go
+
+// 配牌后牌山剩余可以摸的牌数：108 - 14 - 3*13
+const SanmaLeftDrawTilesCountAfterDeal = 55
+
+// 三麻中与字牌性质相同的数牌（1m 9m）
+func isSanmaHonorLikeTile(tile int) bool {
+	return tile == 0 || tile == 8
+}
+
+// 和率按字牌计算的牌：字牌，以及三麻的 1m 9m
+func isHonorLikeTile(tile int, isSanma bool) bool {
+	return tile >= 27 || isSanma && isSanmaHonorLikeTile(tile)
+}
+
+// 三麻中不存在的牌（2-8m）
+func IsSanmaAbsentTile(tile int) bool {
+	return tile >= 1 && tile <= 7
+}
+
+// 同 CalcPointTsumo，三麻时的子家支付点数和亲家支付点数
+// 有自摸损时与四麻相同，即不存在的一家（总是子家）不支付；否则其应付的部分由另两家平摊
+func CalcPointTsumo3(han int, fu int, yakumanTimes int, isParent bool) (childPoint int, parentPoint int) {
+	if ruleset.SanmaTsumoLoss {
+		return CalcPointTsumo(han, fu, yakumanTimes, isParent)
+	}
+	basicPoint := calcBasicPoint(han, fu, yakumanTimes)
+	if isParent {
+		return roundUpPoint(3 * basicPoint), 0
+	}
+	return roundUpPoint(3 * basicPoint / 2), roundUpPoint(5 * basicPoint / 2)
+}
+
+// 同 CalcPointTsumoSum，三麻时两家支付的点数之和
+func CalcPointTsumoSum3(han int, fu int, yakumanTimes int, isParent bool) int {
+	childPoint, parentPoint := CalcPointTsumo3(han, fu, yakumanTimes, isParent)
+	if isParent {
+		return 2 * childPoint
+	}
+	return childPoint + parentPoint
+}
+
+// 对三麻的危险度进行修正
+// 1m 9m 只能对碰或单骑，按剩余枚数当做客风来算；2-8m 不存在，危险度为 0
+func (l RiskTiles34) FixWithSanma(turns int, safeTiles34 []bool, leftTiles34 []int, doraTiles []int) RiskTiles34 {
+	for tile := 0; tile < 9; tile++ {
+		if IsSanmaAbsentTile(tile) || safeTiles34[tile] || leftTiles34[tile] == 0 {
+			l[tile] = 0
+			continue
+		}
+		t := HonorTileType[0][leftTiles34[tile]-1]
+		l[tile] = SanmaRiskRate[turns][t]
+		for _, dora := range doraTiles {
+			if tile == dora {
+				l[tile] *= FixedDoraRiskRateMulti[t]
+			}
+		}
+	}
+	return l
+}
@@ -0,0 +1,71 @@
+package util
+
+// 三麻的听牌率、危险度和和率表，结构与四麻的表相同
+// 注意：这些表不是三麻牌谱的统计数据，而是在初始化时由四麻的表按经验系数换算得到的近似值（换算方法见各表的注释）
+// 因此三麻的分析结果会附上 SanmaApproximationNote 的提示
+// 有了三麻的统计数据（如 https://shikkaku.com/data_sanma_20）后，直接替换这些表并去掉提示即可
+
+// 三麻分析结果的提示
+const SanmaApproximationNote = "注意：三麻的听牌率、危险度和和率为由四麻数据换算的近似值，仅供参考"
+
+const (
+	// 三麻没有 2-8m，数牌的待牌集中在筒子和索子两种花色，每张数牌的危险度约为四麻的 3/2
+	sanmaNumberRiskMulti = 3.0 / 2.0
+
+	// 三麻中放铳的对手少一家，假设自摸与荣和各占一半，和率约为四麻的 1/2 + 1/2 * 2/3
+	sanmaAgariMulti = 5.0 / 6.0
+)
+
+var (
+	// [副露数][巡目][副露之后的手切数]
+	// 三麻的牌种少，进张多，相同巡目下听牌率更高，由 GetTenpaiRate3 换算
+	sanmaTenpaiRate [][][]float64
+
+	// [巡目][类型]
+	// 数牌的危险度按 sanmaNumberRiskMulti 换算，字牌不变（1m 9m 按字牌计算，见 FixWithSanma）
+	SanmaRiskRate [][]float64
+
+	// 数牌和率、字牌非单骑和率、字牌单骑和率
+	// 按 sanmaAgariMulti 换算，振听时只能自摸，和率不变
+	sanmaAgariMap                    map[tileType][5]float64
+	sanmaHonorTileNonDankiAgariTable [len(honorTileNonDankiAgariTable)]float64
+	sanmaHonorTileDankiAgariTable    [len(honorTileDankiAgariTable)]float64
+)
+
+func init() {
+	sanmaTenpaiRate = make([][][]float64, len(tenpaiRate))
+	for meldCount, turnRates := range tenpaiRate {
+		sanmaTenpaiRate[meldCount] = make([][]float64, len(turnRates))
+		for turn, rates := range turnRates {
+			sanmaTenpaiRate[meldCount][turn] = make([]float64, len(rates))
+			for i, rate := range rates {
+				sanmaTenpaiRate[meldCount][turn][i] = GetTenpaiRate3(rate)
+			}
+		}
+	}
+
+	SanmaRiskRate = make([][]float64, len(RiskRate))
+	for turn, rates := range RiskRate {
+		SanmaRiskRate[turn] = make([]float64, len(rates))
+		for t, rate := range rates {
+			if tileType(t) < tileTypeYakuHaiLeft3 {
+				rate *= sanmaNumberRiskMulti
+			}
+			SanmaRiskRate[turn][t] = rate
+		}
+	}
+
+	sanmaAgariMap = make(map[tileType][5]float64, len(agariMap))
+	for t, rates := range agariMap {
+		for left := range rates {
+			rates[left] *= sanmaAgariMulti
+		}
+		sanmaAgariMap[t] = rates
+	}
+	for left, rate := range honorTileNonDankiAgariTable {
+		sanmaHonorTileNonDankiAgariTable[left] = rate * sanmaAgariMulti
+	}
+	for left, rate := range honorTileDankiAgariTable {
+		sanmaHonorTileDankiAgariTable[left] = rate * sanmaAgariMulti
+	}
+}
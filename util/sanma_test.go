@@ -0,0 +1,93 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalcPointTsumo3(t *testing.T) {
+	assert := assert.New(t)
+	defer SetRuleset(CurrentRuleset())
+
+	// 自摸损：北家应付的部分没有人支付
+	SetRuleset(RulesetTenhou)
+	assert.Equal(6000, CalcPointTsumoSum3(5, 30, 0, false))
+	assert.Equal(8000, CalcPointTsumoSum3(5, 30, 0, true))
+
+	// 无自摸损：北家应付的部分由另两家平摊，总额与荣和相同
+	SetRuleset(RulesetMajsoul)
+	child, parent := CalcPointTsumo3(5, 30, 0, false)
+	assert.Equal(3000, child)
+	assert.Equal(5000, parent)
+	assert.Equal(12000, CalcPointTsumoSum3(5, 30, 0, true))
+	assert.Equal(1000, CalcPointTsumoSum3(1, 30, 0, false))
+
+	// 三麻自摸时的计分
+	playerInfo := model.NewSimplePlayerInfo(MustStrToTiles34("123p 456p 789p 11s 555z"), nil)
+	playerInfo.IsSanma = true
+	playerInfo.IsTsumo = true
+	playerInfo.WinTile = 9
+	scores := ScoreHand(playerInfo)
+	if assert.NotEmpty(scores) {
+		s := scores[0]
+		child, parent = CalcPointTsumo3(s.Han, s.Fu, 0, false)
+		assert.Equal(child, s.TsumoChildPoint)
+		assert.Equal(parent, s.TsumoParentPoint)
+		assert.Equal(child+parent, s.Point)
+		assert.Equal(CalcPointRon(s.Han, s.Fu, 0, false), s.Point)
+	}
+}
+
+func TestRiskTiles34_FixWithSanma(t *testing.T) {
+	assert := assert.New(t)
+	const eps = 1e-3
+
+	leftTiles34 := InitLeftTiles34()
+	for tile := range leftTiles34 {
+		if IsSanmaAbsentTile(tile) {
+			leftTiles34[tile] = 0
+		}
+	}
+	leftTiles34[8] = 1
+	safeTiles34 := make([]bool, 34)
+	risk34 := CalculateRiskTiles34Sanma(9, safeTiles34, leftTiles34, nil, 27, 28).FixWithSanma(9, safeTiles34, leftTiles34, nil)
+
+	// 1m 9m 当做客风
+	assert.Equal(SanmaRiskRate[9][tileTypeOtakazeLeft3], risk34[0])
+	assert.Equal(SanmaRiskRate[9][tileTypeOtakazeLeft1], risk34[8])
+	for tile := 1; tile <= 7; tile++ {
+		assert.Zero(risk34[tile])
+	}
+	assert.Equal(risk34[29], risk34[0])
+
+	// 筒子和索子的危险度比四麻高，字牌不变
+	risk34Of4 := CalculateRiskTiles34(9, safeTiles34, leftTiles34, nil, 27, 28)
+	assert.InDelta(risk34Of4[13]*sanmaNumberRiskMulti, risk34[13], eps)
+	assert.InDelta(risk34Of4[22]*sanmaNumberRiskMulti, risk34[22], eps)
+	assert.Equal(risk34Of4[29], risk34[29])
+}
+
+func TestCalcTenpaiRate3(t *testing.T) {
+	assert := assert.New(t)
+	const eps = 1e-3
+
+	// 三麻的听牌率表由四麻的表换算得到
+	assert.Equal(100.0, CalcTenpaiRate3([]*model.Meld{{}, {}, {}, {}}, nil, nil))
+	assert.InDelta(GetTenpaiRate3(19.88), CalcTenpaiRate3([]*model.Meld{{}}, []int{1, 2, 3, 4, 5}, []int{2}), eps)
+	assert.InDelta(GetTenpaiRate3(5), CalcTenpaiRate3(nil, []int{1, 2, 3, 4, 5}, nil), eps)
+	assert.True(CalcTenpaiRate3([]*model.Meld{{}, {}}, []int{1, 2, 3, 4, 5}, []int{2, 4}) > CalcTenpaiRate([]*model.Meld{{}, {}}, []int{1, 2, 3, 4, 5}, []int{2, 4}))
+}
+
+func TestCalculateAvgAgariRate_sanma(t *testing.T) {
+	const eps = 1e-3
+
+	// 三麻时 1m 9m 的和率与字牌相同
+	playerInfo := &model.PlayerInfo{IsSanma: true}
+	assert.Equal(t, CalculateAvgAgariRate(Waits{27: 2, 28: 2}, playerInfo), CalculateAvgAgariRate(Waits{0: 2, 8: 2}, playerInfo))
+	assert.NotEqual(t, CalculateAvgAgariRate(Waits{0: 2, 8: 2}, nil), CalculateAvgAgariRate(Waits{0: 2, 8: 2}, playerInfo))
+
+	// 放铳的对手少一家，和率比四麻低
+	assert.InDelta(t, honorTileNonDankiAgariTable[2]*sanmaAgariMulti, CalculateAgariRateOfEachTile(Waits{27: 2, 28: 2}, playerInfo)[27], eps)
+	assert.True(t, CalculateAvgAgariRate(Waits{12: 4, 15: 4}, playerInfo) < CalculateAvgAgariRate(Waits{12: 4, 15: 4}, nil))
+}
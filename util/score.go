@@ -0,0 +1,117 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"sort"
+)
+
+// 某种手牌拆解下的计分明细
+type HandScore struct {
+	DivideResult *DivideResult
+	YakuTypes    []int // 已排序
+	YakumanTimes int
+
+	YakuHan    int // 役种的番数，不含宝牌
+	NumDora    int // 宝牌数（含拔北宝牌）
+	NumRedFive int // 赤宝牌数
+	NumUraDora int // 里宝牌数
+	Han        int // 总番数，役满时为 0
+
+	Fu      int      // 进位后的符数，役满时为 0
+	FuItems []FuItem // 符数明细（进位前）
+
+	Point int // 和牌者获得的点数（不含本场和立直棒）
+
+	// 点数支付
+	RonPoint         int // 荣和时放铳者支付的点数
+	TsumoChildPoint  int // 自摸时子家支付的点数
+	TsumoParentPoint int // 子家自摸时亲家支付的点数
+}
+
+// 役满、累计役满、三倍满等的名称，不满满贯时返回空字符串
+func (s *HandScore) LimitName() string {
+	if s.YakumanTimes > 0 {
+		if s.YakumanTimes == 1 {
+			return "役满"
+		}
+		return fmt.Sprintf("%d倍役满", s.YakumanTimes)
+	}
+	basicPoint := calcBasicPoint(s.Han, s.Fu, 0)
+	switch {
+	case basicPoint >= 8000:
+		return "累计役满"
+	case basicPoint >= 6000:
+		return "三倍满"
+	case basicPoint >= 4000:
+		return "倍满"
+	case basicPoint >= 3000:
+		return "跳满"
+	case basicPoint >= 2000:
+		return "满贯"
+	default:
+		return ""
+	}
+}
+
+// 已和牌，计算所有有役的手牌拆解下的计分明细，按点数从高到低排序
+// 调用前请设置 IsTsumo WinTile，里宝牌需设置 UraDoraTiles
+// 无役时返回空
+func ScoreHand(playerInfo *model.PlayerInfo) (scores []*HandScore) {
+	isNaki := playerInfo.IsNaki()
+
+	numRedFive := 0
+	for _, num := range playerInfo.NumRedFives {
+		numRedFive += num
+	}
+	numDora := playerInfo.CountDora() - numRedFive
+	numUraDora := playerInfo.CountUraDora()
+
+	for _, divideResult := range DivideTiles34(playerInfo.HandTiles34) {
+		_hi := &_handInfo{
+			PlayerInfo:   playerInfo,
+			divideResult: divideResult,
+		}
+		yakuTypes := findYakuTypes(_hi, isNaki)
+		if len(yakuTypes) == 0 {
+			// 此手牌拆解下无役
+			continue
+		}
+		sort.Ints(yakuTypes)
+
+		score := &HandScore{
+			DivideResult: divideResult,
+			YakuTypes:    yakuTypes,
+			YakumanTimes: CalcYakumanTimes(yakuTypes, isNaki),
+			NumDora:      numDora,
+			NumRedFive:   numRedFive,
+			NumUraDora:   numUraDora,
+		}
+		if score.YakumanTimes == 0 {
+			score.YakuHan = CalcYakuHan(yakuTypes, isNaki)
+			score.Han = score.YakuHan + numDora + numRedFive + numUraDora
+			score.Fu = _hi.calcFuWithItems(isNaki, &score.FuItems)
+		}
+
+		if playerInfo.IsTsumo && playerInfo.IsSanma {
+			score.Point = CalcPointTsumoSum3(score.Han, score.Fu, score.YakumanTimes, playerInfo.IsParent)
+			score.TsumoChildPoint, score.TsumoParentPoint = CalcPointTsumo3(score.Han, score.Fu, score.YakumanTimes, playerInfo.IsParent)
+		} else if playerInfo.IsTsumo {
+			score.Point = CalcPointTsumoSum(score.Han, score.Fu, score.YakumanTimes, playerInfo.IsParent)
+			score.TsumoChildPoint, score.TsumoParentPoint = CalcPointTsumo(score.Han, score.Fu, score.YakumanTimes, playerInfo.IsParent)
+		} else {
+			score.Point = CalcPointRon(score.Han, score.Fu, score.YakumanTimes, playerInfo.IsParent)
+			score.RonPoint = score.Point
+		}
+		scores = append(scores, score)
+	}
+
+	// 高点法
+	sort.SliceStable(scores, func(i, j int) bool {
+		if scores[i].Point != scores[j].Point {
+			return scores[i].Point > scores[j].Point
+		}
+		return scores[i].Han > scores[j].Han
+	})
+	return
+}
@@ -0,0 +1,63 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestScoreHand(t *testing.T) {
+	assert := assert.New(t)
+
+	// 子家自摸，两种拆解取高点
+	tiles34 := MustStrToTiles34("22334455m 234p 234s")
+	playerInfo := model.NewSimplePlayerInfo(tiles34, nil)
+	playerInfo.SelfWindTile = 28
+	playerInfo.WinTile = MustStrToTile34("4p")
+	playerInfo.IsTsumo = true
+	scores := ScoreHand(playerInfo)
+	if assert.Len(scores, 2) {
+		s := scores[0]
+		assert.Equal(6, s.Han)
+		assert.Equal(20, s.Fu)
+		assert.Equal([]FuItem{{"符底", 20}}, s.FuItems)
+		assert.Equal(12000, s.Point)
+		assert.Equal(3000, s.TsumoChildPoint)
+		assert.Equal(6000, s.TsumoParentPoint)
+		assert.Equal("跳满", s.LimitName())
+		assert.Equal(4, scores[1].Han)
+	}
+
+	// 立直荣和，暗刻和坎张
+	tiles34 = MustStrToTiles34("555m 13p 789s 11666z")
+	tiles34[MustStrToTile34("2p")]++
+	playerInfo = model.NewSimplePlayerInfo(tiles34, nil)
+	playerInfo.SelfWindTile = 28
+	playerInfo.WinTile = MustStrToTile34("2p")
+	playerInfo.IsRiichi = true
+	playerInfo.DoraTiles = []int{MustStrToTile34("5m")}
+	playerInfo.UraDoraTiles = []int{MustStrToTile34("1z")}
+	scores = ScoreHand(playerInfo)
+	if assert.Len(scores, 1) {
+		s := scores[0]
+		assert.Equal(3, s.NumDora)
+		assert.Equal(2, s.NumUraDora)
+		assert.Equal(7, s.Han)
+		assert.Equal([]FuItem{
+			{"符底", 20},
+			{"暗刻 555m", 4},
+			{"暗刻 666z", 8},
+			{"雀头 11z", 2},
+			{"门清荣和", 10},
+			{"坎张", 2},
+		}, s.FuItems)
+		assert.Equal(50, s.Fu)
+		assert.Equal(12000, s.RonPoint)
+	}
+
+	// 无役
+	playerInfo = model.NewSimplePlayerInfo(MustStrToTiles34("123m 555m 13p 789s 11z"), nil)
+	playerInfo.HandTiles34[MustStrToTile34("2p")]++
+	playerInfo.WinTile = MustStrToTile34("2p")
+	assert.Empty(ScoreHand(playerInfo))
+}
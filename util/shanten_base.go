@@ -27,6 +27,24 @@ func CalculateShantenOfChiitoi(tiles34 []int) int {
 	return shanten
 }
 
+// 国士无双向听数 = 13-幺九牌种类数-(有幺九对子?1:0)
+func CalculateShantenOfKokushi(tiles34 []int) int {
+	shanten := 13
+	hasPair := false
+	for _, tile := range YaochuTiles {
+		if c := tiles34[tile]; c > 0 {
+			shanten--
+			if c >= 2 {
+				hasPair = true
+			}
+		}
+	}
+	if hasPair {
+		shanten--
+	}
+	return shanten
+}
+
 type shanten struct {
 	tiles         []int
 	numberMelds   int
@@ -346,7 +364,7 @@ func CalculateShantenOfNormal(tiles34 []int, countOfTiles int) int {
 	return st.minShanten
 }
 
-// 根据手牌计算向听数（不考虑国士）
+// 根据手牌计算向听数（考虑七对和国士）
 // 3k+1 和 3k+2 张牌都行
 func CalculateShanten(tiles34 []int) int {
 	countOfTiles := CountOfTiles34(tiles34) // 若入参带 countOfTiles，能节省约 5% 的时间
@@ -354,8 +372,9 @@ func CalculateShanten(tiles34 []int) int {
 		panic(fmt.Sprintln("[CalculateShanten] 参数错误 >14", tiles34, countOfTiles))
 	}
 	minShanten := CalculateShantenOfNormal(tiles34, countOfTiles)
-	if countOfTiles >= 13 { // 考虑七对子
+	if countOfTiles >= 13 { // 考虑七对子和国士无双
 		minShanten = MinInt(minShanten, CalculateShantenOfChiitoi(tiles34))
+		minShanten = MinInt(minShanten, CalculateShantenOfKokushi(tiles34))
 	}
 	return minShanten
 }
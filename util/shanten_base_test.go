@@ -20,6 +20,16 @@ func TestCalculateShantenOfChiitoi(t *testing.T) {
 	assert.Equal(2, CalculateShantenOfChiitoi(MustStrToTiles34("577m 23677p 245577s")))
 }
 
+func TestCalculateShantenOfKokushi(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(-1, CalculateShantenOfKokushi(MustStrToTiles34("119m 19p 19s 1234567z")))
+	assert.Equal(0, CalculateShantenOfKokushi(MustStrToTiles34("19m 19p 19s 1234567z")))
+	assert.Equal(0, CalculateShantenOfKokushi(MustStrToTiles34("19m 19p 19s 1123456z")))
+	assert.Equal(1, CalculateShantenOfKokushi(MustStrToTiles34("159m 19p 19s 123456z")))
+	assert.Equal(8, CalculateShantenOfKokushi(MustStrToTiles34("258m 258s 258p 12345z")))
+}
+
 func TestCalculateShantenOfNormal(t *testing.T) {
 	assert := assert.New(t)
 
@@ -38,6 +48,8 @@ func TestCalculateShanten(t *testing.T) {
 	assert.Equal(1, CalculateShanten(MustStrToTiles34("123456789m 147s 14m")))
 	assert.Equal(2, CalculateShanten(MustStrToTiles34("123456789m 147s 1m")))
 	assert.Equal(6, CalculateShanten(MustStrToTiles34("258m 258s 258p 12345z"))) // 和牌最远
+	assert.Equal(0, CalculateShanten(MustStrToTiles34("19m 19p 19s 1234567z")))  // 国士十三面
+	assert.Equal(-1, CalculateShanten(MustStrToTiles34("19m 19p 19s 12345677z")))
 	assert.Equal(0, CalculateShanten(MustStrToTiles34("123456789m 1134p")))
 	assert.Equal(-1, CalculateShanten(MustStrToTiles34("123456789m 11345p")))
 
@@ -54,7 +54,7 @@ type Hand13AnalysisResult struct {
 
 	// 摸到非进张牌时的进张数的加权均值（非改良+改良。对于非改良牌，其进张数为 Waits.AllCount()）
 	// 这里只考虑一巡的改良均值
-	// TODO: 在考虑改良的情况下，如何计算向听前进所需要的摸牌次数的期望值？蒙特卡罗方法？
+	// 考虑改良时向听前进所需的摸牌次数，可以用蒙特卡罗模拟估计（见 Rollout13）
 	AvgImproveWaitsCount float64
 
 	// 听牌时的手牌和率
@@ -70,6 +70,9 @@ type Hand13AnalysisResult struct {
 	// （鸣牌时）是否片听
 	IsPartWait bool
 
+	// （鸣牌时）一向听和两向听时，各个役的成立概率
+	YakuPaths *YakuPathResult
+
 	// 宝牌个数（手牌+副露）
 	DoraCount int
 
@@ -82,6 +85,9 @@ type Hand13AnalysisResult struct {
 	// 局收支
 	MixedRoundPoint float64
 
+	// 多巡的牌效率，由 SortByTurns 计算
+	Turns *TurnsResult
+
 	// TODO: 赤牌改良提醒
 }
 
@@ -352,6 +358,11 @@ func (n *shantenSearchNode13) analysis(playerInfo *model.PlayerInfo, considerImp
 		}
 	}
 
+	// 副露手未听牌时，役种信息不全，估计各个役的成立概率
+	if result13.IsNaki && shanten13 >= 1 && shanten13 <= 2 {
+		result13.YakuPaths = CalcYakuPaths(playerInfo)
+	}
+
 	// 三向听七对子特殊提醒
 	if len(playerInfo.Melds) == 0 && shanten13 == 3 && CountPairsOfTiles34(tiles34)+shanten13 == 6 {
 		// 对于三向听，除非进张很差才会考虑七对子
@@ -566,6 +577,15 @@ type Hand14AnalysisResult struct {
 	// 副露信息（没有副露就是 nil）
 	// 比如用 23m 吃了牌，OpenTiles 就是 [1,2]
 	OpenTiles []int
+
+	// 考虑放铳后的期望收支，由 SortByEV 计算
+	EV float64
+
+	// 段位战终盘时的期望 pt，由 SortByRankPoint 计算
+	RankPointEV float64
+
+	// 切牌后听牌时的立直判断，由 CalcRiichiAdvices 计算
+	RiichiAdvice *RiichiAdvice
 }
 
 func (r *Hand14AnalysisResult) String() string {
@@ -894,6 +914,7 @@ func calculateMeldShanten(tiles34 []int, calledTile int, isRedFive bool, allowCh
 // calledTile 他家出的牌，尝试鸣这张牌
 // isRedFive 这张牌是否为赤5
 // allowChi 是否允许吃这张牌
+// 役种和打点按照当前规则（见 SetRuleset）计算，如无食断时副露后的断幺不算役
 func CalculateMeld(playerInfo *model.PlayerInfo, calledTile int, isRedFive bool, allowChi bool) (minShanten int, results Hand14AnalysisResultList, incShantenResults Hand14AnalysisResultList) {
 	if len(playerInfo.LeftTiles34) == 0 {
 		playerInfo.FillLeftTiles34()
@@ -941,5 +962,9 @@ func CalculateMeld(playerInfo *model.PlayerInfo, calledTile int, isRedFive bool,
 	results.Sort(false)
 	incShantenResults.Sort(false)
 
+	// 避开无役的路线
+	results.sortNoYakuRoutesLast()
+	incShantenResults.sortNoYakuRoutesLast()
+
 	return
 }
@@ -295,6 +295,7 @@ func TestBestDiscard(t *testing.T) {
 	assert.Equal("7m", bestHumanDiscardTile(t, "234788m 234567s 33z", "8m"))   // C3Q17 和率下降一点但是打点提升
 	assert.Equal("8m", bestHumanDiscardTile(t, "234788m 234567s 33z", "3z"))   // C3Q17 打点充足时和率优先
 	assert.Equal("7m", bestHumanDiscardTile(t, "334557m 222p 789s 33z", "9s")) // C3Q18 和率下降一点但是打点提升
+	assert.Equal("5m", bestHumanDiscardTile(t, "159m 19p 19s 1234567z", ""))   // 国士十三面
 
 	// 一向听 技术论
 	//assert.Equal("1m", bestHumanDiscardTile(t, "1223446m 345p 1178s", "8s"))
@@ -39,6 +39,8 @@ func TestCalculateShantenAndWaits13(t *testing.T) {
 
 	// closed
 	assert.Equal("听牌 3 进张 [7z]", toString(CalculateShantenAndWaits13(MustStrToTiles34("1122334455667z"), nil)))
+	assert.Equal("听牌 39 进张 [19m 19p 19s 1234567z]", toString(CalculateShantenAndWaits13(MustStrToTiles34("19m 19p 19s 1234567z"), nil))) // 国士十三面
+	assert.Equal("听牌 4 进张 [7z]", toString(CalculateShantenAndWaits13(MustStrToTiles34("19m 19p 19s 1123456z"), nil)))
 	assert.Equal("听牌 4 进张 [4s]", toString(CalculateShantenAndWaits13(MustStrToTiles34("123456789m 1135s"), nil)))
 	assert.Equal("听牌 8 进张 [25s]", toString(CalculateShantenAndWaits13(MustStrToTiles34("123456789m 1134s"), nil)))
 	assert.Equal("一向听 61 进张 [12345678m 47p 12345678s]", toString(CalculateShantenAndWaits13(MustStrToTiles34("3456m 3456s 44456p"), nil)))
@@ -0,0 +1,399 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"math"
+	"sort"
+)
+
+// 多巡的牌效率
+// 在剩余牌不变（不考虑他家摸走的牌）的近似下，按照最优的切牌策略，精确计算 k 次摸牌内听牌和自摸和牌的概率
+// 与只考虑一巡改良的 AvgImproveWaitsCount 相比，能够反映改良、向听前进后的形状等多巡的影响
+// 只考虑牌型，不考虑役和打点
+
+const (
+	// 默认计算的摸牌次数
+	defaultTurnsHorizon = 12
+
+	// 向听数不超过该值时，考虑第一次摸牌的改良，且摸牌后考虑进张数最多的两种切法
+	// 否则只考虑向听前进，且只考虑进张数最多的切法
+	turnsFullSearchMaxShanten = 2
+
+	// 计算的手牌数（结点数）的上限，超过时放弃计算，以控制计算量
+	// 两向听的手牌约需要 7000 个结点
+	turnsMaxNodes = 10000
+)
+
+// 手牌的各个牌的枚数，用作 memo 的 key
+type turnsHandKey [34]uint8
+
+func newTurnsHandKey(tiles34 []int) (key turnsHandKey) {
+	for i, c := range tiles34 {
+		key[i] = uint8(c)
+	}
+	return
+}
+
+func (k turnsHandKey) tiles34() []int {
+	tiles34 := make([]int, 34)
+	for i, c := range k {
+		tiles34[i] = int(c)
+	}
+	return tiles34
+}
+
+type turnsMemoKey struct {
+	hand  turnsHandKey
+	turns int
+}
+
+// 摸到某张牌后可以选择的手牌（摸切以外的选择）
+type turnsDraw struct {
+	rate      float64 // 摸到这张牌的概率
+	nextHands []turnsHandKey
+}
+
+type turnsNode struct {
+	shanten int
+
+	// 进张数，听牌时为和了牌数
+	waitsCount int
+
+	// 向听前进的摸牌
+	advances []turnsDraw
+
+	// 向听不变但进张增加的摸牌（改良），需要时才计算
+	improves         []turnsDraw
+	improvesComputed bool
+}
+
+type TurnsResult struct {
+	// 计算的摸牌次数
+	Turns int
+
+	// Turns 次摸牌内听牌的概率 (0-100)
+	TenpaiRate float64
+
+	// Turns 次摸牌内自摸和牌的概率 (0-100)，不考虑役
+	AgariRate float64
+
+	// 听牌所需的摸牌次数的期望，超过 Turns 次的按 Turns 次计算
+	AvgTenpaiTurns float64
+
+	// 自摸和牌所需的摸牌次数的期望，超过 Turns 次的按 Turns 次计算
+	AvgAgariTurns float64
+}
+
+// 同一组剩余牌下的多巡牌效率计算，不同手牌之间共享 memo
+type turnsSearcher struct {
+	leftTiles34 []int
+	leftCount   int
+
+	// 总的摸牌次数
+	turns int
+
+	// 前 improveDepth 次摸牌考虑改良
+	improveDepth int
+
+	// 摸牌后有多种切法时，只考虑切牌后进张数最多的 maxBranches 种
+	maxBranches int
+
+	// 结点数超过 maxNodes 时放弃计算，此时 exceeded 为 true
+	maxNodes int
+	exceeded bool
+
+	waitsMemo  map[turnsHandKey]int
+	nodes      map[turnsHandKey]*turnsNode
+	tenpaiMemo map[turnsMemoKey]float64
+	agariMemo  map[turnsMemoKey]float64
+}
+
+// shanten 为要计算的手牌中的最大向听数，用来控制计算量
+func newTurnsSearcher(leftTiles34 []int, turns int, shanten int) *turnsSearcher {
+	improveDepth, maxBranches := 1, 2
+	if shanten > turnsFullSearchMaxShanten {
+		improveDepth, maxBranches = 1, 1
+	}
+	return &turnsSearcher{
+		leftTiles34:  leftTiles34,
+		leftCount:    CountOfTiles34(leftTiles34),
+		turns:        turns,
+		improveDepth: improveDepth,
+		maxBranches:  maxBranches,
+		maxNodes:     turnsMaxNodes,
+		waitsMemo:    map[turnsHandKey]int{},
+		nodes:        map[turnsHandKey]*turnsNode{},
+		tenpaiMemo:   map[turnsMemoKey]float64{},
+		agariMemo:    map[turnsMemoKey]float64{},
+	}
+}
+
+// 向听数的计算很快，且很少重复，不做 memo
+func (s *turnsSearcher) shanten(tiles34 []int) int {
+	return CalculateShanten(tiles34)
+}
+
+// 3k+1 张牌的进张数，听牌时为和了牌数
+func (s *turnsSearcher) waitsCount(tiles34 []int) int {
+	key := newTurnsHandKey(tiles34)
+	if cnt, ok := s.waitsMemo[key]; ok {
+		return cnt
+	}
+	shanten := s.shanten(tiles34)
+	cnt := 0
+	for tile, left := range s.leftTiles34 {
+		if left == 0 || tiles34[tile] == 4 {
+			continue
+		}
+		tiles34[tile]++
+		if s.shanten(tiles34) < shanten {
+			cnt += left
+		}
+		tiles34[tile]--
+	}
+	s.waitsMemo[key] = cnt
+	return cnt
+}
+
+// 摸牌后切牌，返回满足条件的切法中切牌后进张数最多的 maxBranches 种
+func (s *turnsSearcher) bestDiscards(tiles34 []int, drawTile int, ok func(shanten int, waitsCount int) bool) (nextHands []turnsHandKey) {
+	type candidate struct {
+		hand       turnsHandKey
+		waitsCount int
+	}
+	var candidates []candidate
+	for discardTile, c := range tiles34 {
+		if c == 0 || discardTile == drawTile {
+			continue
+		}
+		tiles34[discardTile]--
+		if shanten := s.shanten(tiles34); ok(shanten, 0) {
+			if waitsCount := s.waitsCount(tiles34); ok(shanten, waitsCount) {
+				candidates = append(candidates, candidate{newTurnsHandKey(tiles34), waitsCount})
+			}
+		}
+		tiles34[discardTile]++
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].waitsCount > candidates[j].waitsCount
+	})
+	for i := 0; i < len(candidates) && i < s.maxBranches; i++ {
+		nextHands = append(nextHands, candidates[i].hand)
+	}
+	return
+}
+
+// 计算向听前进的摸牌
+func (s *turnsSearcher) node(hand turnsHandKey) *turnsNode {
+	if n, ok := s.nodes[hand]; ok {
+		return n
+	}
+
+	tiles34 := hand.tiles34()
+	shanten := s.shanten(tiles34)
+	if len(s.nodes) >= s.maxNodes {
+		s.exceeded = true
+		return &turnsNode{shanten: shanten}
+	}
+	n := &turnsNode{
+		shanten:    shanten,
+		waitsCount: s.waitsCount(tiles34),
+	}
+	s.nodes[hand] = n
+	if shanten == shantenStateTenpai {
+		return n
+	}
+
+	for drawTile, left := range s.leftTiles34 {
+		if left == 0 || tiles34[drawTile] == 4 {
+			continue
+		}
+		tiles34[drawTile]++
+		if s.shanten(tiles34) < shanten {
+			n.advances = append(n.advances, turnsDraw{
+				rate: float64(left) / float64(s.leftCount),
+				nextHands: s.bestDiscards(tiles34, drawTile, func(_shanten int, _ int) bool {
+					return _shanten < shanten
+				}),
+			})
+		}
+		tiles34[drawTile]--
+	}
+	return n
+}
+
+// 计算改良：向听不变时，切牌后进张增加
+func (s *turnsSearcher) calcImproves(n *turnsNode, hand turnsHandKey) {
+	if n.improvesComputed {
+		return
+	}
+	n.improvesComputed = true
+	if n.shanten == shantenStateTenpai {
+		return
+	}
+
+	tiles34 := hand.tiles34()
+	for drawTile, left := range s.leftTiles34 {
+		if left == 0 || tiles34[drawTile] == 4 {
+			continue
+		}
+		tiles34[drawTile]++
+		if s.shanten(tiles34) == n.shanten {
+			nextHands := s.bestDiscards(tiles34, drawTile, func(_shanten int, waitsCount int) bool {
+				// waitsCount 为 0 时表示尚未计算进张数
+				return _shanten == n.shanten && (waitsCount == 0 || waitsCount > n.waitsCount)
+			})
+			if len(nextHands) > 0 {
+				n.improves = append(n.improves, turnsDraw{
+					rate:      float64(left) / float64(s.leftCount),
+					nextHands: nextHands,
+				})
+			}
+		}
+		tiles34[drawTile]--
+	}
+}
+
+// k 次摸牌内听牌的概率 (0-1)
+func (s *turnsSearcher) tenpaiRate(hand turnsHandKey, turns int) float64 {
+	if s.exceeded {
+		return 0
+	}
+	// 一次摸牌最多前进一向听
+	n := s.node(hand)
+	if n.shanten <= shantenStateTenpai {
+		return 1
+	} else if turns < n.shanten {
+		return 0
+	}
+	key := turnsMemoKey{hand, turns}
+	if rate, ok := s.tenpaiMemo[key]; ok {
+		return rate
+	}
+	rate := s.expand(n, hand, turns, s.tenpaiRate)
+	s.tenpaiMemo[key] = rate
+	return rate
+}
+
+// k 次摸牌内自摸和牌的概率 (0-1)
+func (s *turnsSearcher) agariRate(hand turnsHandKey, turns int) float64 {
+	if s.exceeded {
+		return 0
+	}
+	n := s.node(hand)
+	if turns <= n.shanten {
+		return 0
+	}
+	if n.shanten == shantenStateTenpai {
+		// 听牌后摸切，不考虑听牌后的改良
+		return 1 - math.Pow(1-float64(n.waitsCount)/float64(s.leftCount), float64(turns))
+	}
+	key := turnsMemoKey{hand, turns}
+	if rate, ok := s.agariMemo[key]; ok {
+		return rate
+	}
+	rate := s.expand(n, hand, turns, s.agariRate)
+	s.agariMemo[key] = rate
+	return rate
+}
+
+// 摸一张牌后按最优策略切牌，没有更好的选择时摸切
+func (s *turnsSearcher) expand(n *turnsNode, hand turnsHandKey, turns int, f func(turnsHandKey, int) float64) float64 {
+	stayRate := f(hand, turns-1)
+	rate := 0.0
+	sumDrawRate := 0.0
+	bestOf := func(draw turnsDraw) float64 {
+		best := stayRate
+		for _, next := range draw.nextHands {
+			if r := f(next, turns-1); r > best {
+				best = r
+			}
+		}
+		return best
+	}
+	for _, draw := range n.advances {
+		rate += draw.rate * bestOf(draw)
+		sumDrawRate += draw.rate
+	}
+	// 只在前几次摸牌考虑改良，以控制计算量
+	if s.turns-turns < s.improveDepth {
+		s.calcImproves(n, hand)
+		for _, draw := range n.improves {
+			rate += draw.rate * bestOf(draw)
+			sumDrawRate += draw.rate
+		}
+	}
+	rate += (1 - sumDrawRate) * stayRate
+	return rate
+}
+
+// 超出计算量时返回 nil
+func (s *turnsSearcher) result(tiles34 []int, turns int) *TurnsResult {
+	hand := newTurnsHandKey(tiles34)
+	r := &TurnsResult{Turns: turns}
+	for k := 0; k < turns; k++ {
+		r.AvgTenpaiTurns += 1 - s.tenpaiRate(hand, k)
+		r.AvgAgariTurns += 1 - s.agariRate(hand, k)
+	}
+	r.TenpaiRate = 100 * s.tenpaiRate(hand, turns)
+	r.AgariRate = 100 * s.agariRate(hand, turns)
+	if s.exceeded {
+		return nil
+	}
+	return r
+}
+
+// 计算 3k+1 张牌在 turns 次摸牌内听牌、自摸和牌的概率
+// turns 为 0 时取 defaultTurnsHorizon
+// 超出计算量（见 turnsMaxNodes）时返回 nil
+func CalculateTurns13(tiles34 []int, leftTiles34 []int, turns int) *TurnsResult {
+	if len(leftTiles34) == 0 {
+		leftTiles34 = InitLeftTiles34WithTiles34(tiles34)
+	}
+	if turns <= 0 {
+		turns = defaultTurnsHorizon
+	}
+	return newTurnsSearcher(leftTiles34, turns, CalculateShanten(tiles34)).result(tiles34, turns)
+}
+
+// 剩余摸牌次数与 defaultTurnsHorizon 中的较小值
+func turnsHorizon(leftDrawTilesCount int) int {
+	if leftDrawTilesCount == model.LeftDrawTilesCountUnknown {
+		return defaultTurnsHorizon
+	}
+	return MaxInt(1, MinInt(defaultTurnsHorizon, leftDrawTilesCount/4))
+}
+
+// 计算各个切牌的多巡牌效率，并按照 turns 次摸牌内的自摸和牌概率、听牌概率从高到低排序
+// turns 为 0 时根据剩余摸牌数决定
+// 超出计算量（见 turnsMaxNodes）时不计算多巡的牌效率，保持原有的排序
+func (l Hand14AnalysisResultList) SortByTurns(turns int) {
+	if len(l) == 0 {
+		return
+	}
+	if turns <= 0 {
+		turns = turnsHorizon(l[0].LeftDrawTilesCount)
+	}
+	// 切牌不影响剩余牌，所有切牌共享 memo
+	maxShanten := 0
+	for _, r := range l {
+		maxShanten = MaxInt(maxShanten, r.Result13.Shanten)
+	}
+	searcher := newTurnsSearcher(l[0].Result13.LeftTiles34, turns, maxShanten)
+	results := make([]*TurnsResult, len(l))
+	for i, r := range l {
+		if results[i] = searcher.result(r.Result13.Tiles34, turns); results[i] == nil {
+			return
+		}
+	}
+	for i, r := range l {
+		r.Result13.Turns = results[i]
+	}
+	sort.SliceStable(l, func(i, j int) bool {
+		ri, rj := l[i].Result13.Turns, l[j].Result13.Turns
+		if !InDelta(ri.AgariRate, rj.AgariRate, 0.01) {
+			return ri.AgariRate > rj.AgariRate
+		}
+		return ri.TenpaiRate > rj.TenpaiRate
+	})
+}
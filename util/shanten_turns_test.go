@@ -0,0 +1,128 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"math"
+	"testing"
+)
+
+func TestCalculateTurns13(t *testing.T) {
+	assert := assert.New(t)
+
+	// 单骑听牌时摸切，和率只取决于和了牌数
+	tiles34 := MustStrToTiles34("123456789m 234p 5s")
+	leftTiles34 := InitLeftTiles34WithTiles34(tiles34)
+	r := CalculateTurns13(tiles34, leftTiles34, 6)
+	assert.Equal(6, r.Turns)
+	assert.InDelta(100, r.TenpaiRate, 1e-9)
+	assert.InDelta(0, r.AvgTenpaiTurns, 1e-9)
+	assert.InDelta(100*(1-math.Pow(1-3.0/123, 6)), r.AgariRate, 1e-9)
+
+	// 摸牌次数越多，听牌和自摸的概率越高
+	tiles34 = MustStrToTiles34("12388m 45679p 556s")
+	prev := CalculateTurns13(tiles34, nil, 1)
+	assert.True(prev.TenpaiRate > 0)
+	assert.Zero(prev.AgariRate)
+	for turns := 2; turns <= 8; turns++ {
+		r := CalculateTurns13(tiles34, nil, turns)
+		assert.True(r.TenpaiRate > prev.TenpaiRate)
+		assert.True(r.AgariRate > prev.AgariRate)
+		assert.True(r.AvgTenpaiTurns < float64(turns))
+		prev = r
+	}
+
+	// 两向听：改良和形状的影响，切 9p 留 1p 的边张比切 7p 拆搭子更好
+	r9p := CalculateTurns13(MustStrToTiles34("3456m 13789p 4578s"), nil, 0)
+	r7p := CalculateTurns13(MustStrToTiles34("3456m 13899p 4578s"), nil, 0)
+	assert.Equal(defaultTurnsHorizon, r9p.Turns)
+	assert.True(r9p.TenpaiRate > r7p.TenpaiRate)
+	assert.True(r9p.AgariRate > r7p.AgariRate)
+	assert.True(r9p.AvgTenpaiTurns < r7p.AvgTenpaiTurns)
+}
+
+func TestHand14AnalysisResultList_SortByTurns(t *testing.T) {
+	assert := assert.New(t)
+
+	pi := model.NewSimplePlayerInfo(MustStrToTiles34("12388m 455679p 556s"), nil)
+	_, results14, _ := CalculateShantenWithImproves14(pi)
+	results14.SortByTurns(8)
+	if !assert.Len(results14, 4) {
+		return
+	}
+	for i, r := range results14 {
+		if assert.NotNil(r.Result13.Turns) {
+			assert.Equal(8, r.Result13.Turns.Turns)
+			if i > 0 {
+				assert.True(r.Result13.Turns.AgariRate <= results14[i-1].Result13.Turns.AgariRate+0.01)
+			}
+		}
+	}
+	// 切 5s 9p 保留两面，比切 5p 6s 更好
+	best := []int{results14[0].DiscardTile, results14[1].DiscardTile}
+	assert.ElementsMatch([]int{MustStrToTile34("9p"), MustStrToTile34("5s")}, best)
+}
+
+func TestTurnsSearcher_maxNodes(t *testing.T) {
+	assert := assert.New(t)
+
+	// 超出计算量时放弃计算
+	tiles34 := MustStrToTiles34("3456m 13789p 4578s")
+	s := newTurnsSearcher(InitLeftTiles34WithTiles34(tiles34), defaultTurnsHorizon, 2)
+	s.maxNodes = 100
+	assert.Nil(s.result(tiles34, defaultTurnsHorizon))
+	assert.True(s.exceeded)
+	assert.Len(s.nodes, s.maxNodes)
+
+	// 向听数较大时结点数很多，不计算多巡的牌效率，保持原有的排序
+	pi := model.NewSimplePlayerInfo(MustStrToTiles34("1479m 258p 3699s 123z"), nil)
+	_, results14, _ := CalculateShantenWithImproves14(pi)
+	discardTiles := []int{}
+	for _, r := range results14 {
+		discardTiles = append(discardTiles, r.DiscardTile)
+	}
+	results14.SortByTurns(defaultTurnsHorizon)
+	for i, r := range results14 {
+		assert.Equal(discardTiles[i], r.DiscardTile)
+		assert.Nil(r.Result13.Turns)
+	}
+}
+
+func BenchmarkSortByTurns_Shanten1(b *testing.B) {
+	pi := model.NewSimplePlayerInfo(MustStrToTiles34("12388m 455679p 556s"), nil)
+	_, results14, _ := CalculateShantenWithImproves14(pi)
+	for i := 0; i < b.N; i++ {
+		// 52,462,477 ns/op
+		results14.SortByTurns(defaultTurnsHorizon)
+	}
+}
+
+func BenchmarkSortByTurns_Shanten2(b *testing.B) {
+	pi := model.NewSimplePlayerInfo(MustStrToTiles34("3456m 137899p 4578s"), nil)
+	_, results14, _ := CalculateShantenWithImproves14(pi)
+	for i := 0; i < b.N; i++ {
+		// 1,162,167,318 ns/op
+		// 与 CalculateShantenWithImproves14 的耗时相当
+		results14.SortByTurns(defaultTurnsHorizon)
+	}
+}
+
+func BenchmarkSortByTurns_Shanten3(b *testing.B) {
+	pi := model.NewSimplePlayerInfo(MustStrToTiles34("12688m 33579p 24s 56z"), nil)
+	_, results14, _ := CalculateShantenWithImproves14(pi)
+	for i := 0; i < b.N; i++ {
+		// 564,539,869 ns/op
+		// 三向听时只考虑进张数最多的切法
+		results14.SortByTurns(defaultTurnsHorizon)
+	}
+}
+
+func BenchmarkSortByTurns_Shanten5(b *testing.B) {
+	pi := model.NewSimplePlayerInfo(MustStrToTiles34("1479m 258p 3699s 123z"), nil)
+	_, results14, _ := CalculateShantenWithImproves14(pi)
+	for i := 0; i < b.N; i++ {
+		// 1,281,485,441 ns/op
+		// 结点数达到 turnsMaxNodes 后放弃计算
+		results14.SortByTurns(defaultTurnsHorizon)
+	}
+}
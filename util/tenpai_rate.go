@@ -5,6 +5,15 @@ import "github.com/EndlessCheng/mahjong-helper/util/model"
 // 没有立直时，根据玩家的副露、手切来判断其听牌率 (0-100)
 // TODO: 传入 *model.PlayerInfo
 func CalcTenpaiRate(melds []*model.Meld, discardTiles []int, meldDiscardsAt []int) float64 {
+	return calcTenpaiRate(melds, discardTiles, meldDiscardsAt, false)
+}
+
+// 同 CalcTenpaiRate，三麻时使用三麻的听牌率表
+func CalcTenpaiRate3(melds []*model.Meld, discardTiles []int, meldDiscardsAt []int) float64 {
+	return calcTenpaiRate(melds, discardTiles, meldDiscardsAt, true)
+}
+
+func calcTenpaiRate(melds []*model.Meld, discardTiles []int, meldDiscardsAt []int, isSanma bool) float64 {
 	isNaki := false
 	for _, meld := range melds {
 		if meld.MeldType != model.MeldTypeAnkan {
@@ -15,6 +24,9 @@ func CalcTenpaiRate(melds []*model.Meld, discardTiles []int, meldDiscardsAt []in
 	if !isNaki {
 		// 默听听牌率近似为巡目数
 		turn := len(discardTiles)
+		if isSanma {
+			return GetTenpaiRate3(float64(turn))
+		}
 		return float64(turn)
 	}
 
@@ -23,6 +35,9 @@ func CalcTenpaiRate(melds []*model.Meld, discardTiles []int, meldDiscardsAt []in
 	}
 
 	_tenpaiRate := tenpaiRate[len(melds)]
+	if isSanma {
+		_tenpaiRate = sanmaTenpaiRate[len(melds)]
+	}
 
 	turn := MinInt(len(discardTiles), len(_tenpaiRate)-1)
 	_tenpaiRateWithTurn := _tenpaiRate[turn]
@@ -20,6 +20,36 @@ func (hi *_handInfo) tsumo() bool {
 	return !hi.IsNaki() && hi.IsTsumo
 }
 
+// 门清限定，立直后一巡内和牌
+func (hi *_handInfo) ippatsu() bool {
+	return hi.IsIppatsu && (hi.IsRiichi || hi.IsDaburii)
+}
+
+// 摸到最后一张牌自摸（岭上牌不算海底）
+func (hi *_handInfo) haitei() bool {
+	return hi.IsLastTile && hi.IsTsumo && !hi.IsRinshan
+}
+
+// 荣和最后一张舍牌
+func (hi *_handInfo) houtei() bool {
+	return hi.IsLastTile && !hi.IsTsumo
+}
+
+// 开杠后摸岭上牌自摸
+func (hi *_handInfo) rinshan() bool {
+	return hi.IsRinshan && hi.IsTsumo
+}
+
+// 荣和他家加杠的牌
+func (hi *_handInfo) chankan() bool {
+	return hi.IsChankan && !hi.IsTsumo
+}
+
+// 门清限定，子家第一巡摸牌前荣和，且此前无人鸣牌
+func (hi *_handInfo) renhou() bool {
+	return hi.IsFirstDraw && !hi.IsTsumo && !hi.IsParent
+}
+
 // 门清限定
 func (hi *_handInfo) chiitoi() bool {
 	return hi.divideResult.IsChiitoi
@@ -344,6 +374,11 @@ var yakuCheckerMap = map[int]yakuChecker{
 	YakuRiichi:         (*_handInfo).riichi,
 	YakuChiitoi:        (*_handInfo).chiitoi,
 	YakuTsumo:          (*_handInfo).tsumo,
+	YakuIppatsu:        (*_handInfo).ippatsu,
+	YakuHaitei:         (*_handInfo).haitei,
+	YakuHoutei:         (*_handInfo).houtei,
+	YakuRinshan:        (*_handInfo).rinshan,
+	YakuChankan:        (*_handInfo).chankan,
 	YakuPinfu:          (*_handInfo).pinfu,
 	YakuRyanpeikou:     (*_handInfo).ryanpeikou,
 	YakuIipeikou:       (*_handInfo).iipeikou,
@@ -374,6 +409,9 @@ func findNormalYaku(hi *_handInfo, isNaki bool) (yakuTypes []int) {
 	}
 
 	for yakuType := range yakuHanMap {
+		if isNaki && yakuType == YakuTanyao && !ruleset.OpenTanyao {
+			continue
+		}
 		if checker, ok := yakuCheckerMap[yakuType]; ok {
 			if checker(hi) {
 				yakuTypes = append(yakuTypes, yakuType)
@@ -381,6 +419,11 @@ func findNormalYaku(hi *_handInfo, isNaki bool) (yakuTypes []int) {
 		}
 	}
 
+	// 人和的番数由规则决定
+	if !isNaki && ruleset.RenhouHan > 0 && !ruleset.isRenhouYakuman() && hi.renhou() {
+		yakuTypes = append(yakuTypes, YakuRenhou)
+	}
+
 	if considerOldYaku {
 		if !isNaki {
 			yakuHanMap = OldYakuHanMap
@@ -82,6 +82,18 @@ const (
 	YakuDaichikurin
 	YakuDaichisei
 
+	// 新增的役追加在末尾，以保持已有役的编号（JSON 输出的 type）不变
+	YakuKokushi
+	YakuKokushi13
+	YakuIppatsu
+	YakuHaitei
+	YakuHoutei
+	YakuRinshan
+	YakuChankan
+	YakuTenhou
+	YakuChiihou
+	YakuRenhou // 番数由规则决定
+
 	//_endYakuType  // 标记 enum 结束，方便计算有多少个 YakuType
 )
 
@@ -93,12 +105,12 @@ var YakuNameMap = map[int]string{
 	YakuChiitoi: "七对",
 
 	// Yaku based on luck
-	YakuTsumo: "自摸",
-	//YakuIppatsu: "一发",
-	//YakuHaitei:  "海底",
-	//YakuHoutei:  "河底",
-	//YakuRinshan: "岭上",
-	//YakuChankan: "抢杠",
+	YakuTsumo:   "自摸",
+	YakuIppatsu: "一发",
+	YakuHaitei:  "海底",
+	YakuHoutei:  "河底",
+	YakuRinshan: "岭上",
+	YakuChankan: "抢杠",
 	YakuDaburii: "w立",
 
 	// Yaku based on sequences
@@ -127,8 +139,8 @@ var YakuNameMap = map[int]string{
 	YakuChinitsu: "清一色",
 
 	// Yakuman
-	//YakuKokushi:       "国士",
-	//YakuKokushi13:     "国士十三面",
+	YakuKokushi:       "国士",
+	YakuKokushi13:     "国士十三面",
 	YakuSuuAnkou:      "四暗刻",
 	YakuSuuAnkouTanki: "四暗刻单骑",
 	YakuDaisangen:     "大三元",
@@ -140,8 +152,9 @@ var YakuNameMap = map[int]string{
 	YakuChuuren:       "九莲",
 	YakuChuuren9:      "纯正九莲",
 	YakuSuuKantsu:     "四杠子",
-	//YakuTenhou:        "天和",
-	//YakuChiihou:       "地和",
+	YakuTenhou:        "天和",
+	YakuChiihou:       "地和",
+	YakuRenhou:        "人和",
 }
 
 var OldYakuNameMap = map[int]string{
@@ -207,12 +220,12 @@ var YakuHanMap = _yakuHanMap{
 	YakuRiichi:  1,
 	YakuChiitoi: 2,
 
-	YakuTsumo: 1,
-	//YakuIppatsu: 1,
-	//YakuHaitei:  1,
-	//YakuHoutei:  1,
-	//YakuRinshan: 1,
-	//YakuChankan: 1,
+	YakuTsumo:   1,
+	YakuIppatsu: 1,
+	YakuHaitei:  1,
+	YakuHoutei:  1,
+	YakuRinshan: 1,
+	YakuChankan: 1,
 	YakuDaburii: 2,
 
 	YakuPinfu:          1,
@@ -238,10 +251,10 @@ var YakuHanMap = _yakuHanMap{
 }
 
 var NakiYakuHanMap = _yakuHanMap{
-	//YakuHaitei:  1,
-	//YakuHoutei:  1,
-	//YakuRinshan: 1,
-	//YakuChankan: 1,
+	YakuHaitei:  1,
+	YakuHoutei:  1,
+	YakuRinshan: 1,
+	YakuChankan: 1,
 
 	YakuSanshokuDoujun: 1,
 	YakuIttsuu:         1,
@@ -285,11 +298,23 @@ func CalcYakuHan(yakuTypes []int, isNaki bool) (cntHan int) {
 	}
 
 	for _, yakuType := range yakuTypes {
+		if isNaki && yakuType == YakuTanyao && !ruleset.OpenTanyao {
+			continue
+		}
 		if han, ok := yakuHanMap[yakuType]; ok {
 			cntHan += han
 		}
 	}
 
+	// 人和的番数由规则决定
+	if !isNaki && !ruleset.isRenhouYakuman() {
+		for _, yakuType := range yakuTypes {
+			if yakuType == YakuRenhou {
+				cntHan += ruleset.RenhouHan
+			}
+		}
+	}
+
 	if considerOldYaku {
 		if !isNaki {
 			yakuHanMap = OldYakuHanMap
@@ -310,8 +335,8 @@ func CalcYakuHan(yakuTypes []int, isNaki bool) (cntHan int) {
 //
 
 var YakumanTimesMap = map[int]int{
-	//YakuKokushi:       1,
-	//YakuKokushi13:     2,
+	YakuKokushi:       1,
+	YakuKokushi13:     2,
 	YakuSuuAnkou:      1,
 	YakuSuuAnkouTanki: 2,
 	YakuDaisangen:     1,
@@ -323,8 +348,8 @@ var YakumanTimesMap = map[int]int{
 	YakuChuuren:       1,
 	YakuChuuren9:      2,
 	YakuSuuKantsu:     1,
-	//YakuTenhou:        1,
-	//YakuChiihou:       1,
+	YakuTenhou:        1,
+	YakuChiihou:       1,
 }
 
 var NakiYakumanTimesMap = map[int]int{
@@ -355,7 +380,12 @@ func CalcYakumanTimes(yakuTypes []int, isNaki bool) (times int) {
 
 	for _, yakuman := range yakuTypes {
 		if t, ok := yakumanTimesMap[yakuman]; ok {
+			if !ruleset.DoubleYakuman {
+				t = 1
+			}
 			times += t
+		} else if yakuman == YakuRenhou && !isNaki && ruleset.isRenhouYakuman() {
+			times++
 		}
 	}
 
@@ -367,5 +397,9 @@ func CalcYakumanTimes(yakuTypes []int, isNaki bool) (times int) {
 		}
 	}
 
+	if !ruleset.MultipleYakuman && times > 1 {
+		times = 1
+	}
+
 	return
 }
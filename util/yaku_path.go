@@ -0,0 +1,297 @@
+package util
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"sort"
+	"strings"
+)
+
+// 副露手的役的路线：对于未听牌的副露手，估计各个役最终成立的概率
+// 用来在鸣牌判断和鸣牌何切中避开无役的路线
+// 只考虑副露后常见的役：役牌、断幺、混一色、对对和、混全带幺九、一气通贯、三色同顺
+
+// 还差 n 张牌时该役成立的概率（粗略估计）
+var yakuPathRates = [...]float64{0.9, 0.7, 0.45, 0.25, 0.1}
+
+// 役牌对子，剩余 0/1/2 枚时能碰出（或作为雀头和牌后自摸）的概率
+var yakuhaiPairRates = [...]float64{0, 0.4, 0.65}
+
+// 役牌单张，剩余至少两枚时能凑成刻子的概率
+const yakuhaiSingleRate = 0.1
+
+func yakuPathRate(missing int) float64 {
+	if missing < 0 || missing >= len(yakuPathRates) {
+		return 0
+	}
+	return yakuPathRates[missing]
+}
+
+type YakuPath struct {
+	YakuType int
+
+	// 成立的概率 (0-1)，已确定时为 1
+	Rate float64
+}
+
+type YakuPathResult struct {
+	// 按照成立的概率从高到低排序，不含概率为 0 的役
+	Paths []YakuPath
+
+	// 最终无役的概率 (0-1)，近似认为各个役相互独立
+	NoYakuRate float64
+}
+
+// 显示成立概率较高的几个役
+func (r *YakuPathResult) String() string {
+	const maxShownPaths = 3
+	var names []string
+	for i, p := range r.Paths {
+		if i == maxShownPaths {
+			break
+		}
+		names = append(names, fmt.Sprintf("%s%.0f%%", YakuNameMap[p.YakuType], p.Rate*100))
+	}
+	return strings.Join(names, " ")
+}
+
+type yakuPathCalculator struct {
+	tiles34     []int
+	leftTiles34 []int
+	melds       []model.Meld
+	pi          *model.PlayerInfo
+}
+
+func (c *yakuPathCalculator) isYakuhai(tile int) bool {
+	return tile >= 31 || tile == c.pi.RoundWindTile || tile == c.pi.SelfWindTile
+}
+
+// 有多个役牌刻子的可能时，取至少一个成立的概率
+func (c *yakuPathCalculator) yakuhai() float64 {
+	notRate := 1.0
+	for tile := 27; tile < 34; tile++ {
+		if !c.isYakuhai(tile) {
+			continue
+		}
+		for _, meld := range c.melds {
+			if meld.MeldType != model.MeldTypeChi && meld.Tiles[0] == tile {
+				return 1
+			}
+		}
+		left := c.leftTiles34[tile]
+		switch cnt := c.tiles34[tile]; {
+		case cnt >= 3:
+			return 1
+		case cnt == 2:
+			notRate *= 1 - yakuhaiPairRates[MinInt(left, 2)]
+		case cnt == 1 && left >= 2:
+			notRate *= 1 - yakuhaiSingleRate
+		}
+	}
+	return 1 - notRate
+}
+
+// 需要换掉手中所有的幺九牌
+func (c *yakuPathCalculator) tanyao() float64 {
+	if !ruleset.OpenTanyao {
+		return 0
+	}
+	for _, meld := range c.melds {
+		for _, tile := range meld.Tiles {
+			if isYaochupai(tile) {
+				return 0
+			}
+		}
+	}
+	missing := 0
+	for _, tile := range YaochuTiles {
+		missing += c.tiles34[tile]
+	}
+	return yakuPathRate(missing)
+}
+
+// 需要换掉手中其他花色的数牌，取最容易的花色
+func (c *yakuPathCalculator) honitsu() (rate float64) {
+	for suit := 0; suit < 3; suit++ {
+		ok := true
+		for _, meld := range c.melds {
+			if tile := meld.Tiles[0]; tile < 27 && tile/9 != suit {
+				ok = false
+				break
+			}
+		}
+		if !ok {
+			continue
+		}
+		missing := 0
+		for tile := 0; tile < 27; tile++ {
+			if tile/9 != suit {
+				missing += c.tiles34[tile]
+			}
+		}
+		if r := yakuPathRate(missing); r > rate {
+			rate = r
+		}
+	}
+	return
+}
+
+// 根据对对和的向听数估计，已经没有剩余牌的对子无法碰出
+func (c *yakuPathCalculator) toitoi() float64 {
+	sets := 0
+	for _, meld := range c.melds {
+		if meld.MeldType == model.MeldTypeChi {
+			return 0
+		}
+		sets++
+	}
+	pairs := 0
+	for tile, cnt := range c.tiles34 {
+		if cnt >= 3 {
+			sets++
+		} else if cnt == 2 && c.leftTiles34[tile] > 0 {
+			pairs++
+		}
+	}
+	if sets > 4 {
+		sets = 4
+	}
+	shanten := 8 - 2*sets - MinInt(pairs, 5-sets)
+	// 对子需要碰出特定的牌，比一般的进张更难
+	return yakuPathRate(shanten + 1)
+}
+
+// 需要换掉手中的 456，副露必须带幺九
+func (c *yakuPathCalculator) chanta() float64 {
+	for _, meld := range c.melds {
+		hasYaochu := false
+		for _, tile := range meld.Tiles {
+			if isYaochupai(tile) {
+				hasYaochu = true
+			}
+		}
+		if !hasYaochu {
+			return 0
+		}
+	}
+	missing := 0
+	for tile := 0; tile < 27; tile++ {
+		if t9 := tile % 9; t9 >= 3 && t9 <= 5 {
+			missing += c.tiles34[tile]
+		}
+	}
+	// 还需要与幺九牌相邻的搭子，比断幺更难
+	return yakuPathRate(missing + 1)
+}
+
+// 需要的顺子各自的第一张牌，计算还缺多少种牌
+// 与需要的顺子无关的副露超过一组时无法成立，缺的牌已经没有剩余时也无法成立
+func (c *yakuPathCalculator) sequencesRate(starts []int) float64 {
+	covered := make([]bool, len(starts))
+	otherMelds := 0
+	for _, meld := range c.melds {
+		isCovered := false
+		if meld.MeldType == model.MeldTypeChi {
+			first := MinInt(meld.Tiles[0], MinInt(meld.Tiles[1], meld.Tiles[2]))
+			for i, start := range starts {
+				if first == start && !covered[i] {
+					covered[i] = true
+					isCovered = true
+					break
+				}
+			}
+		}
+		if !isCovered {
+			otherMelds++
+		}
+	}
+	if otherMelds > 4-len(starts) {
+		return 0
+	}
+	missing := 0
+	for i, start := range starts {
+		if covered[i] {
+			continue
+		}
+		for tile := start; tile < start+3; tile++ {
+			if c.tiles34[tile] == 0 {
+				if c.leftTiles34[tile] == 0 {
+					return 0
+				}
+				missing++
+			}
+		}
+	}
+	// 还需要把这些牌组成顺子
+	return yakuPathRate(missing + 1)
+}
+
+func (c *yakuPathCalculator) ittsuu() (rate float64) {
+	for suit := 0; suit < 3; suit++ {
+		if r := c.sequencesRate([]int{9 * suit, 9*suit + 3, 9*suit + 6}); r > rate {
+			rate = r
+		}
+	}
+	return
+}
+
+func (c *yakuPathCalculator) sanshoku() (rate float64) {
+	for start := 0; start < 7; start++ {
+		if r := c.sequencesRate([]int{start, start + 9, start + 18}); r > rate {
+			rate = r
+		}
+	}
+	return
+}
+
+// 计算 3k+1 张牌的副露手的各个役的成立概率
+func CalcYakuPaths(playerInfo *model.PlayerInfo) *YakuPathResult {
+	if len(playerInfo.LeftTiles34) == 0 {
+		playerInfo.FillLeftTiles34()
+	}
+	c := &yakuPathCalculator{
+		tiles34:     playerInfo.HandTiles34,
+		leftTiles34: playerInfo.LeftTiles34,
+		melds:       playerInfo.Melds,
+		pi:          playerInfo,
+	}
+	rates := map[int]float64{
+		YakuYakuhai:        c.yakuhai(),
+		YakuTanyao:         c.tanyao(),
+		YakuHonitsu:        c.honitsu(),
+		YakuToitoi:         c.toitoi(),
+		YakuChanta:         c.chanta(),
+		YakuIttsuu:         c.ittsuu(),
+		YakuSanshokuDoujun: c.sanshoku(),
+	}
+
+	r := &YakuPathResult{NoYakuRate: 1}
+	for yakuType, rate := range rates {
+		if rate > 0 {
+			r.Paths = append(r.Paths, YakuPath{yakuType, rate})
+			r.NoYakuRate *= 1 - rate
+		}
+	}
+	sort.Slice(r.Paths, func(i, j int) bool {
+		if r.Paths[i].Rate != r.Paths[j].Rate {
+			return r.Paths[i].Rate > r.Paths[j].Rate
+		}
+		return r.Paths[i].YakuType < r.Paths[j].YakuType
+	})
+	return r
+}
+
+// 无役的概率达到该值时，视作无役的路线
+const noYakuRouteRate = 0.7
+
+// 是否为无役的路线（副露手未听牌时）
+func (r *Hand13AnalysisResult) isNoYakuRoute() bool {
+	return r.YakuPaths != nil && r.YakuPaths.NoYakuRate >= noYakuRouteRate
+}
+
+// 将无役的路线排在后面，其余顺序不变
+func (l Hand14AnalysisResultList) sortNoYakuRoutesLast() {
+	sort.SliceStable(l, func(i, j int) bool {
+		return !l[i].Result13.isNoYakuRoute() && l[j].Result13.isNoYakuRoute()
+	})
+}
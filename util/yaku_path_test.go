@@ -0,0 +1,73 @@
+package util
+
+import (
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCalcYakuPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	chi234m := model.Meld{MeldType: model.MeldTypeChi, Tiles: []int{1, 2, 3}}
+	chi123p := model.Meld{MeldType: model.MeldTypeChi, Tiles: []int{9, 10, 11}}
+	pon7z := model.Meld{MeldType: model.MeldTypePon, Tiles: []int{33, 33, 33}}
+
+	rates := func(r *YakuPathResult) map[int]float64 {
+		m := map[int]float64{}
+		for _, p := range r.Paths {
+			m[p.YakuType] = p.Rate
+		}
+		return m
+	}
+
+	// 断幺：没有幺九牌
+	r := CalcYakuPaths(model.NewSimplePlayerInfo(MustStrToTiles34("567p 345s 66s 78p 3m"), []model.Meld{chi234m}))
+	assert.InDelta(0.9, rates(r)[YakuTanyao], 1e-9)
+	assert.InDelta(0.1, r.NoYakuRate, 1e-9)
+	assert.Equal("断幺90%", r.String())
+
+	// 断幺：还有三张幺九牌，其余役都很难
+	r = CalcYakuPaths(model.NewSimplePlayerInfo(MustStrToTiles34("567p 345s 68s 19p 2z"), []model.Meld{chi234m}))
+	assert.Len(r.Paths, 1)
+	assert.InDelta(0.75, r.NoYakuRate, 1e-9)
+
+	// 一气通贯和混一色
+	r = CalcYakuPaths(model.NewSimplePlayerInfo(MustStrToTiles34("456p 78p 99p 1m 22z"), []model.Meld{chi123p}))
+	assert.InDelta(0.7, rates(r)[YakuIttsuu], 1e-9)
+	assert.InDelta(0.7, rates(r)[YakuHonitsu], 1e-9)
+	assert.Zero(rates(r)[YakuTanyao])
+	assert.Equal(YakuIttsuu, r.Paths[0].YakuType)
+
+	// 役牌对子，以及碰出役牌后役已确定
+	r = CalcYakuPaths(model.NewSimplePlayerInfo(MustStrToTiles34("456m 78p 11s 4z 55z"), []model.Meld{chi123p}))
+	assert.InDelta(yakuhaiPairRates[2], rates(r)[YakuYakuhai], 1e-9)
+	r = CalcYakuPaths(model.NewSimplePlayerInfo(MustStrToTiles34("456m 78p 11s 4z 55z"), []model.Meld{pon7z}))
+	assert.InDelta(1, rates(r)[YakuYakuhai], 1e-9)
+	assert.Zero(r.NoYakuRate)
+
+	// 役牌对子没有剩余牌时无法碰出
+	pi := model.NewSimplePlayerInfo(MustStrToTiles34("456m 78p 11s 4z 55z"), []model.Meld{chi123p})
+	pi.LeftTiles34[31] = 0
+	assert.Zero(rates(CalcYakuPaths(pi))[YakuYakuhai])
+}
+
+func TestHand14AnalysisResultList_sortNoYakuRoutesLast(t *testing.T) {
+	assert := assert.New(t)
+
+	newResult := func(discardTile int, paths *YakuPathResult) *Hand14AnalysisResult {
+		return &Hand14AnalysisResult{DiscardTile: discardTile, Result13: &Hand13AnalysisResult{YakuPaths: paths}}
+	}
+	l := Hand14AnalysisResultList{
+		newResult(0, &YakuPathResult{NoYakuRate: 0.9}),
+		newResult(1, nil),
+		newResult(2, &YakuPathResult{NoYakuRate: 0.1}),
+		newResult(3, &YakuPathResult{NoYakuRate: 0.75}),
+	}
+	l.sortNoYakuRoutesLast()
+	var discards []int
+	for _, r := range l {
+		discards = append(discards, r.DiscardTile)
+	}
+	assert.Equal([]int{1, 2, 0, 3}, discards)
+}
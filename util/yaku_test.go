@@ -9,15 +9,18 @@ import (
 )
 
 func calcStrYaku(humanTiles string, humanWinTile string, isTsumo bool, melds ...model.Meld) string {
-	output := ""
-	pi := &model.PlayerInfo{
+	return calcStrYakuWithPlayerInfo(&model.PlayerInfo{
 		HandTiles34:   MustStrToTiles34(humanTiles),
 		Melds:         melds,
 		IsTsumo:       isTsumo,
 		WinTile:       MustStrToTile34(humanWinTile),
 		RoundWindTile: 27,
 		SelfWindTile:  27,
-	}
+	})
+}
+
+func calcStrYakuWithPlayerInfo(pi *model.PlayerInfo) string {
+	output := ""
 	isNaki := pi.IsNaki()
 	for _, result := range DivideTiles34(pi.HandTiles34) {
 		yakuTypes := findYakuTypes(&_handInfo{
@@ -57,6 +60,8 @@ func Test_findYakuTypes(t *testing.T) {
 	assert.Equal("[四暗刻单骑 大四喜 字一色]", calcStrYaku("11122233344455z", "5z", false))
 	assert.Equal("[大三元]", calcStrYaku("12333m 555666777z", "1m", false))
 	assert.Equal("[清老头]", calcStrYaku("111999m 111999s 11p", "1m", false))
+	assert.Equal("[国士]", calcStrYaku("19m 19p 19s 12345677z", "1z", false))
+	assert.Equal("[国士十三面]", calcStrYaku("19m 19p 19s 12345677z", "7z", true))
 
 	// 三暗刻判定
 	assert.Equal("[三色同刻]", calcStrYaku("333m 333p 333567s 11z", "3m", false))
@@ -100,6 +105,57 @@ func Test_findYakuTypes(t *testing.T) {
 	))
 }
 
+func Test_findSituationalYakuTypes(t *testing.T) {
+	assert := assert.New(t)
+
+	newPI := func(humanTiles string, humanWinTile string, isTsumo bool, melds ...model.Meld) *model.PlayerInfo {
+		return &model.PlayerInfo{
+			HandTiles34:   MustStrToTiles34(humanTiles),
+			Melds:         melds,
+			IsTsumo:       isTsumo,
+			WinTile:       MustStrToTile34(humanWinTile),
+			RoundWindTile: 27,
+			SelfWindTile:  28,
+		}
+	}
+	pon := model.Meld{MeldType: model.MeldTypePon, Tiles: MustStrToTiles("333s")}
+	minkan := model.Meld{MeldType: model.MeldTypeMinkan, Tiles: MustStrToTiles("3333s")}
+
+	pi := newPI("345m 222789p 333s 66z", "3m", false)
+	pi.IsRiichi = true
+	pi.IsIppatsu = true
+	assert.Equal("[立直 一发]", calcStrYakuWithPlayerInfo(pi))
+	pi.IsRiichi = false
+	assert.Equal("[无役]", calcStrYakuWithPlayerInfo(pi), "没有立直时不算一发")
+
+	pi = newPI("345m 222789p 66z", "3m", true, pon)
+	pi.IsLastTile = true
+	assert.Equal("[海底]", calcStrYakuWithPlayerInfo(pi))
+	pi.IsTsumo = false
+	assert.Equal("[河底]", calcStrYakuWithPlayerInfo(pi))
+	pi.IsLastTile = false
+	pi.IsChankan = true
+	assert.Equal("[抢杠]", calcStrYakuWithPlayerInfo(pi))
+
+	pi = newPI("345m 222789p 66z", "3m", true, minkan)
+	pi.IsRinshan = true
+	pi.IsLastTile = true
+	assert.Equal("[岭上]", calcStrYakuWithPlayerInfo(pi), "岭上牌不算海底")
+
+	pi = newPI("345m 222789p 333s 66z", "3m", true)
+	pi.IsFirstDraw = true
+	pi.IsParent = true
+	assert.Equal("[天和]", calcStrYakuWithPlayerInfo(pi))
+	pi.IsParent = false
+	assert.Equal("[地和]", calcStrYakuWithPlayerInfo(pi))
+	pi.IsTsumo = false
+	assert.Equal("[无役]", calcStrYakuWithPlayerInfo(pi), "荣和不算地和")
+
+	pi = newPI("19m 19p 19s 12345677z", "1z", true)
+	pi.IsFirstDraw = true
+	assert.Equal("[国士 地和]", calcStrYakuWithPlayerInfo(pi))
+}
+
 func Test_findOldYakuTypes(t *testing.T) {
 	considerOldYaku = true
 
@@ -1,5 +1,15 @@
 package util
 
+// 门清限定
+func (hi *_handInfo) kokushi() bool {
+	return hi.divideResult.IsKokushi && hi.WinTile != hi.divideResult.PairTile
+}
+
+// 门清限定，十三面听牌
+func (hi *_handInfo) kokushi13() bool {
+	return hi.divideResult.IsKokushi && hi.WinTile == hi.divideResult.PairTile
+}
+
 // 门清限定
 func (hi *_handInfo) suuAnkou() bool {
 	if hi.WinTile == hi.divideResult.PairTile {
@@ -139,11 +149,23 @@ func (hi *_handInfo) chuuren9() bool {
 	return hi.divideResult.IsChuurenPoutou && hi._isChuuren9()
 }
 
+// 亲家配牌即和牌
+func (hi *_handInfo) tenhou() bool {
+	return hi.IsFirstDraw && hi.IsTsumo && hi.IsParent && len(hi.Melds) == 0
+}
+
+// 子家第一巡摸牌自摸，且此前无人鸣牌
+func (hi *_handInfo) chiihou() bool {
+	return hi.IsFirstDraw && hi.IsTsumo && !hi.IsParent && len(hi.Melds) == 0
+}
+
 func (hi *_handInfo) suuKantsu() bool {
 	return hi.numKantsu() == 4
 }
 
 var yakumanCheckerMap = map[int]yakuChecker{
+	YakuKokushi:       (*_handInfo).kokushi,
+	YakuKokushi13:     (*_handInfo).kokushi13,
 	YakuSuuAnkou:      (*_handInfo).suuAnkou,
 	YakuSuuAnkouTanki: (*_handInfo).suuAnkouTanki,
 	YakuDaisangen:     (*_handInfo).daisangen,
@@ -155,6 +177,8 @@ var yakumanCheckerMap = map[int]yakuChecker{
 	YakuChuuren:       (*_handInfo).chuuren,
 	YakuChuuren9:      (*_handInfo).chuuren9,
 	YakuSuuKantsu:     (*_handInfo).suuKantsu,
+	YakuTenhou:        (*_handInfo).tenhou,
+	YakuChiihou:       (*_handInfo).chiihou,
 }
 
 //
@@ -199,6 +223,22 @@ var oldYakumanCheckerMap = map[int]yakuChecker{
 // 结果未排序
 // *计算前必须设置顺子牌和刻子牌
 func findYakumanTypes(hi *_handInfo, isNaki bool) (yakumanTypes []int) {
+	// 国士无双的拆解没有面子，不能再检测其他役满
+	if hi.divideResult.IsKokushi {
+		if isNaki {
+			return nil
+		}
+		for _, yakuman := range []int{YakuKokushi, YakuKokushi13, YakuTenhou, YakuChiihou} {
+			if yakumanCheckerMap[yakuman](hi) {
+				yakumanTypes = append(yakumanTypes, yakuman)
+			}
+		}
+		if ruleset.isRenhouYakuman() && hi.renhou() {
+			yakumanTypes = append(yakumanTypes, YakuRenhou)
+		}
+		return
+	}
+
 	var yakumanTimesMap _yakumanTimesMap
 	if !isNaki {
 		yakumanTimesMap = YakumanTimesMap
@@ -214,6 +254,11 @@ func findYakumanTypes(hi *_handInfo, isNaki bool) (yakumanTypes []int) {
 		}
 	}
 
+	// 人和为役满时，可以和其他役满复合
+	if !isNaki && ruleset.isRenhouYakuman() && hi.renhou() {
+		yakumanTypes = append(yakumanTypes, YakuRenhou)
+	}
+
 	if considerOldYaku && !isNaki {
 		for yakuman := range OldYakumanTimesMap {
 			if checker, ok := oldYakumanCheckerMap[yakuman]; ok {
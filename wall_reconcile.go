@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/fatih/color"
+)
+
+// 牌山剩余牌量的校验：每个事件处理完后，根据可见的牌（自家手牌、各家牌河、副露、宝牌指示牌、拔北）重新计算剩余牌量
+// - 若可见的牌本身是合理的，仅剩余牌量不一致，则直接修正剩余牌量
+// - 若可见的牌不合理（某种牌超过 4 枚、自家手牌数量不对），说明漏收了消息（断线、重连、切换座位等），
+//   此时暂停输出分析结果，直到收到完整的场况（新的一局、雀魂的 SyncGameActions、天凤的 REINIT）
+
+// 重连时能提供完整场况的数据源，如天凤的 REINIT
+type reinitParser interface {
+	IsReinit() bool
+	ParseReinit() *reinitState
+}
+
+// 重连时的场况，下标为 0=自家, 1=下家, 2=对家, 3=上家
+type reinitState struct {
+	// 各家舍牌，不区分手切摸切
+	// 被鸣的牌也在其中
+	discardTiles [4][]int
+
+	melds [4][]*model.Meld
+
+	// 立直宣言牌在 discardTiles 中的下标，未立直时为 -1
+	reachTileAt [4]int
+
+	nukiDoraNums [4]int
+}
+
+// 一局开始时的剩余牌量
+func (d *roundData) initLeftCounts() []int {
+	leftCounts := util.InitLeftTiles34()
+	if d.playerNumber == 3 {
+		// 三麻没有 2-8m
+		for i := 1; i <= 7; i++ {
+			leftCounts[i] = 0
+		}
+	}
+	return leftCounts
+}
+
+// 自家视角下可见的牌
+func (d *roundData) visibleTiles34() []int {
+	visible := make([]int, 34)
+	for tile, c := range d.counts {
+		visible[tile] += c
+	}
+	for _, tile := range d.doraIndicators {
+		visible[tile]++
+	}
+	for _, player := range d.players {
+		for _, tile := range player.discardTiles {
+			if tile < 0 {
+				tile = ^tile
+			}
+			visible[tile]++
+		}
+		for _, meld := range player.melds {
+			for _, tile := range meld.Tiles {
+				visible[tile]++
+			}
+			// 鸣的牌已经计入了牌河
+			if meld.MeldType != meldTypeAnkan {
+				visible[meld.CalledTile]--
+			}
+		}
+		visible[30] += player.nukiDoraNum
+	}
+	return visible
+}
+
+// 检查可见的牌是否合理，不合理时返回原因
+func (d *roundData) checkVisibleTiles(visible []int) string {
+	for tile, c := range visible {
+		if c > 4 {
+			return fmt.Sprintf("%s 可见 %d 枚", util.MahjongZH[tile], c)
+		}
+		if d.playerNumber == 3 && tile >= 1 && tile <= 7 && c > 0 {
+			return fmt.Sprintf("三麻出现了 %s", util.MahjongZH[tile])
+		}
+	}
+	// 手牌为空时（如观战）不检查手牌数量
+	if handsCount := util.CountOfTiles34(d.counts); handsCount > 0 {
+		if cnt := handsCount + 3*len(d.players[0].melds); cnt != 13 && cnt != 14 {
+			return fmt.Sprintf("自家手牌 %d 张，副露 %d 组", handsCount, len(d.players[0].melds))
+		}
+	}
+	return ""
+}
+
+func (d *roundData) markDesync(reason string) {
+	if d.desyncReason != "" {
+		return
+	}
+	d.desyncReason = reason
+	color.HiRed("数据不同步（%s），暂停分析，等待重连或下一局", reason)
+}
+
+// 根据可见的牌校验并修正剩余牌量
+func (d *roundData) reconcileLeftCounts() {
+	if d.desyncReason != "" {
+		return
+	}
+
+	visible := d.visibleTiles34()
+	if reason := d.checkVisibleTiles(visible); reason != "" {
+		d.markDesync(reason)
+		return
+	}
+
+	leftCounts := d.initLeftCounts()
+	var fixedTiles []int
+	for tile, c := range visible {
+		leftCounts[tile] -= c
+		if leftCounts[tile] != d.leftCounts[tile] {
+			fixedTiles = append(fixedTiles, tile)
+		}
+	}
+	if len(fixedTiles) == 0 {
+		return
+	}
+	if debugMode {
+		for _, tile := range fixedTiles {
+			fmt.Printf("修正剩余牌量：%s %d -> %d\n", util.MahjongZH[tile], d.leftCounts[tile], leftCounts[tile])
+		}
+	}
+	copy(d.leftCounts, leftCounts)
+}
+
+// 在输出分析结果前校验剩余牌量，发现不同步时不输出本次事件的分析结果
+func (d *roundData) reconcileBeforeOutput() {
+	d.reconcileLeftCounts()
+	if d.desyncReason != "" {
+		d.skipOutput = true
+	}
+}
+
+// 根据重连时的场况恢复各家的牌河和副露，之后由 reconcileLeftCounts 重新计算剩余牌量
+func (d *roundData) restoreReinitState(s *reinitState) {
+	// 不知道各家舍牌的先后顺序，从庄家开始依次排列
+	for i := 0; ; i++ {
+		restored := false
+		for j := 0; j < len(d.players); j++ {
+			who := (d.dealer + j) % len(d.players)
+			if i >= len(s.discardTiles[who]) {
+				continue
+			}
+			restored = true
+			tile := s.discardTiles[who][i]
+			player := d.players[who]
+			d.globalDiscardTiles = append(d.globalDiscardTiles, tile)
+			player.discardTiles = append(player.discardTiles, tile)
+			player.latestDiscardAtGlobal = len(d.globalDiscardTiles) - 1
+			if i == s.reachTileAt[who] {
+				player.isReached = true
+				player.reachTileAtGlobal = player.latestDiscardAtGlobal
+				player.reachTileAt = i
+			}
+			if !player.isReached && len(player.discardTiles) <= 5 {
+				player.earlyOutsideTiles = append(player.earlyOutsideTiles, util.OutsideTiles(tile)...)
+			}
+		}
+		if !restored {
+			break
+		}
+	}
+
+	for who, player := range d.players {
+		for _, meld := range s.melds[who] {
+			player.melds = append(player.melds, meld)
+			if meld.MeldType != meldTypeAnkan {
+				player.isNaki = true
+			}
+			// 不知道鸣牌的时机，视作最近一次舍牌时鸣牌
+			player.meldDiscardsAt = append(player.meldDiscardsAt, len(player.discardTiles)-1)
+			player.meldDiscardsAtGlobal = append(player.meldDiscardsAtGlobal, player.latestDiscardAtGlobal)
+		}
+		player.nukiDoraNum = s.nukiDoraNums[who]
+	}
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/EndlessCheng/mahjong-helper/util"
+	"github.com/EndlessCheng/mahjong-helper/util/model"
+	"github.com/stretchr/testify/assert"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+// 返回 f 执行期间输出到 stdout 的内容
+func captureStdout(t *testing.T, f func()) string {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	defer func() { os.Stdout = stdout }()
+
+	f()
+
+	w.Close()
+	out, err := ioutil.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestReconcileLeftCounts(t *testing.T) {
+	assert := assert.New(t)
+
+	d := &tenhouRoundData{isRoundEnd: true}
+	d.roundData = newGame(d)
+	d.skipOutput = true
+	d.doraIndicators = []int{0}
+	d.counts = util.MustStrToTiles34("123456789m 1234p")
+	d.players[1].discardTiles = []int{27, ^27}
+	d.players[2].melds = append(d.players[2].melds, &model.Meld{MeldType: meldTypePon, Tiles: []int{27, 27, 27}, CalledTile: 27})
+
+	// 剩余牌量不一致时直接修正
+	d.leftCounts[5] = 0
+	d.leftCounts[27] = 4
+	d.reconcileLeftCounts()
+	assert.Empty(d.desyncReason)
+	assert.Equal(2, d.leftCounts[0])
+	assert.Equal(3, d.leftCounts[5])
+	assert.Equal(0, d.leftCounts[27])
+	assert.Equal(4, d.leftCounts[33])
+
+	// 某种牌超过 4 枚时标记为不同步，之后不再修正
+	d.players[3].discardTiles = []int{27}
+	d.reconcileLeftCounts()
+	assert.NotEmpty(d.desyncReason)
+	d.leftCounts[5] = 0
+	d.reconcileLeftCounts()
+	assert.Equal(0, d.leftCounts[5])
+
+	// 不同步时不输出分析结果
+	d.msg = &tenhouMessage{Tag: "E8"}
+	d.skipOutput = false
+	assert.NoError(d.analysis())
+	assert.False(d.skipOutput)
+	assert.NotEmpty(d.desyncReason)
+
+	// 新的一局时恢复
+	d.reset(1, 0, 3)
+	assert.Empty(d.desyncReason)
+	assert.Equal(util.InitLeftTiles34(), d.leftCounts)
+
+	// 本次事件导致不同步时，不输出本次事件的分析结果
+	d.counts = util.MustStrToTiles34("123456789m 1234p")
+	d.players[1].discardTiles = []int{2, 2}
+	d.players[2].discardTiles = []int{2}
+	d.reconcileLeftCounts()
+	assert.Empty(d.desyncReason)
+	d.msg = &tenhouMessage{Tag: "E8"}
+	d.skipOutput = false
+	out := captureStdout(t, func() {
+		assert.NoError(d.analysis())
+	})
+	assert.Empty(out)
+	assert.NotEmpty(d.desyncReason)
+	assert.False(d.skipOutput)
+
+	d.reset(1, 0, 3)
+	assert.Empty(d.desyncReason)
+	assert.Equal(util.InitLeftTiles34(), d.leftCounts)
+
+	// 三麻
+	d.playerNumber = 3
+	d.reset(0, 0, 0)
+	d.counts = util.MustStrToTiles34("19m 123456789p 111z")
+	d.reconcileLeftCounts()
+	assert.Empty(d.desyncReason)
+	assert.Equal(0, d.leftCounts[1])
+	assert.Equal(3, d.leftCounts[0])
+	d.players[1].discardTiles = []int{3}
+	d.reconcileLeftCounts()
+	assert.NotEmpty(d.desyncReason)
+}
+
+func TestTenhouReinit(t *testing.T) {
+	assert := assert.New(t)
+
+	d := &tenhouRoundData{isRoundEnd: true}
+	d.roundData = newGame(d)
+	d.skipOutput = true
+
+	// 自家碰了上家的 1z，下家切 3m 立直，被碰的 1z 不在牌河中
+	d.msg = &tenhouMessage{
+		Tag:    "REINIT",
+		Seed:   "1,0,1,1,2,100",
+		Ten:    "250,240,250,250",
+		Dealer: "3",
+		Hai:    "20,24,28,52,56,60,84,88,92,132",
+		Meld0:  "41579",
+		Kawa1:  "0,4,255,8",
+		Kawa3:  "36,40",
+	}
+	assert.NoError(d.analysis())
+	assert.Empty(d.desyncReason)
+
+	assert.Equal([]int{0, 1, 2}, d.players[1].discardTiles)
+	assert.True(d.players[1].isReached)
+	assert.Equal(2, d.players[1].reachTileAt)
+	assert.Equal([]int{9, 10, 27}, d.players[3].discardTiles)
+	assert.Len(d.globalDiscardTiles, 6)
+	if assert.Len(d.players[0].melds, 1) {
+		meld := d.players[0].melds[0]
+		assert.Equal(meldTypePon, meld.MeldType)
+		assert.Equal([]int{27, 27, 27}, meld.Tiles)
+	}
+	assert.True(d.players[0].isNaki)
+
+	assert.Equal(1, d.leftCounts[27])
+	assert.Equal(3, d.leftCounts[0])
+	assert.Equal(3, d.leftCounts[25]) // 宝牌指示牌
+	assert.Equal(3, d.leftCounts[33])
+	assert.Equal(4, d.leftCounts[32])
+}
+
+// 雀魂的 SyncGameActions 和 ChangeSeatTo 会重新载入整局的操作，此时从不同步中恢复
+func TestMajsoulResync(t *testing.T) {
+	assert := assert.New(t)
+
+	// 没有账号时消息会被跳过
+	accountID := gameConf.currentActiveMajsoulAccountID
+	defer gameConf.setMajsoulAccountID(accountID)
+	gameConf.setMajsoulAccountID(1)
+
+	actions := majsoulRoundActions{}
+	assert.NoError(json.Unmarshal([]byte(`[
+		{"name": "RecordNewRound", "data": {"chang": 0, "ju": 0, "ben": 0, "dora": "1z", "md5": "x",
+			"tiles0": ["1m", "2m", "3m", "4m", "5m", "6m", "7m", "8m", "9m", "1p", "2p", "3p", "1z", "2z"],
+			"tiles1": ["1s", "2s", "3s", "4s", "5s", "6s", "7s", "8s", "9s", "4p", "5p", "6p", "7p"],
+			"tiles2": ["1m", "2m", "3m", "4m", "5m", "6m", "7m", "8m", "9m", "1p", "2p", "3p", "3z"],
+			"tiles3": ["1s", "2s", "3s", "4s", "5s", "6s", "7s", "8s", "9s", "4p", "5p", "6p", "4z"]}},
+		{"name": "RecordDiscardTile", "data": {"seat": 0, "tile": "2z", "moqie": false, "is_liqi": false, "is_wliqi": false}},
+		{"name": "RecordDealTile", "data": {"seat": 1, "tile": "7z"}},
+		{"name": "RecordDiscardTile", "data": {"seat": 1, "tile": "7z", "moqie": true, "is_liqi": false, "is_wliqi": false}},
+		{"name": "RecordDealTile", "data": {"seat": 2, "tile": "6z"}},
+		{"name": "RecordDiscardTile", "data": {"seat": 2, "tile": "6z", "moqie": true, "is_liqi": false, "is_wliqi": false}}
+	]`), &actions))
+
+	newHandler := func(selfSeat int) *mjHandler {
+		d := &majsoulRoundData{selfSeat: selfSeat}
+		d.roundData = newGame(d)
+		d.desyncReason = "1z 可见 5 枚"
+		return &mjHandler{
+			majsoulMessageQueue: make(chan []byte, 10),
+			majsoulRoundData:    d,
+		}
+	}
+	run := func(h *mjHandler, msg interface{}) {
+		data, err := json.Marshal(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.majsoulMessageQueue <- data
+		close(h.majsoulMessageQueue)
+		h.runAnalysisMajsoulMessageTask()
+	}
+	assertSynced := func(d *majsoulRoundData) {
+		assert.Empty(d.desyncReason)
+		assert.False(d.skipOutput)
+		assert.Len(d.globalDiscardTiles, 3)
+		leftCounts := d.initLeftCounts()
+		for tile, c := range d.visibleTiles34() {
+			leftCounts[tile] -= c
+		}
+		assert.Equal(leftCounts, d.leftCounts)
+	}
+
+	// 重连
+	h := newHandler(0)
+	run(h, struct {
+		SyncGameActions majsoulRoundActions `json:"sync_game_actions"`
+	}{actions})
+	assertSynced(h.majsoulRoundData)
+	assert.Equal(util.MustStrToTiles34("123456789m 123p 1z"), h.majsoulRoundData.counts)
+
+	// 观战时切换座位
+	h = newHandler(0)
+	h.majsoulRoundData.gameMode = gameModeLive
+	h.majsoulCurrentRoundActions = actions
+	run(h, struct {
+		ChangeSeatTo int `json:"change_seat_to"`
+	}{1})
+	assertSynced(h.majsoulRoundData)
+	assert.Equal(1, h.majsoulRoundData.selfSeat)
+	assert.Equal(util.MustStrToTiles34("123456789s 4567p"), h.majsoulRoundData.counts)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"github.com/labstack/echo/v4"
+	"net/http"
+)
+
+// 网页版何切分析，调用 /analysis 获取 JSON 格式的分析结果
+func (h *mjHandler) webUI(c echo.Context) error {
+	return c.HTML(http.StatusOK, webUIHTML)
+}
+
+// 注意不要在其中使用反引号
+const webUIHTML = `<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>日本麻将助手</title>
+<style>
+body { font-family: sans-serif; margin: 16px; background: #1e1e1e; color: #ddd; }
+form { display: flex; flex-wrap: wrap; gap: 8px 16px; align-items: center; margin-bottom: 16px; }
+input, select, button { font-size: 15px; padding: 4px 6px; }
+#tiles { width: 320px; }
+table { border-collapse: collapse; margin-bottom: 16px; }
+th, td { padding: 3px 8px; text-align: left; white-space: nowrap; }
+th { color: #999; font-weight: normal; border-bottom: 1px solid #555; }
+tr.best td { color: #fff; }
+.good { color: #6c6; } .warn { color: #dd5; } .bad { color: #e66; } .dim { color: #888; }
+.improves { color: #999; font-size: 13px; white-space: normal; max-width: 480px; }
+#error { color: #e66; }
+</style>
+</head>
+<body>
+<form id="form">
+  <label>手牌 <input id="tiles" placeholder="24688m 34s # 6666P 234p + 3m" autofocus></label>
+  <label>宝牌 <input id="dora" size="8" placeholder="13m6p"></label>
+  <label>场风 <select id="round_wind"><option value="1z">东</option><option value="2z">南</option><option value="3z">西</option><option value="4z">北</option></select></label>
+  <label>自风 <select id="self_wind"><option value="">--</option><option value="1z">东</option><option value="2z">南</option><option value="3z">西</option><option value="4z">北</option></select></label>
+  <label>巡目 <input id="turn" type="number" min="0" max="18" size="3" value="0"></label>
+  <label>舍牌 <input id="discards" size="16" placeholder="19m1z"></label>
+  <button type="submit">分析</button>
+</form>
+<div id="error"></div>
+<div id="result"></div>
+<script>
+var shantenNames = ["听牌", "一向听", "两向听", "三向听", "四向听", "五向听", "六向听", "七向听", "八向听"];
+
+function shantenName(shanten) {
+  return shanten === -1 ? "和了" : (shantenNames[shanten] || shanten + "向听");
+}
+
+function escapeHTML(s) {
+  return String(s).replace(/[&<>"]/g, function (c) {
+    return {"&": "&amp;", "<": "&lt;", ">": "&gt;", '"': "&quot;"}[c];
+  });
+}
+
+function waitTiles(waits) {
+  return Object.keys(waits).filter(function (t) { return waits[t] > 0; }).sort().join(" ");
+}
+
+function riskClass(risk) {
+  if (risk >= 15) return "bad";
+  if (risk >= 5) return "warn";
+  return "";
+}
+
+// 与命令行输出的单行何切分析结果对应
+function resultRow(r13, discard, isBest) {
+  var cells = [];
+  cells.push(r13.waits_count);
+  cells.push(r13.improve_way_count > 0 ? r13.avg_improve_waits_count.toFixed(2) : "");
+  if (discard) {
+    var open = discard.open_tiles.length > 0 ? discard.open_tiles.join("") + " 鸣，" : "";
+    cells.push('<span class="' + riskClass(discard.risk) + '">' + open + "切 " + discard.discard_tile + (discard.is_discard_dora_tile ? "（宝牌）" : "") + "</span>");
+  } else {
+    cells.push("");
+  }
+  if (r13.shanten >= 1) {
+    cells.push(r13.avg_next_shanten_waits_count.toFixed(2) + " " + shantenName(r13.shanten - 1) + (r13.shanten === 1 ? "数" : ""));
+  } else {
+    var cls = r13.furiten_rate === 1 || r13.is_part_wait ? "bad" : "";
+    cells.push('<span class="' + cls + '">' + r13.agari_rate.toFixed(2) + "% 参考和率</span>");
+  }
+  cells.push(r13.mixed_waits_score > 0 && r13.shanten >= 1 && r13.shanten <= 2 ? r13.mixed_waits_score.toFixed(2) : "");
+  cells.push(r13.dama_point > 0 ? '<span class="good">' + (r13.is_naki ? "荣和" : "默听") + Math.round(r13.dama_point) + "</span>" : "");
+  cells.push(r13.riichi_point > 0 ? '<span class="good">立直' + Math.round(r13.riichi_point) + "</span>" : "");
+  var yaku = r13.yaku.map(function (y) { return y.name; }).join(" ");
+  if (!yaku && r13.is_naki && r13.shanten >= 0 && r13.shanten <= 2) {
+    yaku = '<span class="bad">无役</span>';
+  }
+  if (r13.is_part_wait) yaku += ' <span class="bad">片听</span>';
+  cells.push(yaku);
+  cells.push(r13.furiten_rate > 0 ? (r13.furiten_rate < 1 ? '<span class="warn">可能振听</span>' : '<span class="bad">振听</span>') : "");
+  cells.push(waitTiles(r13.waits));
+  var improves = Object.keys(r13.improves).sort().map(function (t) { return "摸" + t + "→" + r13.improves[t]; }).join(" ");
+  cells.push('<span class="improves">' + improves + "</span>");
+  return "<tr" + (isBest ? ' class="best"' : "") + "><td>" + cells.join("</td><td>") + "</td></tr>";
+}
+
+var header = "<tr><th>进张</th><th>改良均值</th><th>切牌</th><th>前进后</th><th>速度</th><th>默听</th><th>立直</th><th>役</th><th>振听</th><th>进张牌</th><th>改良</th></tr>";
+
+function resultsTable(results) {
+  if (results.length === 0) return "";
+  var title = (results[0].open_tiles.length > 0 ? "鸣牌后" : "") + shantenName(results[0].result13.shanten) + "：";
+  var rows = results.map(function (r, i) { return resultRow(r.result13, r, i === 0); });
+  return "<div>" + title + "</div><table>" + header + rows.join("") + "</table>";
+}
+
+function render(data) {
+  var html = "<h3>" + escapeHTML(data.hands) + (data.target_tile ? " + " + data.target_tile + "?" : "") + "</h3>";
+  if (data.result13) {
+    html += "<div>当前" + shantenName(data.result13.shanten) + "：</div><table>" + header + resultRow(data.result13, null, false) + "</table>";
+  }
+  if (data.shanten === -1 && !data.target_tile) {
+    html += '<div class="bad">【已和牌】</div>';
+  }
+  html += resultsTable(data.results14);
+  html += resultsTable(data.inc_shanten_results14);
+  document.getElementById("result").innerHTML = html;
+}
+
+document.getElementById("form").addEventListener("submit", function (e) {
+  e.preventDefault();
+  var body = {json: true, turn: parseInt(document.getElementById("turn").value, 10) || 0};
+  ["tiles", "dora", "round_wind", "self_wind", "discards"].forEach(function (id) {
+    body[id] = document.getElementById(id).value.trim();
+  });
+  document.getElementById("error").textContent = "计算中……";
+  fetch("/analysis", {method: "POST", headers: {"Content-Type": "application/json"}, body: JSON.stringify(body)})
+    .then(function (resp) {
+      if (resp.status === 403) throw new Error("正在计算上一个请求，请稍后再试");
+      return resp.json().then(function (data) {
+        if (!resp.ok) throw new Error(data.error || resp.statusText);
+        return data;
+      });
+    })
+    .then(function (data) {
+      document.getElementById("error").textContent = "";
+      render(data);
+    })
+    .catch(function (err) {
+      document.getElementById("error").textContent = err.message;
+    });
+});
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWebUI(t *testing.T) {
+	h := &mjHandler{}
+	e := echo.New()
+	e.GET("/ui", h.webUI)
+	e.POST("/analysis", h.analysis)
+
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ui", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `fetch("/analysis"`)
+
+	post := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/analysis", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// 南家时南也是役牌
+	rec = post(`{"json": true, "tiles": "123m 456p 789s 1122z", "round_wind": "1z", "self_wind": "2z", "turn": 5, "discards": "9m1p"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	result := &analysisJSON{}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), result))
+	if assert.NotNil(t, result.Result13) {
+		assert.Equal(t, map[string]int{"1z": 2, "2z": 2}, result.Result13.DamaWaits)
+	}
+
+	rec = post(`{"json": true, "tiles": "123m 456p 789s 1122z", "self_wind": "5z"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "error")
+}